@@ -0,0 +1,214 @@
+package glox
+
+import (
+	"math"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// LoxArray is a growable array of values, created with [a, b, c] literals. Arrays are
+// mutable and shared by reference, like instances.
+type LoxArray struct {
+	elements []interface{}
+}
+
+func NewLoxArray(elements []interface{}) *LoxArray {
+	return &LoxArray{elements: elements}
+}
+
+// Len returns the number of elements of the array.
+func (a *LoxArray) Len() int {
+	return len(a.elements)
+}
+
+// Elements returns the elements of the array. The slice is shared with the array.
+func (a *LoxArray) Elements() []interface{} {
+	return a.elements
+}
+
+func (a *LoxArray) Iterate() Iterator {
+	return &arrayIterator{array: a}
+}
+
+// concat returns a new array with the elements of the array followed by the elements of
+// the other one, the + operator. Neither array changes.
+func (a *LoxArray) concat(other *LoxArray) *LoxArray {
+	elements := make([]interface{}, 0, len(a.elements)+len(other.elements))
+	elements = append(elements, a.elements...)
+	return NewLoxArray(append(elements, other.elements...))
+}
+
+// index checks that the value is a whole number that is a valid index of the elements, of
+// an array or a tuple.
+func index(bracket ast.Token, value interface{}, elements []interface{}) (int, error) {
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) {
+		return 0, NewRuntimeError(bracket, "Index must be a whole number")
+	}
+
+	if number < 0 || number >= float64(len(elements)) {
+		return 0, NewRuntimeError(bracket, "Index "+formatNumber(number)+" is out of bounds")
+	}
+
+	return int(number), nil
+}
+
+func (a *LoxArray) property(name ast.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "push":
+		doc := "push(value) adds the value at the end of the array and returns the array."
+		return NewNativeFunction("push", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			a.elements = append(a.elements, arguments[0])
+			return a, nil
+		}), nil
+	case "pop":
+		doc := "pop() removes the last element of the array and returns it, or nil if the array is empty."
+		return NewNativeFunction("pop", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if len(a.elements) == 0 {
+				return nil, nil
+			}
+
+			last := a.elements[len(a.elements)-1]
+			a.elements = a.elements[:len(a.elements)-1]
+			return last, nil
+		}), nil
+	}
+
+	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+}
+
+// stringifyArray prints the array like its literal, with the elements printed like print
+// prints them. Strings are quoted so [1, "2"] isn't printed as [1, 2].
+func (i *Interpreter) stringifyArray(a *LoxArray, seen map[interface{}]bool) string {
+	if seen[a] {
+		return "[...]"
+	}
+
+	seen[a] = true
+	defer delete(seen, a)
+
+	elements := make([]string, 0, len(a.elements))
+	for _, element := range a.elements {
+		elements = append(elements, i.stringifyElement(element, seen))
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// stringifyElement prints a value held by an array or a map. seen are the collections
+// being printed, a collection that holds itself is printed as [...] or {...} the second
+// time.
+func (i *Interpreter) stringifyElement(value interface{}, seen map[interface{}]bool) string {
+	switch value := value.(type) {
+	case string:
+		return "\"" + value + "\""
+	case *LoxArray:
+		return i.stringifyArray(value, seen)
+	case *LoxMap:
+		return i.stringifyMap(value, seen)
+	case *LoxTuple:
+		return i.stringifyTuple(value, seen)
+	}
+
+	return i.stringify(value)
+}
+
+type arrayIterator struct {
+	array *LoxArray
+	next  int
+}
+
+func (ai *arrayIterator) Next() (interface{}, bool) {
+	if ai.next >= len(ai.array.elements) {
+		return nil, false
+	}
+
+	ai.next++
+	return ai.array.elements[ai.next-1], true
+}
+
+func (i *Interpreter) VisitArrayExpr(expr *ast.ArrayLiteral) (interface{}, error) {
+	elements := make([]interface{}, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, value)
+	}
+
+	return NewLoxArray(elements), nil
+}
+
+func (i *Interpreter) VisitIndexGetExpr(expr *ast.IndexGet) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *LoxArray:
+		n, err := index(expr.Bracket, key, object.elements)
+		if err != nil {
+			return nil, err
+		}
+
+		return object.elements[n], nil
+	case *LoxTuple:
+		n, err := index(expr.Bracket, key, object.elements)
+		if err != nil {
+			return nil, err
+		}
+
+		return object.elements[n], nil
+	case *LoxMap:
+		return object.get(expr.Bracket, key)
+	}
+
+	return nil, NewRuntimeError(expr.Bracket, "Only arrays, tuples and maps can be indexed")
+}
+
+func (i *Interpreter) VisitIndexSetExpr(expr *ast.IndexSet) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *LoxArray:
+		n, err := index(expr.Bracket, key, object.elements)
+		if err != nil {
+			return nil, err
+		}
+
+		object.elements[n] = value
+		return value, nil
+	case *LoxTuple:
+		return nil, NewRuntimeError(expr.Bracket, "Tuples can't be changed")
+	case *LoxMap:
+		if err := object.set(expr.Bracket, key, value); err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	}
+
+	return nil, NewRuntimeError(expr.Bracket, "Only arrays and maps can be indexed")
+}
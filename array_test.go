@@ -0,0 +1,55 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArrays(t *testing.T) {
+	output, err := runSource(t, `
+var a = [1, "two", nil];
+print a;
+print a[1];
+a[2] = 3;
+print a.push(4);
+print a.pop();
+print len(a);
+for (var x in a) print x;
+print 3 in a;
+print [];
+`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := "[1, \"two\", nil]\ntwo\n[1, \"two\", 3, 4]\n4\n3\n1\ntwo\n3\ntrue\n[]\n"
+	if output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
+
+func TestArrayIndexErrors(t *testing.T) {
+	tests := map[string]string{
+		`print [1][5];`:          "Index 5 is out of bounds",
+		`print [1][-1];`:         "Index -1 is out of bounds",
+		`print [1]["a"];`:        "Index must be a whole number",
+		`print [1][0.5];`:        "Index must be a whole number",
+		`var a = [1]; a[1] = 2;`: "Index 1 is out of bounds",
+	}
+
+	for source, message := range tests {
+		if _, err := runSource(t, source, DefaultOptions()); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected an error containing %q, got %v", source, message, err)
+		}
+	}
+}
+
+func TestArraysAreOffInTheCanonicalEdition(t *testing.T) {
+	language, _ := EditionOptions(EditionCanonical)
+	options := DefaultOptions()
+	options.LanguageOptions = language
+
+	if program, _ := Compile(`print [1, 2];`, options); program != nil {
+		t.Errorf("expected array literals not to compile in the canonical edition")
+	}
+}
@@ -0,0 +1,4 @@
+// Package ast defines the tokens and syntax tree of lox programs. It has no dependency on
+// the interpreter, so tools that only need to inspect lox source can import it without
+// pulling in the runtime.
+package ast
@@ -0,0 +1,254 @@
+package ast
+
+import "strings"
+
+type Expr interface {
+	Accept(visitor Visitor) (interface{}, error)
+}
+
+type Visitor interface {
+	VisitAssignExpr(expr *Assign) (interface{}, error)
+	VisitLogicalExpr(expr *Logical) (interface{}, error)
+	VisitBinaryExpr(expr *Binary) (interface{}, error)
+	VisitCallExpr(expr *Call) (interface{}, error)
+	VisitGroupingExpr(expr *Grouping) (interface{}, error)
+	VisitLiteralExpr(expr *Literal) (interface{}, error)
+	VisitUnaryExpr(expr *Unary) (interface{}, error)
+	VisitVarExpr(expr *VarExpr) (interface{}, error)
+	VisitGetExpr(expr *GetExpr) (interface{}, error)
+	VisitSetExpr(expr *SetExpr) (interface{}, error)
+	VisitThisExpr(expr *ThisExpr) (interface{}, error)
+	VisitSuperExpr(expr *SuperExpr) (interface{}, error)
+	VisitRangeExpr(expr *Range) (interface{}, error)
+	VisitComparisonExpr(expr *Comparison) (interface{}, error)
+	VisitArrayExpr(expr *ArrayLiteral) (interface{}, error)
+	VisitIndexGetExpr(expr *IndexGet) (interface{}, error)
+	VisitIndexSetExpr(expr *IndexSet) (interface{}, error)
+	VisitMapExpr(expr *MapLiteral) (interface{}, error)
+	VisitTupleExpr(expr *Tuple) (interface{}, error)
+}
+
+type Assign struct {
+	Name  Token
+	Value Expr
+}
+
+func (a *Assign) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAssignExpr(a)
+}
+
+type Logical struct {
+	Left Expr
+	Operator Token
+	Right Expr
+}
+
+func (l *Logical) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitLogicalExpr(l)
+}
+
+type Binary struct {
+	Left     Expr
+	Operator Token
+	Right    Expr
+}
+
+func (b *Binary) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitBinaryExpr(b)
+}
+
+type Call struct {
+	Callee Expr
+	Paren Token
+	Arguments []Expr
+	// KeywordArguments are the arguments passed by parameter name, e.g. draw(x: 10).
+	// They always follow the positional arguments.
+	KeywordArguments []KeywordArgument
+}
+
+// KeywordArgument is a single name: value pair in a call's argument list.
+type KeywordArgument struct {
+	Name  Token
+	Value Expr
+}
+
+func (c *Call) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCallExpr(c)
+}
+
+type Grouping struct {
+	Expression Expr
+}
+
+func (g *Grouping) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitGroupingExpr(g)
+}
+
+type Literal struct {
+	Value interface{}
+	// Raw is the source text of number literals, so they can be parsed again with more
+	// precision than the float64 Value has.
+	Raw string
+	// Big is set for number literals with an n suffix, like 123n, which are big numbers
+	// even outside big number mode.
+	Big bool
+}
+
+// NewBoolLiteral creates a literal holding the runtime boolean value. Synthetic nodes
+// must use it rather than the True and False token types, which are not lox values.
+func NewBoolLiteral(value bool) *Literal {
+	return &Literal{Value: value}
+}
+
+// NewNilLiteral creates a literal holding nil.
+func NewNilLiteral() *Literal {
+	return &Literal{Value: nil}
+}
+
+// NewTokenLiteral creates a literal from a string or number token, holding the value the
+// scanner parsed from the token.
+func NewTokenLiteral(token Token) *Literal {
+	if token.Type == Number {
+		return &Literal{Value: token.Literal, Raw: token.Lexeme, Big: strings.HasSuffix(token.Lexeme, "n")}
+	}
+
+	return &Literal{Value: token.Literal}
+}
+
+func (l *Literal) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitLiteralExpr(l)
+}
+
+type Unary struct {
+	Operator Token
+	Right    Expr
+}
+
+func (u *Unary) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitUnaryExpr(u)
+}
+
+type VarExpr struct {
+	Name Token
+}
+
+func (v *VarExpr) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitVarExpr(v)
+}
+
+type GetExpr struct {
+	Object Expr
+	Name Token
+}
+
+func (g *GetExpr) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitGetExpr(g)
+}
+
+type SetExpr struct {
+	Object Expr
+	Name Token
+	Value Expr
+}
+
+func (se *SetExpr) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitSetExpr(se)
+}
+
+type ThisExpr struct {
+	Keyword Token
+}
+
+func (th *ThisExpr) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitThisExpr(th)
+}
+
+type SuperExpr struct {
+	Keyword Token
+	Method Token
+}
+
+func (se *SuperExpr) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitSuperExpr(se)
+}
+// Range is a range of numbers, start..end excludes the end and start..=end includes it.
+type Range struct {
+	Start    Expr
+	Operator Token
+	End      Expr
+}
+
+// Inclusive reports if the range includes its end.
+func (r *Range) Inclusive() bool {
+	return r.Operator.Type == DotDotEqual
+}
+
+func (r *Range) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitRangeExpr(r)
+}
+
+// Comparison is a chain of two or more comparisons, a < b <= c. Operators[n] compares
+// Operands[n] with Operands[n+1]. A single comparison is a Binary node.
+type Comparison struct {
+	Operands  []Expr
+	Operators []Token
+}
+
+func (c *Comparison) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitComparisonExpr(c)
+}
+
+// ArrayLiteral is an array literal, [a, b, c].
+type ArrayLiteral struct {
+	Bracket  Token
+	Elements []Expr
+}
+
+func (a *ArrayLiteral) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitArrayExpr(a)
+}
+
+// MapLiteral is a map literal, {key: value, ...}. Keys[i] maps to Values[i].
+type MapLiteral struct {
+	Brace  Token
+	Keys   []Expr
+	Values []Expr
+}
+
+func (m *MapLiteral) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitMapExpr(m)
+}
+
+// Tuple is a tuple of two or more values, (a, b). Paren is the opening parenthesis.
+type Tuple struct {
+	Paren    Token
+	Elements []Expr
+}
+
+func (t *Tuple) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitTupleExpr(t)
+}
+
+// IndexGet reads an element of an array, array[index], or the value of a key of a map.
+// Bracket is the closing bracket, where errors about the index are reported.
+type IndexGet struct {
+	Object  Expr
+	Bracket Token
+	Index   Expr
+}
+
+func (ig *IndexGet) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexGetExpr(ig)
+}
+
+// IndexSet assigns to an element of an array, array[index] = value, or to a key of a map.
+type IndexSet struct {
+	Object  Expr
+	Bracket Token
+	Index   Expr
+	Value   Expr
+}
+
+func (is *IndexSet) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexSetExpr(is)
+}
@@ -0,0 +1,36 @@
+package ast
+
+// Span is the region of source code a construct was parsed from, from its first token to
+// its last token.
+type Span struct {
+	Start Token
+	End   Token
+}
+
+// Desugaring describes the construct a synthetic node was created from. The parser turns
+// some constructs into simpler ones, e.g. a for loop becomes a while loop wrapped in
+// blocks, and the synthetic nodes don't exist in the source the user wrote.
+type Desugaring struct {
+	// Construct is the keyword of the original construct, e.g. "for".
+	Construct string
+	// Span is where the original construct is in the source.
+	Span Span
+}
+
+// SourceMap maps the synthetic nodes created while desugaring back to the constructs they
+// were created from, so tools like debuggers and coverage reports can point at the code
+// the user wrote. Keys are either Stmt or Expr nodes.
+type SourceMap map[interface{}]Desugaring
+
+// Lookup returns the desugaring that created the node, if the node is synthetic.
+func (sm SourceMap) Lookup(node interface{}) (Desugaring, bool) {
+	desugaring, ok := sm[node]
+	return desugaring, ok
+}
+
+// Add records the nodes as created from the desugaring.
+func (sm SourceMap) Add(desugaring Desugaring, nodes ...interface{}) {
+	for _, node := range nodes {
+		sm[node] = desugaring
+	}
+}
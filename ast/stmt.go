@@ -0,0 +1,183 @@
+package ast
+
+// Stmt is the interface for lox statements. There are no place in the grammar
+// where both expressions and statements are allowed. E.g. the both operands for
+// the + operator must be expressions, the body of while loop is always statements.
+// Making a separate interface for statements will forbid us to pass statements
+// where an expression was required or vice versa.
+type Stmt interface {
+	Accept(visitor StmtVisitor) error
+}
+
+type StmtVisitor interface {
+	VisitBlockStmt(stmt *Block) error
+	VisitExpressionExpr(expr *Expression) error
+	VisitPrintExpr(expr *Print) error
+	VisitVarStmt(expr *VarStmt) error
+	VisitDestructureStmt(stmt *DestructureStmt) error
+	VisitIfStmt(stmt *IfStmt) error
+	VisitWhileStmt(stmt *WhileStmt) error
+	VisitForInStmt(stmt *ForInStmt) error
+	VisitFunctionStmt(stmt *FunctionStmt) error
+	VisitReturnStmt(stmt *ReturnStmt) error
+	VisitClassStmt(stmt *ClassStmt) error
+	VisitThrowStmt(stmt *ThrowStmt) error
+	VisitTryStmt(stmt *TryStmt) error
+	VisitBadStmt(stmt *BadStmt) error
+}
+
+type Block struct {
+	Statements []Stmt
+}
+
+func (b *Block) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBlockStmt(b)
+}
+
+type Expression struct {
+	Expression Expr
+}
+
+func (e *Expression) Accept(visitor StmtVisitor) error {
+	return visitor.VisitExpressionExpr(e)
+}
+
+type FunctionStmt struct {
+	Name   Token
+	Params []Token
+	Body   []Stmt
+}
+
+func (f *FunctionStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitFunctionStmt(f)
+}
+
+type IfStmt struct {
+	// Keyword is the 'if' token, it tells where the statement is.
+	Keyword    Token
+	Condition  Expr
+	ThenBranch Stmt
+	ElseBranch Stmt
+}
+
+func (i *IfStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitIfStmt(i)
+}
+
+type Print struct {
+	// Keyword is the 'print' token, it tells where the statement is.
+	Keyword    Token
+	Expression Expr
+}
+
+func (p *Print) Accept(visitor StmtVisitor) error {
+	return visitor.VisitPrintExpr(p)
+}
+
+type VarStmt struct {
+	Name        Token
+	Initializer Expr
+}
+
+func (v *VarStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitVarStmt(v)
+}
+
+// DestructureStmt declares a variable for every element of a tuple, var (a, b) = value;.
+// Paren is the '(' of the names, where errors about unpacking are reported.
+type DestructureStmt struct {
+	Paren       Token
+	Names       []Token
+	Initializer Expr
+}
+
+func (d *DestructureStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitDestructureStmt(d)
+}
+
+type WhileStmt struct {
+	// Keyword is the 'while' token, or the 'for' token of the loop the while
+	// statement was desugared from.
+	Keyword   Token
+	Condition Expr
+	Body      Stmt
+}
+
+func (w *WhileStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitWhileStmt(w)
+}
+
+// ForInStmt is a for (var name in collection) loop. The body runs once for every element
+// of the collection, with the element bound to a fresh variable each time.
+type ForInStmt struct {
+	Keyword    Token
+	Name       Token
+	Collection Expr
+	Body       Stmt
+}
+
+func (f *ForInStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitForInStmt(f)
+}
+
+type ReturnStmt struct {
+	Keyword Token
+	Value   Expr
+}
+
+func (r *ReturnStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitReturnStmt(r)
+}
+
+type ClassStmt struct {
+	Name       Token
+	Superclass *VarExpr
+	Methods    []*FunctionStmt
+}
+
+func (c *ClassStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitClassStmt(c)
+}
+
+// ThrowStmt throws the value, which unwinds the statements up to the closest try statement
+// with a catch clause.
+type ThrowStmt struct {
+	Keyword Token
+	Value   Expr
+}
+
+func (t *ThrowStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitThrowStmt(t)
+}
+
+// TryStmt is a try block with a catch clause, a finally block or both. Catch is nil when
+// there is no catch clause and Finally is nil when there is no finally block.
+type TryStmt struct {
+	Keyword Token
+	Body    []Stmt
+	Catch   *CatchClause
+	Finally []Stmt
+}
+
+// CatchClause binds the exception caught by a try statement to Name while its body runs.
+type CatchClause struct {
+	Name Token
+	Body []Stmt
+}
+
+func (t *TryStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitTryStmt(t)
+}
+
+// BadStmt stands in for a statement the parser couldn't parse. From is the first token of
+// the statement and At is the token where the parser reported the error. A program with a
+// BadStmt always has a parse error, so it's never resolved or interpreted, but the rest of
+// the tree can still be inspected.
+type BadStmt struct {
+	From Token
+	At   Token
+}
+
+func (b *BadStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBadStmt(b)
+}
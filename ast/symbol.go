@@ -0,0 +1,51 @@
+package ast
+
+import "sync"
+
+// Symbol is an interned identifier. Every occurrence of the same name maps to the same
+// Symbol, so scopes and environments can key their maps by a small integer and compare
+// names without comparing strings. The zero Symbol is not the symbol of any name.
+type Symbol int32
+
+// symbols is the table of interned names. It's shared by every scanner, resolver and
+// interpreter in the process, so a Symbol means the same name everywhere.
+var symbols = struct {
+	sync.RWMutex
+	ids   map[string]Symbol
+	names []string
+}{ids: make(map[string]Symbol), names: []string{""}}
+
+// Symbols the interpreter refers to by name.
+var (
+	SymbolThis  = Intern("this")
+	SymbolSuper = Intern("super")
+)
+
+// Intern returns the symbol of the name, adding it to the table the first time the name is
+// seen.
+func Intern(name string) Symbol {
+	symbols.RLock()
+	symbol, ok := symbols.ids[name]
+	symbols.RUnlock()
+	if ok {
+		return symbol
+	}
+
+	symbols.Lock()
+	defer symbols.Unlock()
+	if symbol, ok := symbols.ids[name]; ok {
+		return symbol
+	}
+
+	symbol = Symbol(len(symbols.names))
+	symbols.ids[name] = symbol
+	symbols.names = append(symbols.names, name)
+	return symbol
+}
+
+// String returns the name the symbol was interned from.
+func (s Symbol) String() string {
+	symbols.RLock()
+	defer symbols.RUnlock()
+	return symbols.names[s]
+}
@@ -0,0 +1,46 @@
+package ast
+
+import "fmt"
+
+type Token struct {
+	Type    TokenType
+	Lexeme  string
+	Literal interface{}
+	Line    int
+	// Column is the column of the first character of the token on its line, counted in
+	// characters from 1. It's 0 for synthetic tokens.
+	Column int
+
+	// symbol is the interned name of identifier tokens, including 'this' and 'super'.
+	symbol Symbol
+}
+
+func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int) Token {
+	token := Token{
+		Type:    tokenType,
+		Lexeme:  lexeme,
+		Literal: literal,
+		Line:    line,
+	}
+
+	switch tokenType {
+	case Identifiers, This, Super:
+		token.symbol = Intern(lexeme)
+	}
+
+	return token
+}
+
+// Symbol returns the interned name of the token. Tokens made by the scanner carry it
+// already, synthetic tokens intern their lexeme on demand.
+func (t Token) Symbol() Symbol {
+	if t.symbol != 0 {
+		return t.symbol
+	}
+
+	return Intern(t.Lexeme)
+}
+
+func (t Token) ToString() string {
+	return fmt.Sprintf("%v %s %s", t.Type, t.Lexeme, t.Literal)
+}
@@ -0,0 +1,74 @@
+package ast
+
+type  TokenType int
+
+const (
+	// Single-character tokens.
+	LeftParen TokenType = iota
+	RightParen
+	LeftBrace
+	RightBrace
+	LeftBracket
+	RightBracket
+	Comma
+	Dot
+	Minus
+	Plus
+	Semicolon
+	Slash
+	Star
+	Colon
+
+	// One or two character tokens.
+	Bang
+	BangEqual
+	Equal
+	EqualEqual
+	Greater
+	GreaterEqual
+	Less
+	LessEqual
+	DotDot
+	DotDotEqual
+	Pipe
+
+	// Literals
+	Identifiers
+	String
+	Number
+
+	// Keywords
+	And
+	Class
+	Else
+	False
+	Fun
+	For
+	If
+	Nil
+	Or
+	PRINT // conflicting with the Print{} stmt and I am too lazy to rename everything else for it.
+	Return
+	Super
+	This
+	True
+	Var
+	While
+
+	// Keywords of the exceptions extension. The scanner reads them as identifiers and the
+	// parser only treats them as keywords when exceptions are enabled.
+	Throw
+	Try
+	Catch
+	Finally
+
+	// In is the membership operator. The scanner reads 'in' as an identifier too, and the
+	// parser only treats it as the operator where an operator can follow an operand.
+	In
+
+	// Invalid is a run of characters that don't start any token. The scanner reports them
+	// and the parser skips them.
+	Invalid
+
+	Eof
+)
\ No newline at end of file
@@ -0,0 +1,147 @@
+package ast
+
+// BaseVisitor implements Visitor with methods that do nothing and return nil. Passes that
+// only care about a few kinds of expressions can embed it and override the methods they
+// need, so adding a new expression type doesn't break them.
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitAssignExpr(expr *Assign) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitLogicalExpr(expr *Logical) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitBinaryExpr(expr *Binary) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitCallExpr(expr *Call) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitGroupingExpr(expr *Grouping) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitLiteralExpr(expr *Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitUnaryExpr(expr *Unary) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitVarExpr(expr *VarExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitGetExpr(expr *GetExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitSetExpr(expr *SetExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitThisExpr(expr *ThisExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitSuperExpr(expr *SuperExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitRangeExpr(expr *Range) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitComparisonExpr(expr *Comparison) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitArrayExpr(expr *ArrayLiteral) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitIndexGetExpr(expr *IndexGet) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitIndexSetExpr(expr *IndexSet) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitMapExpr(expr *MapLiteral) (interface{}, error) {
+	return nil, nil
+}
+
+func (BaseVisitor) VisitTupleExpr(expr *Tuple) (interface{}, error) {
+	return nil, nil
+}
+
+// BaseStmtVisitor implements StmtVisitor with methods that do nothing and return nil. It's
+// the statement counterpart of BaseVisitor.
+type BaseStmtVisitor struct{}
+
+func (BaseStmtVisitor) VisitBlockStmt(stmt *Block) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitExpressionExpr(expr *Expression) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitPrintExpr(expr *Print) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitVarStmt(expr *VarStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitDestructureStmt(stmt *DestructureStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitIfStmt(stmt *IfStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitWhileStmt(stmt *WhileStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitForInStmt(stmt *ForInStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitFunctionStmt(stmt *FunctionStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitReturnStmt(stmt *ReturnStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitClassStmt(stmt *ClassStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitThrowStmt(stmt *ThrowStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitTryStmt(stmt *TryStmt) error {
+	return nil
+}
+
+func (BaseStmtVisitor) VisitBadStmt(stmt *BadStmt) error {
+	return nil
+}
+
+var (
+	_ Visitor     = BaseVisitor{}
+	_ StmtVisitor = BaseStmtVisitor{}
+)
@@ -1,46 +1,332 @@
 package glox
 
-type AstPrinter struct{}
-
-// func (ap *AstPrinter) Print(expr Expr) (string, error) {
-// 	val, err := expr.Accept(ap)
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	return val.(string), nil
-// }
-
-// func (ap *AstPrinter) VisitBinaryExpr(expr *Binary) (interface{}, error) {
-// 	return ap.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right), nil
-// }
-
-// func (ap *AstPrinter) VisitGroupingExpr(expr *Grouping) (interface{}, error) {
-// 	return ap.parenthesize("group", expr.Expression), nil
-// }
-
-// func (ap *AstPrinter) VisitLiteralExpr(expr *Literal) (interface{}, error) {
-// 	if expr.Value == nil {
-// 		return "nil", nil
-// 	}
-
-// 	return fmt.Sprintf("%v", expr.Value), nil
-// }
-
-// func (ap *AstPrinter) VisitUnaryExpr(expr *Unary) (interface{}, error) {
-// 	return ap.parenthesize(expr.Operator.Lexeme, expr.Right), nil
-// }
-
-// func (ap *AstPrinter) parenthesize(name string, exprs ...Expr) string {
-// 	s := strings.Builder{}
-// 	s.WriteString("(" + name)
-
-// 	for _, expr := range exprs {
-// 		s.WriteString(" ")
-// 		val,_ := expr.Accept(ap)
-// 		s.WriteString(val.(string))
-// 	}
-
-// 	s.WriteString(")")
-// 	return s.String()
-// }
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// AstPrinter prints the syntax tree as nested lisp style lists, one statement per line,
+// with the bodies of blocks, functions and classes indented. It's a debugging aid to see
+// how the parser understood the source. The printer is safe to use on trees with parse
+// errors, statements that failed to parse are printed as <error> markers pointing at the
+// token where the parser lost track.
+type AstPrinter struct {
+	lines []string
+	depth int
+
+	// closers counts the parens appended to each line to close the bodies ending there.
+	closers []int
+}
+
+func NewAstPrinter() *AstPrinter {
+	return &AstPrinter{}
+}
+
+// Print returns the printed form of the statements.
+func (ap *AstPrinter) Print(statements []ast.Stmt) string {
+	ap.printLines(statements)
+	if len(ap.lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(ap.lines, "\n") + "\n"
+}
+
+// printLines prints the statements into lines and closers.
+func (ap *AstPrinter) printLines(statements []ast.Stmt) {
+	ap.lines = nil
+	ap.closers = nil
+	ap.depth = 0
+
+	for _, stmt := range statements {
+		ap.printStmt(stmt)
+	}
+}
+
+// PrintExpr returns the printed form of a single expression.
+func (ap *AstPrinter) PrintExpr(expr ast.Expr) string {
+	if expr == nil {
+		return "<error>"
+	}
+
+	val, _ := expr.Accept(ap)
+	return val.(string)
+}
+
+func (ap *AstPrinter) VisitBlockStmt(stmt *ast.Block) error {
+	ap.printBody("(block", stmt.Statements)
+	return nil
+}
+
+func (ap *AstPrinter) VisitExpressionExpr(expr *ast.Expression) error {
+	ap.line("(; " + ap.PrintExpr(expr.Expression) + ")")
+	return nil
+}
+
+func (ap *AstPrinter) VisitPrintExpr(expr *ast.Print) error {
+	ap.line("(print " + ap.PrintExpr(expr.Expression) + ")")
+	return nil
+}
+
+func (ap *AstPrinter) VisitDestructureStmt(stmt *ast.DestructureStmt) error {
+	names := make([]string, 0, len(stmt.Names))
+	for _, name := range stmt.Names {
+		names = append(names, name.Lexeme)
+	}
+
+	ap.line("(var (" + strings.Join(names, " ") + ") " + ap.PrintExpr(stmt.Initializer) + ")")
+	return nil
+}
+
+func (ap *AstPrinter) VisitVarStmt(stmt *ast.VarStmt) error {
+	if stmt.Initializer == nil {
+		ap.line("(var " + stmt.Name.Lexeme + ")")
+		return nil
+	}
+
+	ap.line("(var " + stmt.Name.Lexeme + " " + ap.PrintExpr(stmt.Initializer) + ")")
+	return nil
+}
+
+func (ap *AstPrinter) VisitIfStmt(stmt *ast.IfStmt) error {
+	branches := []ast.Stmt{stmt.ThenBranch}
+	if stmt.ElseBranch != nil {
+		branches = append(branches, stmt.ElseBranch)
+	}
+
+	ap.printBody("(if "+ap.PrintExpr(stmt.Condition), branches)
+	return nil
+}
+
+func (ap *AstPrinter) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	ap.printBody("(while "+ap.PrintExpr(stmt.Condition), []ast.Stmt{stmt.Body})
+	return nil
+}
+
+func (ap *AstPrinter) VisitForInStmt(stmt *ast.ForInStmt) error {
+	ap.printBody("(for "+stmt.Name.Lexeme+" in "+ap.PrintExpr(stmt.Collection), []ast.Stmt{stmt.Body})
+	return nil
+}
+
+func (ap *AstPrinter) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	params := make([]string, 0, len(stmt.Params))
+	for _, param := range stmt.Params {
+		params = append(params, param.Lexeme)
+	}
+
+	ap.printBody("(fun "+stmt.Name.Lexeme+" ("+strings.Join(params, " ")+")", stmt.Body)
+	return nil
+}
+
+func (ap *AstPrinter) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	if stmt.Value == nil {
+		ap.line("(return)")
+		return nil
+	}
+
+	ap.line("(return " + ap.PrintExpr(stmt.Value) + ")")
+	return nil
+}
+
+func (ap *AstPrinter) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	ap.line("(throw " + ap.PrintExpr(stmt.Value) + ")")
+	return nil
+}
+
+func (ap *AstPrinter) VisitTryStmt(stmt *ast.TryStmt) error {
+	ap.line("(try")
+	ap.depth++
+	ap.printBody("(block", stmt.Body)
+	if stmt.Catch != nil {
+		ap.printBody("(catch "+stmt.Catch.Name.Lexeme, stmt.Catch.Body)
+	}
+
+	if stmt.Finally != nil {
+		ap.printBody("(finally", stmt.Finally)
+	}
+	ap.depth--
+
+	ap.lines[len(ap.lines)-1] += ")"
+	ap.closers[len(ap.closers)-1]++
+	return nil
+}
+
+func (ap *AstPrinter) VisitClassStmt(stmt *ast.ClassStmt) error {
+	header := "(class " + stmt.Name.Lexeme
+	if stmt.Superclass != nil {
+		header += " < " + stmt.Superclass.Name.Lexeme
+	}
+
+	methods := make([]ast.Stmt, 0, len(stmt.Methods))
+	for _, method := range stmt.Methods {
+		methods = append(methods, method)
+	}
+
+	ap.printBody(header, methods)
+	return nil
+}
+
+func (ap *AstPrinter) VisitBadStmt(stmt *ast.BadStmt) error {
+	ap.line(fmt.Sprintf("<error [line %d]%s>", stmt.At.Line, where(stmt.At)))
+	return nil
+}
+
+func (ap *AstPrinter) VisitAssignExpr(expr *ast.Assign) (interface{}, error) {
+	return ap.parenthesize("=", &ast.VarExpr{Name: expr.Name}, expr.Value), nil
+}
+
+func (ap *AstPrinter) VisitLogicalExpr(expr *ast.Logical) (interface{}, error) {
+	return ap.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right), nil
+}
+
+func (ap *AstPrinter) VisitBinaryExpr(expr *ast.Binary) (interface{}, error) {
+	return ap.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right), nil
+}
+
+func (ap *AstPrinter) VisitCallExpr(expr *ast.Call) (interface{}, error) {
+	s := ap.parenthesize("call", append([]ast.Expr{expr.Callee}, expr.Arguments...)...)
+	if len(expr.KeywordArguments) == 0 {
+		return s, nil
+	}
+
+	keywords := make([]string, 0, len(expr.KeywordArguments))
+	for _, argument := range expr.KeywordArguments {
+		keywords = append(keywords, argument.Name.Lexeme+": "+ap.PrintExpr(argument.Value))
+	}
+
+	return strings.TrimSuffix(s, ")") + " " + strings.Join(keywords, " ") + ")", nil
+}
+
+func (ap *AstPrinter) VisitGroupingExpr(expr *ast.Grouping) (interface{}, error) {
+	return ap.parenthesize("group", expr.Expression), nil
+}
+
+func (ap *AstPrinter) VisitLiteralExpr(expr *ast.Literal) (interface{}, error) {
+	switch value := expr.Value.(type) {
+	case nil:
+		return "nil", nil
+	case string:
+		return strconv.Quote(value), nil
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
+
+func (ap *AstPrinter) VisitUnaryExpr(expr *ast.Unary) (interface{}, error) {
+	return ap.parenthesize(expr.Operator.Lexeme, expr.Right), nil
+}
+
+func (ap *AstPrinter) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
+	return expr.Name.Lexeme, nil
+}
+
+func (ap *AstPrinter) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	return "(. " + ap.PrintExpr(expr.Object) + " " + expr.Name.Lexeme + ")", nil
+}
+
+func (ap *AstPrinter) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	target := "(. " + ap.PrintExpr(expr.Object) + " " + expr.Name.Lexeme + ")"
+	return "(= " + target + " " + ap.PrintExpr(expr.Value) + ")", nil
+}
+
+func (ap *AstPrinter) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	return "this", nil
+}
+
+func (ap *AstPrinter) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	return "(super " + expr.Method.Lexeme + ")", nil
+}
+
+func (ap *AstPrinter) VisitRangeExpr(expr *ast.Range) (interface{}, error) {
+	return ap.parenthesize(expr.Operator.Lexeme, expr.Start, expr.End), nil
+}
+
+func (ap *AstPrinter) VisitArrayExpr(expr *ast.ArrayLiteral) (interface{}, error) {
+	return ap.parenthesize("array", expr.Elements...), nil
+}
+
+func (ap *AstPrinter) VisitMapExpr(expr *ast.MapLiteral) (interface{}, error) {
+	entries := make([]ast.Expr, 0, 2*len(expr.Keys))
+	for n, key := range expr.Keys {
+		entries = append(entries, key, expr.Values[n])
+	}
+
+	return ap.parenthesize("map", entries...), nil
+}
+
+func (ap *AstPrinter) VisitTupleExpr(expr *ast.Tuple) (interface{}, error) {
+	return ap.parenthesize("tuple", expr.Elements...), nil
+}
+
+func (ap *AstPrinter) VisitIndexGetExpr(expr *ast.IndexGet) (interface{}, error) {
+	return ap.parenthesize("[]", expr.Object, expr.Index), nil
+}
+
+func (ap *AstPrinter) VisitIndexSetExpr(expr *ast.IndexSet) (interface{}, error) {
+	target := ap.parenthesize("[]", expr.Object, expr.Index)
+	return "(= " + target + " " + ap.PrintExpr(expr.Value) + ")", nil
+}
+
+func (ap *AstPrinter) VisitComparisonExpr(expr *ast.Comparison) (interface{}, error) {
+	operators := make([]string, 0, len(expr.Operators))
+	for _, operator := range expr.Operators {
+		operators = append(operators, operator.Lexeme)
+	}
+
+	return ap.parenthesize(strings.Join(operators, " "), expr.Operands...), nil
+}
+
+// printStmt prints a statement on its own lines at the current depth.
+func (ap *AstPrinter) printStmt(stmt ast.Stmt) {
+	if stmt == nil {
+		ap.line("<error>")
+		return
+	}
+
+	_ = stmt.Accept(ap)
+}
+
+// printBody prints the header followed by the statements indented one level deeper, and
+// closes the list at the end of the last line.
+func (ap *AstPrinter) printBody(header string, statements []ast.Stmt) {
+	if len(statements) == 0 {
+		ap.line(header + ")")
+		return
+	}
+
+	ap.line(header)
+	ap.depth++
+	for _, stmt := range statements {
+		ap.printStmt(stmt)
+	}
+	ap.depth--
+
+	ap.lines[len(ap.lines)-1] += ")"
+	ap.closers[len(ap.closers)-1]++
+}
+
+func (ap *AstPrinter) line(s string) {
+	ap.lines = append(ap.lines, strings.Repeat("  ", ap.depth)+s)
+	ap.closers = append(ap.closers, 0)
+}
+
+func (ap *AstPrinter) parenthesize(name string, exprs ...ast.Expr) string {
+	s := strings.Builder{}
+	s.WriteString("(" + name)
+
+	for _, expr := range exprs {
+		s.WriteString(" ")
+		s.WriteString(ap.PrintExpr(expr))
+	}
+
+	s.WriteString(")")
+	return s.String()
+}
+
+var (
+	_ ast.Visitor     = &AstPrinter{}
+	_ ast.StmtVisitor = &AstPrinter{}
+)
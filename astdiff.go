@@ -0,0 +1,138 @@
+package glox
+
+import (
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// DiffOp says whether a line of an AST diff is in both trees, or only in one of them.
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffRemoved
+	DiffAdded
+)
+
+// DiffLine is a line of the printed syntax tree in an AST diff.
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// AstDiff compares two programs by their syntax trees, so formatting, comments and
+// redundant semicolons make no difference. The trees are printed like --ast prints them,
+// one statement per line, and the lines are compared with a longest common subsequence
+// diff. Lines are compared without the parens closing the bodies that end on them, adding
+// a statement at the end of a block only adds that statement.
+func AstDiff(old, new []ast.Stmt) []DiffLine {
+	oldLines, oldKeys := diffLines(old)
+	newLines, newKeys := diffLines(new)
+
+	// common[i][j] is the length of the longest common subsequence of oldKeys[i:] and
+	// newKeys[j:].
+	common := make([][]int, len(oldKeys)+1)
+	for i := range common {
+		common[i] = make([]int, len(newKeys)+1)
+	}
+
+	for i := len(oldKeys) - 1; i >= 0; i-- {
+		for j := len(newKeys) - 1; j >= 0; j-- {
+			if oldKeys[i] == newKeys[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(oldKeys)+len(newKeys))
+	i, j := 0, 0
+	for i < len(oldKeys) || j < len(newKeys) {
+		switch {
+		case i < len(oldKeys) && j < len(newKeys) && oldKeys[i] == newKeys[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: newLines[j]})
+			i++
+			j++
+		case j == len(newKeys) || (i < len(oldKeys) && common[i+1][j] >= common[i][j+1]):
+			diff = append(diff, DiffLine{Op: DiffRemoved, Text: oldLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffAdded, Text: newLines[j]})
+			j++
+		}
+	}
+
+	return diff
+}
+
+// diffLines prints the statements, returning the lines and the lines without the parens
+// that close bodies.
+func diffLines(statements []ast.Stmt) ([]string, []string) {
+	printer := NewAstPrinter()
+	printer.printLines(statements)
+
+	keys := make([]string, len(printer.lines))
+	for i, line := range printer.lines {
+		keys[i] = line[:len(line)-printer.closers[i]]
+	}
+
+	return printer.lines, keys
+}
+
+// HasChanges reports if the diff has any added or removed line.
+func HasChanges(diff []DiffLine) bool {
+	for _, line := range diff {
+		if line.Op != DiffEqual {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormatDiff prints the changed lines of the diff prefixed with - or +, along with up to
+// context unchanged lines around them. Changes that are further apart are printed as
+// separate hunks, each starting with a @@ line.
+func FormatDiff(diff []DiffLine, context int) string {
+	// show marks the lines that are within the context of a change.
+	show := make([]bool, len(diff))
+	for i, line := range diff {
+		if line.Op == DiffEqual {
+			continue
+		}
+
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(diff) {
+				show[j] = true
+			}
+		}
+	}
+
+	out := strings.Builder{}
+	for i, line := range diff {
+		if !show[i] {
+			continue
+		}
+
+		if i == 0 || !show[i-1] {
+			out.WriteString("@@\n")
+		}
+
+		switch line.Op {
+		case DiffEqual:
+			out.WriteString("  ")
+		case DiffRemoved:
+			out.WriteString("- ")
+		case DiffAdded:
+			out.WriteString("+ ")
+		}
+
+		out.WriteString(line.Text + "\n")
+	}
+
+	return out.String()
+}
@@ -0,0 +1,262 @@
+package glox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Breakpoint pauses execution before the statements on a line run. Condition is a lox
+// expression evaluated in the paused frame, the breakpoint is only hit when it's truthy,
+// or always if it's empty. With a HitCount of n, execution only stops from the nth hit on.
+type Breakpoint struct {
+	Line      int
+	Condition string
+	HitCount  int
+}
+
+// BreakpointHit is a breakpoint stopping execution. Hits counts the times the breakpoint
+// was hit, including this one. Err is set when the condition failed to evaluate, which
+// stops execution so the condition can be fixed.
+type BreakpointHit struct {
+	Breakpoint Breakpoint
+	Hits       int
+	Err        error
+}
+
+// BreakHandler is called when a breakpoint stops execution. Execution is paused while the
+// handler runs, so it can look at the call stack and evaluate expressions in its frames.
+type BreakHandler func(hit BreakpointHit)
+
+// breakpoint is a breakpoint along with the times it was hit.
+type breakpoint struct {
+	Breakpoint
+	hits int
+}
+
+// SetBreakpoint sets a breakpoint, replacing the one on the same line. The condition is
+// parsed right away, so a syntax error in it is returned here rather than at every hit.
+func (i *Interpreter) SetBreakpoint(bp Breakpoint) error {
+	if bp.Condition != "" {
+		if _, diagnostics := parseExpr(bp.Condition, i.options()); HasErrors(diagnostics) {
+			return diagnosticsError(diagnostics)
+		}
+	}
+
+	if i.breakpoints == nil {
+		i.breakpoints = make(map[int]*breakpoint)
+	}
+
+	i.breakpoints[bp.Line] = &breakpoint{Breakpoint: bp}
+	return nil
+}
+
+// ClearBreakpoint removes the breakpoint on the line.
+func (i *Interpreter) ClearBreakpoint(line int) {
+	delete(i.breakpoints, line)
+}
+
+// SetBreakHandler sets the handler called when a breakpoint stops execution.
+func (i *Interpreter) SetBreakHandler(handler BreakHandler) {
+	i.breakHandler = handler
+}
+
+// checkBreakpoint calls the break handler if the statement is on the line of a breakpoint
+// whose condition holds and whose hit count is reached. Of the statements the parser
+// created for a for loop, only the loop itself stops, once as it starts. The increment
+// runs on the line of the loop too, but stopping before it in every iteration would stop
+// at code the user doesn't see as a statement.
+func (i *Interpreter) checkBreakpoint(stmt ast.Stmt) {
+	if _, ok := i.sourceMap.Lookup(stmt); ok {
+		if _, loop := stmt.(*ast.WhileStmt); !loop {
+			return
+		}
+	}
+
+	bp, ok := i.breakpoints[stmtLine(stmt)]
+	if !ok || i.breakHandler == nil {
+		return
+	}
+
+	if bp.Condition != "" {
+		value, err := i.EvaluateInFrame(0, bp.Condition)
+		if err != nil {
+			i.breakHandler(BreakpointHit{Breakpoint: bp.Breakpoint, Hits: bp.hits, Err: err})
+			return
+		}
+
+		if !i.isTruthy(value) {
+			return
+		}
+	}
+
+	bp.hits++
+	if bp.hits >= bp.HitCount {
+		i.breakHandler(BreakpointHit{Breakpoint: bp.Breakpoint, Hits: bp.hits})
+	}
+}
+
+// ParseBreakpoint parses a breakpoint written as "line [after n] [if condition]", like
+// "12 after 3 if i > 5".
+func ParseBreakpoint(s string) (Breakpoint, error) {
+	var bp Breakpoint
+	rest := strings.TrimSpace(s)
+	if index := strings.Index(rest, " if "); index >= 0 {
+		bp.Condition = strings.TrimSpace(rest[index+len(" if "):])
+		rest = rest[:index]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) != 1 && (len(fields) != 3 || fields[1] != "after") {
+		return bp, fmt.Errorf("invalid breakpoint '%s', expected line [after n] [if condition]", s)
+	}
+
+	line, err := strconv.Atoi(fields[0])
+	if err != nil || line < 1 {
+		return bp, fmt.Errorf("invalid breakpoint line '%s'", fields[0])
+	}
+
+	bp.Line = line
+	if len(fields) == 3 {
+		if bp.HitCount, err = strconv.Atoi(fields[2]); err != nil || bp.HitCount < 1 {
+			return bp, fmt.Errorf("invalid breakpoint hit count '%s'", fields[2])
+		}
+	}
+
+	return bp, nil
+}
+
+// debugPrompt returns a break handler that prints where execution stopped and reads
+// commands until told to continue: an empty line or 'c' continues, 'bt' prints the call
+// stack, and anything else is evaluated in the paused frame.
+func (i *Interpreter) debugPrompt(w io.Writer) BreakHandler {
+	return func(hit BreakpointHit) {
+		if hit.Err != nil {
+			fmt.Fprintf(w, "break: line %d, condition failed: %s\n", hit.Breakpoint.Line, hit.Err.Error())
+		} else {
+			fmt.Fprintf(w, "break: line %d, hit %d\n", hit.Breakpoint.Line, hit.Hits)
+		}
+
+		if i.in == nil {
+			i.in = bufio.NewReader(os.Stdin)
+		}
+
+		for {
+			fmt.Fprint(w, "(debug) ")
+			line, err := i.in.ReadString('\n')
+			command := strings.TrimSpace(line)
+			if err != nil || command == "" || command == "c" {
+				return
+			}
+
+			if command == "bt" {
+				for _, frame := range i.CallStack() {
+					fmt.Fprintf(w, "    in %s (line %d)\n", frame.Function, frame.Line)
+				}
+
+				continue
+			}
+
+			value, err := i.EvaluateInFrame(0, command)
+			if err != nil {
+				fmt.Fprintln(w, err.Error())
+				continue
+			}
+
+			fmt.Fprintln(w, i.stringify(value))
+		}
+	}
+}
+
+// stmtLine returns the line a statement starts on, 0 if the statement has no token to
+// tell, like blocks.
+func stmtLine(stmt ast.Stmt) int {
+	switch stmt := stmt.(type) {
+	case *ast.Expression:
+		return exprLine(stmt.Expression)
+	case *ast.Print:
+		return stmt.Keyword.Line
+	case *ast.VarStmt:
+		return stmt.Name.Line
+	case *ast.DestructureStmt:
+		return stmt.Paren.Line
+	case *ast.IfStmt:
+		return stmt.Keyword.Line
+	case *ast.WhileStmt:
+		return stmt.Keyword.Line
+	case *ast.ForInStmt:
+		return stmt.Keyword.Line
+	case *ast.FunctionStmt:
+		return stmt.Name.Line
+	case *ast.ReturnStmt:
+		return stmt.Keyword.Line
+	case *ast.ClassStmt:
+		return stmt.Name.Line
+	case *ast.ThrowStmt:
+		return stmt.Keyword.Line
+	case *ast.TryStmt:
+		return stmt.Keyword.Line
+	}
+
+	return 0
+}
+
+// exprLine returns the line of the leftmost token of the expression that's known, 0 if
+// there is none, like for literals.
+func exprLine(expr ast.Expr) int {
+	var line int
+	switch expr := expr.(type) {
+	case *ast.Assign:
+		return expr.Name.Line
+	case *ast.Logical:
+		line = orLine(exprLine(expr.Left), expr.Operator.Line)
+	case *ast.Binary:
+		line = orLine(exprLine(expr.Left), expr.Operator.Line)
+	case *ast.Call:
+		line = orLine(exprLine(expr.Callee), expr.Paren.Line)
+	case *ast.Grouping:
+		line = exprLine(expr.Expression)
+	case *ast.Unary:
+		line = expr.Operator.Line
+	case *ast.VarExpr:
+		line = expr.Name.Line
+	case *ast.GetExpr:
+		line = orLine(exprLine(expr.Object), expr.Name.Line)
+	case *ast.SetExpr:
+		line = orLine(exprLine(expr.Object), expr.Name.Line)
+	case *ast.ThisExpr:
+		line = expr.Keyword.Line
+	case *ast.SuperExpr:
+		line = expr.Keyword.Line
+	case *ast.Range:
+		line = orLine(exprLine(expr.Start), expr.Operator.Line)
+	case *ast.Comparison:
+		line = exprLine(expr.Operands[0])
+	case *ast.ArrayLiteral:
+		line = expr.Bracket.Line
+	case *ast.MapLiteral:
+		line = expr.Brace.Line
+	case *ast.Tuple:
+		line = expr.Paren.Line
+	case *ast.IndexGet:
+		line = orLine(exprLine(expr.Object), expr.Bracket.Line)
+	case *ast.IndexSet:
+		line = orLine(exprLine(expr.Object), expr.Bracket.Line)
+	}
+
+	return line
+}
+
+// orLine returns line, or the fallback if it's unknown.
+func orLine(line, fallback int) int {
+	if line == 0 {
+		return fallback
+	}
+
+	return line
+}
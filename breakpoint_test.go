@@ -0,0 +1,56 @@
+package glox
+
+import (
+	"io"
+	"testing"
+)
+
+// breakpointHits runs the script with a breakpoint on the line and returns the hits.
+func breakpointHits(t *testing.T, source string, line int) []BreakpointHit {
+	t.Helper()
+
+	options := DefaultOptions()
+	options.NoPrelude = true
+	r := NewRuntimeWithOptions(options)
+	r.SetOutput(io.Discard)
+
+	hits := make([]BreakpointHit, 0)
+	r.interpreter.SetBreakHandler(func(hit BreakpointHit) {
+		hits = append(hits, hit)
+	})
+
+	if err := r.interpreter.SetBreakpoint(Breakpoint{Line: line}); err != nil {
+		t.Fatalf("setting the breakpoint: %s", err.Error())
+	}
+
+	r.scriptMode = true
+	r.run(source)
+	if r.hadError || r.hadRuntimeError {
+		t.Fatalf("the script failed")
+	}
+
+	return hits
+}
+
+func TestBreakpointOnForLoopSkipsDesugaredStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		hits   int
+	}{
+		// The initializer and the loop stop once, the increment never does.
+		{name: "initializer", source: "var total = 0;\nfor (var i = 0; i < 3; i = i + 1)\n  total = total + i;\n", hits: 2},
+		{name: "no initializer", source: "var i = 0;\nfor (; i < 3; i = i + 1)\n  print i;\n", hits: 1},
+		// Statements the user wrote on the line stop every time they run.
+		{name: "body on the line", source: "var total = 0;\nfor (var i = 0; i < 3; i = i + 1) total = total + i;\n", hits: 5},
+		{name: "while loop", source: "var i = 0;\nwhile (i < 3) i = i + 1;\n", hits: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if hits := breakpointHits(t, test.source, 2); len(hits) != test.hits {
+				t.Errorf("expected %d hits, got %d", test.hits, len(hits))
+			}
+		})
+	}
+}
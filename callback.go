@@ -0,0 +1,71 @@
+package glox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Call calls a lox function from Go, e.g. a callback a script handed to a native function.
+// The arguments are converted like the variables of a Sandbox. Whatever goes wrong during
+// the call is returned as the error: runtime errors, uncaught exceptions, a stack overflow,
+// the context being done, and panics of the native functions called. The interpreter is
+// left as it was before the call either way, so it can run the next callback.
+//
+// Once the context is done, the call stops at the next call or loop iteration with an
+// error that scripts can't catch. Call must not be used by more than one goroutine at the
+// same time.
+func (i *Interpreter) Call(ctx context.Context, callee LoxCallable, arguments ...interface{}) (result interface{}, err error) {
+	values := make([]interface{}, 0, len(arguments))
+	for n, argument := range arguments {
+		value, err := sandboxValue(argument)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %s", n+1, err.Error())
+		}
+
+		values = append(values, value)
+	}
+
+	environment, frames, out, previousCtx := i.environment, len(i.frames), i.out, i.ctx
+	i.ctx = ctx
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("panic calling %s: %v", callee.Name(), r)
+		}
+
+		i.environment, i.frames, i.out, i.ctx = environment, i.frames[:frames], out, previousCtx
+	}()
+
+	if err := i.interrupted(ast.Token{}); err != nil {
+		return nil, err
+	}
+
+	result, err = callValue(i, callee, values)
+	if err != nil {
+		return nil, i.uncaught(err)
+	}
+
+	return result, nil
+}
+
+// Global returns the value of the global variable with the name, and false if there is
+// none. After a program ran, it finds the functions the program defined, to call them with
+// Call.
+func (i *Interpreter) Global(name string) (interface{}, bool) {
+	return i.globals.lookup(ast.Intern(name))
+}
+
+// interrupted returns a fatal runtime error at the token once the context of the running
+// call from Go is done.
+func (i *Interpreter) interrupted(token ast.Token) error {
+	if i.ctx == nil {
+		return nil
+	}
+
+	if err := i.ctx.Err(); err != nil {
+		return &RuntimeError{token: token, message: "Interrupted: " + err.Error(), fatal: true}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package glox
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const callbackScript = `
+fun fail() { return 1 - nil; }
+fun spin() { while (true) {} }
+fun deep(n) { return deep(n + 1); }
+fun crash() { return explode(); }
+fun add(a, b) { return a + b; }
+`
+
+// newCallbackInterpreter runs the callback script and returns the interpreter it ran on,
+// with a native that panics, for the callbacks to be called on.
+func newCallbackInterpreter(t *testing.T) *Interpreter {
+	t.Helper()
+
+	options := DefaultOptions()
+	options.MaxCallDepth = 64
+	options.Globals = map[string]interface{}{
+		"explode": NewNativeFunction("explode", "", 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			panic("boom")
+		}),
+	}
+
+	program, diagnostics := Compile(callbackScript, options)
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	interpreter := newProgramInterpreter(options)
+	interpreter.out = io.Discard
+	interpreter.addLocals(program.locals)
+	if err := program.run(interpreter); err != nil {
+		t.Fatalf("running the script: %s", err.Error())
+	}
+
+	return interpreter
+}
+
+func callbackFunction(t *testing.T, interpreter *Interpreter, name string) LoxCallable {
+	t.Helper()
+
+	value, ok := interpreter.Global(name)
+	if !ok {
+		t.Fatalf("no global '%s'", name)
+	}
+
+	return value.(LoxCallable)
+}
+
+func TestCallRecoversFromRepeatedFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		args     []interface{}
+		timeout  time.Duration
+		message  string
+	}{
+		{name: "runtime error", function: "fail", message: "Both operands must be numbers"},
+		{name: "timeout", function: "spin", timeout: time.Millisecond, message: "Interrupted: context deadline exceeded"},
+		{name: "stack overflow", function: "deep", args: []interface{}{0}, message: "Stack overflow"},
+		{name: "panic", function: "crash", message: "panic calling crash: boom"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			interpreter := newCallbackInterpreter(t)
+			failing := callbackFunction(t, interpreter, test.function)
+			add := callbackFunction(t, interpreter, "add")
+
+			for n := 0; n < 20; n++ {
+				ctx, cancel := context.Background(), context.CancelFunc(func() {})
+				if test.timeout > 0 {
+					ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				}
+
+				_, err := interpreter.Call(ctx, failing, test.args...)
+				cancel()
+				if err == nil || !strings.Contains(err.Error(), test.message) {
+					t.Fatalf("call %d: expected an error containing %q, got %v", n, test.message, err)
+				}
+
+				if len(interpreter.frames) != 0 {
+					t.Fatalf("call %d: %d frames left on the call stack", n, len(interpreter.frames))
+				}
+
+				if interpreter.environment != interpreter.globals {
+					t.Fatalf("call %d: the environment wasn't reset to the globals", n)
+				}
+
+				if interpreter.ctx != nil {
+					t.Fatalf("call %d: the context of the call is still set", n)
+				}
+
+				result, err := interpreter.Call(context.Background(), add, 1, 2)
+				if err != nil || result != float64(3) {
+					t.Fatalf("call %d: add(1, 2) after the failure returned %v, %v", n, result, err)
+				}
+			}
+		})
+	}
+}
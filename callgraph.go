@@ -0,0 +1,584 @@
+package glox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Kinds of the nodes of a call graph.
+const (
+	CallGraphScript   = "script"
+	CallGraphFunction = "function"
+	CallGraphMethod   = "method"
+	CallGraphClass    = "class"
+)
+
+// CallGraphNode is a function, method or class of a program. Node 0 is the top level of
+// the script, which makes the calls of the statements outside of any function.
+type CallGraphNode struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Line int    `json:"line"`
+}
+
+// CallGraphEdge says that From calls To, Line is the line of the first such call. Calling a
+// class makes an edge to the class, and the class has an edge to the init method that runs.
+type CallGraphEdge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	Line int `json:"line"`
+}
+
+// CallGraph is the static call graph of a program.
+type CallGraph struct {
+	Nodes []CallGraphNode `json:"nodes"`
+	Edges []CallGraphEdge `json:"edges"`
+}
+
+// BuildCallGraph extracts the calls whose callee is known without running the program:
+// calls of a function or class by the name it's declared with, calls of methods on 'this',
+// which are looked up from the class the call is in, and calls through 'super'. Calls of
+// anything else, like a method of a parameter or a function stored in a field, are left
+// out, as are the calls of functions whose variable is assigned to. Functions passed around
+// as values are not calls, so nothing links to them until they are called by name.
+func BuildCallGraph(statements []ast.Stmt) *CallGraph {
+	b := newCallGraphBuilder()
+	b.build(statements)
+	b.finish()
+	return b.graph
+}
+
+// build walks the statements, collecting the calls and references. Globals are bound late,
+// a function can refer to any global no matter where it's declared, so the global scope
+// starts with all of them.
+func (b *callGraphBuilder) build(statements []ast.Stmt) {
+	b.declare(statements, 0)
+
+	b.beginScope()
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.VarStmt:
+			b.define(stmt.Name.Lexeme, &callBinding{node: -1, declarations: []*ast.VarStmt{stmt}})
+		case *ast.DestructureStmt:
+			for _, name := range stmt.Names {
+				b.define(name.Lexeme, &callBinding{node: -1})
+			}
+		case *ast.FunctionStmt:
+			b.define(stmt.Name.Lexeme, &callBinding{node: b.functions[stmt]})
+		case *ast.ClassStmt:
+			class := b.classes[stmt]
+			b.define(stmt.Name.Lexeme, &callBinding{node: class.node, class: class})
+		}
+	}
+
+	b.walk(statements...)
+}
+
+// WriteDot writes the call graph in the Graphviz DOT language.
+func (g *CallGraph) WriteDot(w io.Writer) {
+	fmt.Fprintln(w, "digraph calls {")
+	for _, node := range g.Nodes {
+		shape := "ellipse"
+		if node.Kind == CallGraphScript || node.Kind == CallGraphClass {
+			shape = "box"
+		}
+
+		fmt.Fprintf(w, "  n%d [label=%q shape=%s];\n", node.ID, node.Name, shape)
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(w, "  n%d -> n%d;\n", edge.From, edge.To)
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+// WriteJSON writes the call graph as a JSON object with the nodes and edges.
+func (g *CallGraph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(g)
+}
+
+func newCallGraphBuilder() *callGraphBuilder {
+	return &callGraphBuilder{
+		graph:     &CallGraph{Nodes: []CallGraphNode{{ID: 0, Name: "<script>", Kind: CallGraphScript}}},
+		parents:   []int{-1},
+		functions: make(map[*ast.FunctionStmt]int),
+		classes:   make(map[*ast.ClassStmt]*callGraphClass),
+	}
+}
+
+// callGraphClass is what the builder knows about a class, to look up the methods called on
+// 'this' and 'super'.
+type callGraphClass struct {
+	node       int
+	methods    map[string]int
+	superclass *callGraphClass
+}
+
+// method returns the node of the method, inherited or not, or -1 if there's none.
+func (c *callGraphClass) method(name string) int {
+	for class := c; class != nil; class = class.superclass {
+		if node, ok := class.methods[name]; ok {
+			return node
+		}
+	}
+
+	return -1
+}
+
+// callBinding is a variable in scope. Node is the function or class it's declared as, or
+// -1 for variables and parameters. A function whose variable is assigned to or declared
+// again is dynamic, and the calls through the variable are dropped. Declarations are the
+// var statements of global variables.
+type callBinding struct {
+	node         int
+	class        *callGraphClass
+	dynamic      bool
+	declarations []*ast.VarStmt
+}
+
+// callGraphCall is an edge found while walking, and the variable it was called through.
+type callGraphCall struct {
+	edge    CallGraphEdge
+	binding *callBinding
+}
+
+// callGraphReference is a use of a variable, in the function or class From.
+type callGraphReference struct {
+	from    int
+	binding *callBinding
+}
+
+type callGraphBuilder struct {
+	graph *CallGraph
+	// parents are the nodes the nodes are declared in, -1 for the script.
+	parents   []int
+	functions map[*ast.FunctionStmt]int
+	classes   map[*ast.ClassStmt]*callGraphClass
+
+	scopes     []map[string]*callBinding
+	calls      []callGraphCall
+	references []callGraphReference
+	// current is the node of the function being walked, class the class whose methods are.
+	current int
+	class   *callGraphClass
+}
+
+// declare adds a node for every function, class and method in the statements, in the order
+// they are declared. Parent is the node the statements are in.
+func (b *callGraphBuilder) declare(statements []ast.Stmt, parent int) {
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.Block:
+			b.declare(stmt.Statements, parent)
+		case *ast.IfStmt:
+			b.declare([]ast.Stmt{stmt.ThenBranch}, parent)
+			if stmt.ElseBranch != nil {
+				b.declare([]ast.Stmt{stmt.ElseBranch}, parent)
+			}
+		case *ast.WhileStmt:
+			b.declare([]ast.Stmt{stmt.Body}, parent)
+		case *ast.ForInStmt:
+			b.declare([]ast.Stmt{stmt.Body}, parent)
+		case *ast.TryStmt:
+			b.declare(stmt.Body, parent)
+			if stmt.Catch != nil {
+				b.declare(stmt.Catch.Body, parent)
+			}
+
+			b.declare(stmt.Finally, parent)
+		case *ast.FunctionStmt:
+			b.functions[stmt] = b.node(stmt.Name.Lexeme, CallGraphFunction, stmt.Name.Line, parent)
+			b.declare(stmt.Body, b.functions[stmt])
+		case *ast.ClassStmt:
+			class := &callGraphClass{node: b.node(stmt.Name.Lexeme, CallGraphClass, stmt.Name.Line, parent), methods: make(map[string]int)}
+			b.classes[stmt] = class
+			for _, method := range stmt.Methods {
+				b.functions[method] = b.node(stmt.Name.Lexeme+"."+method.Name.Lexeme, CallGraphMethod, method.Name.Line, class.node)
+				class.methods[method.Name.Lexeme] = b.functions[method]
+				b.declare(method.Body, b.functions[method])
+			}
+		}
+	}
+}
+
+func (b *callGraphBuilder) node(name, kind string, line, parent int) int {
+	id := len(b.graph.Nodes)
+	b.graph.Nodes = append(b.graph.Nodes, CallGraphNode{ID: id, Name: name, Kind: kind, Line: line})
+	b.parents = append(b.parents, parent)
+	return id
+}
+
+// finish keeps the first of the calls between every two nodes, leaving out the calls
+// through variables that turned out to be dynamic.
+func (b *callGraphBuilder) finish() {
+	type pair struct{ from, to int }
+	seen := make(map[pair]bool)
+	b.graph.Edges = make([]CallGraphEdge, 0)
+
+	for _, call := range b.calls {
+		key := pair{call.edge.From, call.edge.To}
+		if seen[key] || (call.binding != nil && call.binding.dynamic) {
+			continue
+		}
+
+		seen[key] = true
+		b.graph.Edges = append(b.graph.Edges, call.edge)
+	}
+}
+
+func (b *callGraphBuilder) call(to, line int, binding *callBinding) {
+	if to < 0 {
+		return
+	}
+
+	b.calls = append(b.calls, callGraphCall{edge: CallGraphEdge{From: b.current, To: to, Line: line}, binding: binding})
+}
+
+func (b *callGraphBuilder) beginScope() {
+	b.scopes = append(b.scopes, make(map[string]*callBinding))
+}
+
+func (b *callGraphBuilder) endScope() {
+	b.scopes = b.scopes[:len(b.scopes)-1]
+}
+
+// define binds the name in the innermost scope. Globals can be declared again, a global
+// declared as a function and as something else is dynamic, and its binding keeps both.
+func (b *callGraphBuilder) define(name string, binding *callBinding) {
+	scope := b.scopes[len(b.scopes)-1]
+	previous, ok := scope[name]
+	if !ok || len(b.scopes) > 1 {
+		scope[name] = binding
+		return
+	}
+
+	if previous.node >= 0 || binding.node >= 0 {
+		previous.dynamic = true
+	}
+
+	if previous.node < 0 {
+		previous.node, previous.class = binding.node, binding.class
+	}
+
+	previous.declarations = append(previous.declarations, binding.declarations...)
+}
+
+func (b *callGraphBuilder) lookup(name string) *callBinding {
+	for i := len(b.scopes) - 1; i >= 0; i-- {
+		if binding, ok := b.scopes[i][name]; ok {
+			return binding
+		}
+	}
+
+	return nil
+}
+
+func (b *callGraphBuilder) walk(statements ...ast.Stmt) {
+	for _, stmt := range statements {
+		_ = stmt.Accept(b)
+	}
+}
+
+func (b *callGraphBuilder) walkExpr(exprs ...ast.Expr) {
+	for _, expr := range exprs {
+		_, _ = expr.Accept(b)
+	}
+}
+
+// function walks the body of a function or method with the calls made from its node.
+func (b *callGraphBuilder) function(stmt *ast.FunctionStmt) {
+	current := b.current
+	b.current = b.functions[stmt]
+
+	b.beginScope()
+	for _, param := range stmt.Params {
+		b.define(param.Lexeme, &callBinding{node: -1})
+	}
+
+	b.walk(stmt.Body...)
+	b.endScope()
+
+	b.current = current
+}
+
+func (b *callGraphBuilder) VisitBlockStmt(stmt *ast.Block) error {
+	b.beginScope()
+	b.walk(stmt.Statements...)
+	b.endScope()
+	return nil
+}
+
+func (b *callGraphBuilder) VisitExpressionExpr(expr *ast.Expression) error {
+	b.walkExpr(expr.Expression)
+	return nil
+}
+
+func (b *callGraphBuilder) VisitPrintExpr(expr *ast.Print) error {
+	b.walkExpr(expr.Expression)
+	return nil
+}
+
+func (b *callGraphBuilder) VisitVarStmt(stmt *ast.VarStmt) error {
+	if stmt.Initializer != nil {
+		b.walkExpr(stmt.Initializer)
+	}
+
+	if len(b.scopes) > 1 {
+		b.define(stmt.Name.Lexeme, &callBinding{node: -1})
+	}
+
+	return nil
+}
+
+func (b *callGraphBuilder) VisitDestructureStmt(stmt *ast.DestructureStmt) error {
+	b.walkExpr(stmt.Initializer)
+	if len(b.scopes) > 1 {
+		for _, name := range stmt.Names {
+			b.define(name.Lexeme, &callBinding{node: -1})
+		}
+	}
+
+	return nil
+}
+
+func (b *callGraphBuilder) VisitIfStmt(stmt *ast.IfStmt) error {
+	b.walkExpr(stmt.Condition)
+	b.walk(stmt.ThenBranch)
+	if stmt.ElseBranch != nil {
+		b.walk(stmt.ElseBranch)
+	}
+
+	return nil
+}
+
+func (b *callGraphBuilder) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	b.walkExpr(stmt.Condition)
+	b.walk(stmt.Body)
+	return nil
+}
+
+func (b *callGraphBuilder) VisitForInStmt(stmt *ast.ForInStmt) error {
+	b.walkExpr(stmt.Collection)
+	b.beginScope()
+	b.define(stmt.Name.Lexeme, &callBinding{node: -1})
+	b.walk(stmt.Body)
+	b.endScope()
+	return nil
+}
+
+// VisitFunctionStmt binds local functions before walking their bodies, so they can call
+// themselves. Global functions are bound before the walk starts.
+func (b *callGraphBuilder) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	if len(b.scopes) > 1 {
+		b.define(stmt.Name.Lexeme, &callBinding{node: b.functions[stmt]})
+	}
+
+	b.function(stmt)
+	return nil
+}
+
+func (b *callGraphBuilder) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	if stmt.Value != nil {
+		b.walkExpr(stmt.Value)
+	}
+
+	return nil
+}
+
+func (b *callGraphBuilder) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	b.walkExpr(stmt.Value)
+	return nil
+}
+
+func (b *callGraphBuilder) VisitTryStmt(stmt *ast.TryStmt) error {
+	b.beginScope()
+	b.walk(stmt.Body...)
+	b.endScope()
+
+	if stmt.Catch != nil {
+		b.beginScope()
+		b.define(stmt.Catch.Name.Lexeme, &callBinding{node: -1})
+		b.walk(stmt.Catch.Body...)
+		b.endScope()
+	}
+
+	if stmt.Finally != nil {
+		b.beginScope()
+		b.walk(stmt.Finally...)
+		b.endScope()
+	}
+
+	return nil
+}
+
+func (b *callGraphBuilder) VisitClassStmt(stmt *ast.ClassStmt) error {
+	class := b.classes[stmt]
+	if stmt.Superclass != nil {
+		if binding := b.lookup(stmt.Superclass.Name.Lexeme); binding != nil {
+			class.superclass = binding.class
+			b.references = append(b.references, callGraphReference{from: class.node, binding: binding})
+		}
+	}
+
+	if len(b.scopes) > 1 {
+		b.define(stmt.Name.Lexeme, &callBinding{node: class.node, class: class})
+	}
+
+	current := b.current
+	b.current = class.node
+	b.call(class.method("init"), stmt.Name.Line, nil)
+	b.current = current
+
+	enclosing := b.class
+	b.class = class
+	for _, method := range stmt.Methods {
+		b.function(method)
+	}
+	b.class = enclosing
+
+	return nil
+}
+
+func (b *callGraphBuilder) VisitBadStmt(stmt *ast.BadStmt) error {
+	return nil
+}
+
+// VisitAssignExpr makes the function the variable was bound to dynamic, the calls through
+// the variable might call anything.
+func (b *callGraphBuilder) VisitAssignExpr(expr *ast.Assign) (interface{}, error) {
+	if binding := b.lookup(expr.Name.Lexeme); binding != nil {
+		binding.dynamic = true
+		b.reference(binding)
+	}
+
+	b.walkExpr(expr.Value)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitLogicalExpr(expr *ast.Logical) (interface{}, error) {
+	b.walkExpr(expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitBinaryExpr(expr *ast.Binary) (interface{}, error) {
+	b.walkExpr(expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitCallExpr(expr *ast.Call) (interface{}, error) {
+	switch callee := expr.Callee.(type) {
+	case *ast.VarExpr:
+		if binding := b.lookup(callee.Name.Lexeme); binding != nil {
+			b.call(binding.node, expr.Paren.Line, binding)
+		}
+	case *ast.GetExpr:
+		if _, ok := callee.Object.(*ast.ThisExpr); ok && b.class != nil {
+			b.call(b.class.method(callee.Name.Lexeme), expr.Paren.Line, nil)
+		}
+	case *ast.SuperExpr:
+		if b.class != nil && b.class.superclass != nil {
+			b.call(b.class.superclass.method(callee.Method.Lexeme), expr.Paren.Line, nil)
+		}
+	}
+
+	b.walkExpr(expr.Callee)
+	b.walkExpr(expr.Arguments...)
+	for _, argument := range expr.KeywordArguments {
+		b.walkExpr(argument.Value)
+	}
+
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitGroupingExpr(expr *ast.Grouping) (interface{}, error) {
+	b.walkExpr(expr.Expression)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitLiteralExpr(expr *ast.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitUnaryExpr(expr *ast.Unary) (interface{}, error) {
+	b.walkExpr(expr.Right)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
+	if binding := b.lookup(expr.Name.Lexeme); binding != nil {
+		b.reference(binding)
+	}
+
+	return nil, nil
+}
+
+func (b *callGraphBuilder) reference(binding *callBinding) {
+	b.references = append(b.references, callGraphReference{from: b.current, binding: binding})
+}
+
+func (b *callGraphBuilder) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	b.walkExpr(expr.Object)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	b.walkExpr(expr.Object, expr.Value)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitRangeExpr(expr *ast.Range) (interface{}, error) {
+	b.walkExpr(expr.Start, expr.End)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitComparisonExpr(expr *ast.Comparison) (interface{}, error) {
+	b.walkExpr(expr.Operands...)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitArrayExpr(expr *ast.ArrayLiteral) (interface{}, error) {
+	b.walkExpr(expr.Elements...)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitMapExpr(expr *ast.MapLiteral) (interface{}, error) {
+	b.walkExpr(expr.Keys...)
+	b.walkExpr(expr.Values...)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitTupleExpr(expr *ast.Tuple) (interface{}, error) {
+	b.walkExpr(expr.Elements...)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitIndexGetExpr(expr *ast.IndexGet) (interface{}, error) {
+	b.walkExpr(expr.Object, expr.Index)
+	return nil, nil
+}
+
+func (b *callGraphBuilder) VisitIndexSetExpr(expr *ast.IndexSet) (interface{}, error) {
+	b.walkExpr(expr.Object, expr.Index, expr.Value)
+	return nil, nil
+}
+
+var (
+	_ ast.Visitor     = &callGraphBuilder{}
+	_ ast.StmtVisitor = &callGraphBuilder{}
+)
@@ -0,0 +1,163 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// LoxChannel wraps a Go channel so scripts can take part in Go pipelines. Scripts use the
+// methods receive(), send(value) and close(). Values received are converted like the
+// variables of a sandbox, and values sent are converted to the element type of the channel.
+type LoxChannel struct {
+	channel reflect.Value
+}
+
+// NewLoxChannel wraps the channel, which can be a channel of any element type and
+// direction.
+func NewLoxChannel(channel interface{}) (*LoxChannel, error) {
+	value := reflect.ValueOf(channel)
+	if value.Kind() != reflect.Chan {
+		return nil, fmt.Errorf("%T is not a channel", channel)
+	}
+
+	return &LoxChannel{channel: value}, nil
+}
+
+func (lc *LoxChannel) String() string {
+	return "<" + lc.channel.Type().String() + ">"
+}
+
+// receive blocks until a value is received, or until the context of the interpreter is
+// done. It returns nil if the channel is closed.
+func (lc *LoxChannel) receive(interpreter *Interpreter) (interface{}, error) {
+	if lc.channel.Type().ChanDir()&reflect.RecvDir == 0 {
+		return nil, NewNativeError("Can't receive from a send-only channel")
+	}
+
+	cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: lc.channel}, doneCase(interpreter)}
+	chosen, value, ok := reflect.Select(cases)
+	if chosen == 1 {
+		return nil, interpreter.interrupted(ast.Token{})
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	received, err := sandboxValue(value.Interface())
+	if err != nil {
+		return nil, NewNativeError("Can't receive the value: " + err.Error())
+	}
+
+	return received, nil
+}
+
+// send blocks until the value is sent, or until the context of the interpreter is done.
+// Sending on a closed channel is a runtime error instead of a panic.
+func (lc *LoxChannel) send(interpreter *Interpreter, value interface{}) (err error) {
+	if lc.channel.Type().ChanDir()&reflect.SendDir == 0 {
+		return NewNativeError("Can't send on a receive-only channel")
+	}
+
+	converted, err := goValue(value, lc.channel.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if recover() != nil {
+			err = NewNativeError("Can't send on a closed channel")
+		}
+	}()
+
+	cases := []reflect.SelectCase{{Dir: reflect.SelectSend, Chan: lc.channel, Send: converted}, doneCase(interpreter)}
+	if chosen, _, _ := reflect.Select(cases); chosen == 1 {
+		return interpreter.interrupted(ast.Token{})
+	}
+
+	return nil
+}
+
+// doneCase is the select case of the context of the interpreter being done. Without a
+// context, the case has no channel, and select ignores it.
+func doneCase(interpreter *Interpreter) reflect.SelectCase {
+	if interpreter.ctx == nil {
+		return reflect.SelectCase{Dir: reflect.SelectRecv}
+	}
+
+	return reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(interpreter.ctx.Done())}
+}
+
+// close closes the channel, closing it twice is a runtime error.
+func (lc *LoxChannel) close() (err error) {
+	if lc.channel.Type().ChanDir()&reflect.SendDir == 0 {
+		return NewNativeError("Can't close a receive-only channel")
+	}
+
+	defer func() {
+		if recover() != nil {
+			err = NewNativeError("Can't close a closed channel")
+		}
+	}()
+
+	lc.channel.Close()
+	return nil
+}
+
+func (lc *LoxChannel) property(name ast.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "receive":
+		doc := "receive() waits for a value from the channel, or returns nil once it's closed."
+		return NewNativeFunction("receive", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return lc.receive(interpreter)
+		}), nil
+	case "send":
+		doc := "send(value) waits until the value is sent on the channel."
+		return NewNativeFunction("send", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return nil, lc.send(interpreter, arguments[0])
+		}), nil
+	case "close":
+		doc := "close() closes the channel."
+		return NewNativeFunction("close", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return nil, lc.close()
+		}), nil
+	}
+
+	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+}
+
+// goValue converts a lox value to the Go type. Numbers are converted to any Go number type
+// that holds them exactly, other values must be assignable to the type as they are.
+func goValue(value interface{}, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+
+		return reflect.Value{}, NewNativeError("Can't convert nil to " + t.String())
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	if number, ok := value.(float64); ok {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			converted := v.Convert(t)
+			if number == math.Trunc(number) && converted.Convert(v.Type()).Float() == number {
+				return converted, nil
+			}
+		case reflect.Float32, reflect.Float64:
+			return v.Convert(t), nil
+		}
+	}
+
+	return reflect.Value{}, NewNativeError(fmt.Sprintf("Can't convert %s to %s", value, t))
+}
@@ -0,0 +1,62 @@
+package glox
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestChannelsStopWhenTheCallIsCancelled blocks on a channel nobody sends to or receives
+// from, which must give up once the context of the call is done.
+func TestChannelsStopWhenTheCallIsCancelled(t *testing.T) {
+	for name, source := range map[string]string{
+		"receive": `jobs.receive();`,
+		"send":    `jobs.send(1);`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.Globals = map[string]interface{}{"jobs": make(chan int)}
+			program, diagnostics := Compile("fun wait() { "+source+" }", options)
+			if program == nil {
+				t.Fatalf("compile errors: %v", diagnostics)
+			}
+
+			interpreter := newProgramInterpreter(program.options)
+			interpreter.addLocals(program.locals)
+			if err := program.run(interpreter); err != nil {
+				t.Fatalf("running the script: %s", err.Error())
+			}
+
+			wait, _ := interpreter.Global("wait")
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := interpreter.Call(ctx, wait.(LoxCallable))
+			if err == nil || !strings.Contains(err.Error(), "Interrupted") {
+				t.Errorf("expected the call to be interrupted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestChannelsWithoutAContext(t *testing.T) {
+	jobs := make(chan int, 1)
+	results := make(chan float64, 1)
+	jobs <- 21
+	close(jobs)
+
+	options := DefaultOptions()
+	options.Globals = map[string]interface{}{"jobs": jobs, "results": results}
+	output, err := runSource(t, `
+results.send(jobs.receive() * 2);
+print jobs.receive();
+`, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if output != "nil\n" || <-results != 42 {
+		t.Errorf("printed %q", output)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/iamsayantan/glox"
+)
+
+// bench runs a script, or a function the script defines, many times and prints how long a
+// run takes and how much it allocates. The warmup runs aren't measured, they let the Go
+// runtime size its heap first.
+func bench(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	runs := flags.Int("n", 100, "number of measured runs")
+	warmup := flags.Int("warmup", 5, "number of runs before measuring")
+	function := flags.String("func", "", "run the script once, then measure calls to the function with this name, which takes no arguments")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *runs < 1 || *warmup < 0 {
+		fmt.Println("Usage: glox bench [-n runs] [-warmup runs] [-func name] <file>")
+		return 64
+	}
+
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("error reading file: %s\n", err.Error())
+		return 74
+	}
+
+	program, diagnostics := glox.Compile(string(source), options)
+	if glox.HasErrors(diagnostics) {
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s: %s\n", path, diagnostic)
+		}
+
+		return 65
+	}
+
+	pool := glox.NewPool(program, 1)
+	runOnce := func() error {
+		interpreter := pool.Get()
+		defer pool.Put(interpreter)
+		return pool.Run(interpreter, io.Discard)
+	}
+
+	name := path
+	if *function != "" {
+		interpreter := pool.Get()
+		if err := pool.Run(interpreter, io.Discard); err != nil {
+			fmt.Printf("%s: %s\n", path, err.Error())
+			return 70
+		}
+
+		value, ok := interpreter.Global(*function)
+		callee, callable := value.(glox.LoxCallable)
+		if !ok || !callable {
+			fmt.Printf("%s: no function named '%s'\n", path, *function)
+			return 64
+		}
+
+		interpreter.SetOutput(io.Discard)
+		runOnce = func() error {
+			_, err := interpreter.Call(context.Background(), callee)
+			return err
+		}
+
+		name = path + " " + *function + "()"
+	}
+
+	for n := 0; n < *warmup; n++ {
+		if err := runOnce(); err != nil {
+			fmt.Printf("%s: %s\n", path, err.Error())
+			return 70
+		}
+	}
+
+	var before, after runtime.MemStats
+	times := make([]time.Duration, *runs)
+	runtime.ReadMemStats(&before)
+	for n := range times {
+		start := time.Now()
+		if err := runOnce(); err != nil {
+			fmt.Printf("%s: %s\n", path, err.Error())
+			return 70
+		}
+
+		times[n] = time.Since(start)
+	}
+
+	runtime.ReadMemStats(&after)
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	fmt.Printf("%s: %d runs after %d warmup runs\n", name, *runs, *warmup)
+	fmt.Printf("  min     %s\n", roundDuration(times[0]))
+	fmt.Printf("  median  %s\n", roundDuration(times[len(times)/2]))
+	fmt.Printf("  p95     %s\n", roundDuration(times[int(math.Ceil(0.95*float64(len(times))))-1]))
+	fmt.Printf("  allocs  %d per run, %dB per run\n", (after.Mallocs-before.Mallocs)/uint64(*runs), (after.TotalAlloc-before.TotalAlloc)/uint64(*runs))
+	return 0
+}
+
+// roundDuration rounds the duration to three significant digits, the precision a benchmark
+// run to run can tell apart.
+func roundDuration(d time.Duration) time.Duration {
+	precision := time.Nanosecond
+	for precision*1000 <= d {
+		precision *= 10
+	}
+
+	return d.Round(precision)
+}
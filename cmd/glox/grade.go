@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iamsayantan/glox"
+)
+
+// verdictInvalid is the status of the verdict of a manifest that can't be judged, like one
+// naming a program that doesn't exist.
+const verdictInvalid = "invalid manifest"
+
+// gradeManifest tells grade what to judge: the program, the file it reads its input from,
+// if any, and its time limit in seconds, 0 for none. Paths are relative to the directory
+// of the manifest file, or to the current directory for manifests read from stdin. ID is
+// sent back with the verdict.
+type gradeManifest struct {
+	ID        json.RawMessage `json:"id,omitempty"`
+	Program   string          `json:"program"`
+	Stdin     string          `json:"stdin"`
+	TimeLimit float64         `json:"timeLimit"`
+}
+
+// gradeVerdict is the verdict of a manifest, with its ID.
+type gradeVerdict struct {
+	ID json.RawMessage `json:"id,omitempty"`
+	glox.Verdict
+}
+
+// grade judges programs for autograders and online judges. It reads a manifest from the
+// file, or a stream of manifests from stdin, and writes the verdict of every manifest as
+// a line of JSON: the status, the exit status, what the program printed, how long it ran,
+// its diagnostics and the error that stopped it. The exit status of a single manifest
+// file is the one of its program.
+func grade(options glox.Options, args []string) int {
+	if len(args) > 1 {
+		fmt.Println("Usage: glox grade [manifest]")
+		return 64
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	if len(args) == 1 {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			return 74
+		}
+
+		var manifest gradeManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			fmt.Printf("%s: invalid manifest: %s\n", args[0], err.Error())
+			return 65
+		}
+
+		verdict := judge(options, manifest, filepath.Dir(args[0]))
+		encoder.Encode(verdict)
+		return verdict.ExitStatus
+	}
+
+	decoder := json.NewDecoder(os.Stdin)
+	for {
+		var manifest gradeManifest
+		err := decoder.Decode(&manifest)
+		if err == io.EOF {
+			return 0
+		}
+
+		if err != nil {
+			fmt.Printf("invalid manifest: %s\n", err.Error())
+			return 65
+		}
+
+		encoder.Encode(judge(options, manifest, "."))
+	}
+}
+
+// judge runs the program of the manifest, with the paths of the manifest relative to dir.
+func judge(options glox.Options, manifest gradeManifest, dir string) gradeVerdict {
+	invalid := func(err error) gradeVerdict {
+		return gradeVerdict{ID: manifest.ID, Verdict: glox.Verdict{Status: verdictInvalid, ExitStatus: 74, Diagnostics: []glox.JSONDiagnostic{}, Error: &glox.JSONError{Message: err.Error()}}}
+	}
+
+	if manifest.Program == "" {
+		return invalid(fmt.Errorf("the manifest has no program"))
+	}
+
+	source, err := os.ReadFile(manifestPath(dir, manifest.Program))
+	if err != nil {
+		return invalid(err)
+	}
+
+	var stdin io.Reader = strings.NewReader("")
+	if manifest.Stdin != "" {
+		file, err := os.Open(manifestPath(dir, manifest.Stdin))
+		if err != nil {
+			return invalid(err)
+		}
+
+		defer file.Close()
+		stdin = file
+	}
+
+	limit := time.Duration(manifest.TimeLimit * float64(time.Second))
+	return gradeVerdict{ID: manifest.ID, Verdict: glox.Judge(string(source), stdin, limit, options)}
+}
+
+// manifestPath returns the path of a file named in a manifest, relative to dir unless it's
+// absolute.
+func manifestPath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/iamsayantan/glox"
+)
+
+// The semantic token modifiers glox reports, in the order of their bits.
+var semanticModifiers = []string{"declaration", "global", "defaultLibrary"}
+
+// lspMessage is a JSON-RPC request or notification of the language server protocol.
+// Requests have an ID to answer with.
+type lspMessage struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDocumentParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+	Position lspPosition `json:"position"`
+}
+
+// languageServer serves the language server protocol over a reader and a writer, for the
+// documents the editor opened.
+type languageServer struct {
+	options   glox.Options
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]string
+}
+
+// lsp runs a language server on stdin and stdout. It publishes the diagnostics of the open
+// documents and answers semantic token and document highlight requests.
+func lsp(options glox.Options, args []string) int {
+	server := &languageServer{options: options, in: bufio.NewReader(os.Stdin), out: os.Stdout, documents: make(map[string]string)}
+	if err := server.serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "lsp: %s\n", err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// serve handles messages until the editor sends exit or closes the input.
+func (s *languageServer) serve() error {
+	for {
+		message, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if message.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := s.handle(message)
+		if message.ID == nil {
+			continue
+		}
+
+		response := map[string]interface{}{"id": message.ID, "result": result}
+		if rpcErr != nil {
+			response = map[string]interface{}{"id": message.ID, "error": rpcErr}
+		}
+
+		if err := s.write(response); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles a message, returning the result of requests.
+func (s *languageServer) handle(message *lspMessage) (interface{}, *lspError) {
+	var params lspDocumentParams
+	if len(message.Params) > 0 {
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+	}
+
+	uri := params.TextDocument.URI
+	switch message.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":          1,
+				"documentHighlightProvider": true,
+				"semanticTokensProvider": map[string]interface{}{
+					"legend": map[string]interface{}{"tokenTypes": glox.SemanticKinds(), "tokenModifiers": semanticModifiers},
+					"full":   true,
+				},
+			},
+			"serverInfo": map[string]interface{}{"name": "glox", "version": glox.Version},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		s.documents[uri] = params.TextDocument.Text
+		s.publishDiagnostics(uri)
+	case "textDocument/didChange":
+		if len(params.ContentChanges) > 0 {
+			s.documents[uri] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		}
+
+		s.publishDiagnostics(uri)
+	case "textDocument/didClose":
+		delete(s.documents, uri)
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": []interface{}{}})
+	case "textDocument/semanticTokens/full":
+		return map[string]interface{}{"data": s.semanticTokens(s.documents[uri])}, nil
+	case "textDocument/documentHighlight":
+		return s.highlights(s.documents[uri], params.Position), nil
+	default:
+		if message.ID != nil {
+			return nil, &lspError{Code: -32601, Message: "method not found: " + message.Method}
+		}
+	}
+
+	return nil, nil
+}
+
+// publishDiagnostics sends the diagnostics of checking the document. Diagnostics without a
+// span cover their whole line.
+func (s *languageServer) publishDiagnostics(uri string) {
+	source := s.documents[uri]
+	lines := strings.Split(source, "\n")
+
+	diagnostics := make([]interface{}, 0)
+	for _, diagnostic := range glox.Check(source, s.options) {
+		line := diagnostic.Line - 1
+		start, end := 0, 0
+		if line >= 0 && line < len(lines) {
+			end = utf16Column(lines[line], len([]rune(lines[line]))+1)
+		}
+
+		if diagnostic.Column > 0 && line >= 0 && line < len(lines) {
+			start, end = utf16Column(lines[line], diagnostic.Column), utf16Column(lines[line], diagnostic.EndColumn)
+		}
+
+		severity := 1
+		if diagnostic.Severity == glox.SeverityWarning {
+			severity = 2
+		}
+
+		diagnostics = append(diagnostics, map[string]interface{}{
+			"range":    lspRange{Start: lspPosition{Line: line, Character: start}, End: lspPosition{Line: line, Character: end}},
+			"severity": severity,
+			"code":     diagnostic.Code,
+			"source":   "glox",
+			"message":  diagnostic.Message,
+		})
+	}
+
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": diagnostics})
+}
+
+// semanticTokens encodes the semantic tokens of the source the way the protocol wants them,
+// five numbers per token relative to the token before it.
+func (s *languageServer) semanticTokens(source string) []int {
+	lines := strings.Split(source, "\n")
+	data := make([]int, 0)
+	previousLine, previousStart := 0, 0
+
+	for _, token := range glox.SemanticTokens(source, s.options) {
+		line := token.Line - 1
+		if line < 0 || line >= len(lines) {
+			continue
+		}
+
+		start := utf16Column(lines[line], token.Column)
+		length := utf16Column(lines[line], token.Column+token.Length) - start
+
+		modifiers := 0
+		for bit, set := range []bool{token.Declaration, token.Global, token.Native} {
+			if set {
+				modifiers |= 1 << bit
+			}
+		}
+
+		deltaStart := start
+		if line == previousLine {
+			deltaStart = start - previousStart
+		}
+
+		data = append(data, line-previousLine, deltaStart, length, int(token.Kind), modifiers)
+		previousLine, previousStart = line, start
+	}
+
+	return data
+}
+
+// highlights returns the document highlights of the name at the position.
+func (s *languageServer) highlights(source string, position lspPosition) []interface{} {
+	lines := strings.Split(source, "\n")
+	if position.Line < 0 || position.Line >= len(lines) {
+		return []interface{}{}
+	}
+
+	column := runeColumn(lines[position.Line], position.Character)
+	highlights := make([]interface{}, 0)
+	for _, highlight := range glox.Highlights(source, s.options, position.Line+1, column) {
+		line := highlight.Line - 1
+		if line < 0 || line >= len(lines) {
+			continue
+		}
+
+		start := utf16Column(lines[line], highlight.Column)
+		end := utf16Column(lines[line], highlight.Column+highlight.Length)
+
+		// The kinds of the protocol, 2 is a read and 3 a write.
+		kind := 2
+		if highlight.Write {
+			kind = 3
+		}
+
+		highlights = append(highlights, map[string]interface{}{
+			"range": lspRange{Start: lspPosition{Line: line, Character: start}, End: lspPosition{Line: line, Character: end}},
+			"kind":  kind,
+		})
+	}
+
+	return highlights
+}
+
+// utf16Column converts a column of the line counted in characters from 1, like glox counts
+// them, to the offset in UTF-16 code units the protocol counts positions in.
+func utf16Column(line string, column int) int {
+	runes := []rune(line)
+	if column-1 < len(runes) {
+		runes = runes[:column-1]
+	}
+
+	return len(utf16.Encode(runes))
+}
+
+// runeColumn converts an offset of the line in UTF-16 code units to a column counted in
+// characters from 1.
+func runeColumn(line string, character int) int {
+	units := 0
+	for n, r := range []rune(line) {
+		if units >= character {
+			return n + 1
+		}
+
+		units += len(utf16.Encode([]rune{r}))
+	}
+
+	return len([]rune(line)) + 1
+}
+
+// notify sends a notification to the editor.
+func (s *languageServer) notify(method string, params interface{}) {
+	s.write(map[string]interface{}{"method": method, "params": params})
+}
+
+// read reads a message, a header with its Content-Length and the JSON content.
+func (s *languageServer) read() (*lspMessage, error) {
+	length := -1
+	for {
+		header, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		header = strings.TrimSpace(header)
+		if header == "" {
+			break
+		}
+
+		if value := strings.TrimPrefix(header, "Content-Length:"); value != header {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid header %q", header)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(s.in, content); err != nil {
+		return nil, err
+	}
+
+	message := &lspMessage{}
+	if err := json.Unmarshal(content, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// write sends a message with its header.
+func (s *languageServer) write(message map[string]interface{}) error {
+	message["jsonrpc"] = "2.0"
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	return err
+}
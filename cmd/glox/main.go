@@ -1,14 +1,668 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/iamsayantan/glox"
+	"github.com/iamsayantan/glox/ast"
 )
 
+// commands are the subcommands of glox. Anything else on the command line is run as a
+// script.
+var commands = map[string]func(options glox.Options, args []string) int{
+	"astdiff":   astdiff,
+	"bench":     bench,
+	"callgraph": callgraph,
+	"check":     check,
+	"deadcode":  deadcode,
+	"explain":   explain,
+	"fix":       fix,
+	"fmt":       format,
+	"grade":     grade,
+	"lsp":       lsp,
+	"metrics":   metrics,
+	"minify":    minify,
+	"minimize":  minimize,
+	"repl":      repl,
+	"run":       run,
+	"tags":      tags,
+	"template":  template,
+	"version":   version,
+}
+
 func main() {
-	args := os.Args[1:]
+	options := glox.DefaultOptions()
+	flag.Func("edition", "language edition, one of "+strings.Join(glox.Editions(), ", ")+"; later flags can turn single extensions on or off", func(edition string) error {
+		language, err := glox.EditionOptions(edition)
+		if err != nil {
+			return err
+		}
+
+		options.LanguageOptions = language
+		return nil
+	})
+	flag.IntVar(&options.MaxArguments, "max-args", options.MaxArguments, "maximum number of parameters and arguments of a function")
+	flag.IntVar(&options.MaxCallDepth, "max-call-depth", options.MaxCallDepth, "maximum depth of the call stack before a stack overflow error, 0 for no limit")
+	flag.BoolVar(&options.OptionalSemicolons, "optional-semicolons", options.OptionalSemicolons, "let line breaks terminate statements")
+	flag.BoolVar(&options.Stats, "stats", options.Stats, "print call counts, time and allocations per function at exit")
+	flag.BoolVar(&options.DebugResolver, "debug-resolver", options.DebugResolver, "validate resolved variable distances against dynamic lookups")
+	flag.BoolVar(&options.DebugEnv, "debug-env", options.DebugEnv, "print the variables of every scope around a runtime error")
+	flag.StringVar(&options.TraceOut, "trace-out", options.TraceOut, "write a Chrome trace of the function calls to the file")
+	flag.StringVar(&options.RecordOut, "record", options.RecordOut, "log the results of clock, random and input to the file, to replay the run later")
+	flag.StringVar(&options.ReplayIn, "replay", options.ReplayIn, "replay a run logged with --record")
+	flag.BoolVar(&options.History, "history", options.History, "keep a snapshot of the environment after every statement, for :history and :back")
+	flag.BoolVar(&options.BigNumbers, "big-numbers", options.BigNumbers, "use arbitrary precision numbers instead of float64")
+	flag.BoolVar(&options.StrictLogical, "strict-logical", options.StrictLogical, "make 'and' and 'or' require true or false operands")
+	flag.BoolVar(&options.NoPrelude, "no-prelude", options.NoPrelude, "don't load the standard library written in lox")
+	flag.BoolVar(&options.PrintAst, "ast", options.PrintAst, "print the syntax tree before running, even when it has parse errors")
+	flag.Func("watch", "print the value and the call stack every time the variables, separated by commas, are assigned", func(names string) error {
+		options.Watch = append(options.Watch, strings.Split(names, ",")...)
+		return nil
+	})
+	flag.BoolVar(&options.MinimizeCrashes, "minimize-crashes", options.MinimizeCrashes, "add the smallest source that still crashes glox to crash reports")
+	flag.Func("break", "pause at the line to evaluate expressions, written as LINE [after N] [if CONDITION]", func(s string) error {
+		bp, err := glox.ParseBreakpoint(s)
+		options.Breakpoints = append(options.Breakpoints, bp)
+		return err
+	})
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) > 0 {
+		if command, ok := commands[args[0]]; ok {
+			os.Exit(command(options, args[1:]))
+		}
+	}
 
-	runtime := glox.NewRuntime()
+	runtime := glox.NewRuntimeWithOptions(options)
 	runtime.Run(args)
 }
+
+// template renders a template file to the standard output.
+func template(options glox.Options, args []string) int {
+	if len(args) != 1 {
+		fmt.Println("Usage: glox template <file>")
+		return 64
+	}
+
+	runtime := glox.NewRuntimeWithOptions(options)
+	runtime.RunTemplate(args[0])
+	return 0
+}
+
+// repl runs the prompt, or with --json-io the prompt for programs, which reads requests and
+// writes the results as JSON lines.
+func repl(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("repl", flag.ExitOnError)
+	jsonIO := flags.Bool("json-io", false, "read JSON requests from stdin and write every evaluation's result, output and diagnostics as a JSON line")
+	flags.Parse(args)
+
+	if flags.NArg() != 0 {
+		fmt.Println("Usage: glox repl [--json-io]")
+		return 64
+	}
+
+	runtime := glox.NewRuntimeWithOptions(options)
+	if *jsonIO {
+		runtime.RunJSONPrompt(os.Stdin, os.Stdout)
+	} else {
+		runtime.RunPrompt()
+	}
+
+	return 0
+}
+
+// explain prints the description of a diagnostic code, with an example of code that gets
+// the diagnostic and how to fix it. Without a code it lists every code.
+func explain(options glox.Options, args []string) int {
+	if len(args) == 0 {
+		for _, code := range glox.DiagnosticCodes() {
+			fmt.Printf("%s  %s\n", code.Code, code.Title)
+		}
+
+		return 0
+	}
+
+	if len(args) != 1 {
+		fmt.Println("Usage: glox explain [code]")
+		return 64
+	}
+
+	code, ok := glox.Explain(strings.ToUpper(args[0]))
+	if !ok {
+		fmt.Printf("Unknown diagnostic code '%s', glox explain lists the codes\n", args[0])
+		return 64
+	}
+
+	fmt.Printf("%s: %s\n\n%s\n", code.Code, code.Title, code.Explanation)
+	if code.Example != "" {
+		fmt.Printf("\nExample:\n%s\n", indent(code.Example))
+	}
+
+	if code.Fix != "" {
+		fmt.Printf("\nFix:\n%s\n", indent(code.Fix))
+	}
+
+	return 0
+}
+
+// indent indents every line of the code by four spaces.
+func indent(code string) string {
+	return "    " + strings.ReplaceAll(code, "\n", "\n    ")
+}
+
+// version prints the version of glox and how it was built.
+func version(options glox.Options, args []string) int {
+	fmt.Println(glox.VersionInfo())
+	return 0
+}
+
+// astdiff prints the differences between the syntax trees of two files. Like diff, it
+// exits with 0 if the trees are the same and 1 if they differ, and with 65 if either file
+// has parse errors.
+func astdiff(options glox.Options, args []string) int {
+	if len(args) != 2 {
+		fmt.Println("Usage: glox astdiff <old file> <new file>")
+		return 64
+	}
+
+	trees := make([][]ast.Stmt, 0, 2)
+	for _, path := range args {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			return 74
+		}
+
+		statements, diagnostics := glox.Parse(string(source), options)
+		if glox.HasErrors(diagnostics) {
+			for _, diagnostic := range diagnostics {
+				fmt.Printf("%s: %s\n", path, diagnostic)
+			}
+
+			return 65
+		}
+
+		trees = append(trees, statements)
+	}
+
+	diff := glox.AstDiff(trees[0], trees[1])
+	if !glox.HasChanges(diff) {
+		return 0
+	}
+
+	fmt.Printf("--- %s\n+++ %s\n", args[0], args[1])
+	fmt.Print(glox.FormatDiff(diff, 3))
+	return 1
+}
+
+// metrics prints the size and complexity of every function in the files. With budgets set,
+// it exits with 1 and lists the functions over budget if there are any.
+func metrics(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("metrics", flag.ExitOnError)
+	maxComplexity := flags.Int("max-complexity", 0, "fail if a function's cyclomatic complexity is higher, 0 for no limit")
+	maxDepth := flags.Int("max-depth", 0, "fail if a function nests ifs and loops deeper, 0 for no limit")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: glox metrics [--max-complexity n] [--max-depth n] <file>...")
+		return 64
+	}
+
+	status := 0
+	over := make([]string, 0)
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			status = 74
+			continue
+		}
+
+		statements, diagnostics := glox.Parse(string(source), options)
+		if glox.HasErrors(diagnostics) {
+			for _, diagnostic := range diagnostics {
+				fmt.Printf("%s: %s\n", path, diagnostic)
+			}
+
+			status = 65
+			continue
+		}
+
+		functions := glox.Metrics(statements)
+		fmt.Println(path + ":")
+		glox.WriteMetrics(os.Stdout, functions)
+
+		for _, m := range functions {
+			if *maxComplexity > 0 && m.Complexity > *maxComplexity {
+				over = append(over, fmt.Sprintf("%s:%d: %s has complexity %d, the budget is %d", path, m.Line, m.Name, m.Complexity, *maxComplexity))
+			}
+
+			if *maxDepth > 0 && m.Depth > *maxDepth {
+				over = append(over, fmt.Sprintf("%s:%d: %s nests %d deep, the budget is %d", path, m.Line, m.Name, m.Depth, *maxDepth))
+			}
+		}
+	}
+
+	for _, message := range over {
+		fmt.Println(message)
+	}
+
+	if status == 0 && len(over) > 0 {
+		status = 1
+	}
+
+	return status
+}
+
+// minify prints the file without comments and unneeded whitespace. It exits with 65 and
+// prints the diagnostics instead if the file has errors.
+func minify(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("minify", flag.ExitOnError)
+	minifyOptions := glox.MinifyOptions{}
+	flags.BoolVar(&minifyOptions.RenameLocals, "rename-locals", false, "shorten the names of local variables")
+	flags.BoolVar(&minifyOptions.StripDeadCode, "strip-dead", false, "leave out the functions, classes and globals that are never used")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: glox minify [--rename-locals] [--strip-dead] <file>")
+		return 64
+	}
+
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("error reading file: %s\n", err.Error())
+		return 74
+	}
+
+	minified, diagnostics := glox.Minify(string(source), options, minifyOptions)
+	if glox.HasErrors(diagnostics) {
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s: %s\n", path, diagnostic)
+		}
+
+		return 65
+	}
+
+	fmt.Println(minified)
+	return 0
+}
+
+// minimize prints the smallest source left by removing lines from the file that still fails
+// the way the check asks for: with the same panic for "panics", or with the same error,
+// wherever it is, for "error". It exits with 1 if the file doesn't fail that way to begin
+// with.
+func minimize(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("minimize", flag.ExitOnError)
+	checkFor := flags.String("check", "panics", "how the file fails, panics or error")
+	flags.Parse(args)
+
+	// The flags can come after the file too, as in glox minimize repro.lox --check error.
+	path, extra := flags.Arg(0), 0
+	if flags.NArg() > 1 {
+		flags.Parse(flags.Args()[1:])
+		extra = flags.NArg()
+	}
+
+	if path == "" || extra != 0 || (*checkFor != "panics" && *checkFor != "error") {
+		fmt.Println("Usage: glox minimize [--check panics|error] <file>")
+		return 64
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("error reading file: %s\n", err.Error())
+		return 74
+	}
+
+	failure := glox.RunQuietly(string(source), options).Failure()
+	if (*checkFor == "panics" && !strings.HasPrefix(failure, "panic: ")) || (*checkFor == "error" && (failure == "" || strings.HasPrefix(failure, "panic: "))) {
+		fmt.Printf("%s: doesn't fail with the check '%s'\n", path, *checkFor)
+		return 1
+	}
+
+	fmt.Print(glox.Minimize(string(source), func(candidate string) bool {
+		return glox.RunQuietly(candidate, options).Failure() == failure
+	}))
+	return 0
+}
+
+// tags writes a tags file of the functions, classes, methods and global variables of the
+// files, in ctags format or with -e in etags format, for editors without a language server.
+// Directories are searched for .lox files, and like for go tools, dir/... is the directory
+// and all the ones below it. It exits with 65 if a file has errors, still writing the tags
+// of the others.
+func tags(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("tags", flag.ExitOnError)
+	etags := flags.Bool("e", false, "write an Emacs TAGS file instead of a ctags file")
+	output := flags.String("o", "", "the file to write, tags or TAGS by default, - for the standard output")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: glox tags [-e] [-o file] <file or directory>...")
+		return 64
+	}
+
+	paths, err := loxFiles(flags.Args())
+	if err != nil {
+		fmt.Printf("error listing files: %s\n", err.Error())
+		return 74
+	}
+
+	status := 0
+	files := make([]glox.TagFile, 0, len(paths))
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			status = 74
+			continue
+		}
+
+		statements, diagnostics := glox.Parse(string(source), options)
+		if glox.HasErrors(diagnostics) {
+			for _, diagnostic := range diagnostics {
+				fmt.Printf("%s: %s\n", path, diagnostic)
+			}
+
+			status = 65
+			continue
+		}
+
+		files = append(files, glox.TagFile{Path: path, Source: string(source), Tags: glox.Tags(statements)})
+	}
+
+	write := glox.WriteCtags
+	if *etags {
+		write = glox.WriteEtags
+	}
+
+	if *output == "" {
+		*output = "tags"
+		if *etags {
+			*output = "TAGS"
+		}
+	}
+
+	out := os.Stdout
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			fmt.Printf("error writing tags: %s\n", err.Error())
+			return 74
+		}
+
+		defer out.Close()
+	}
+
+	if err := write(out, files); err != nil {
+		fmt.Printf("error writing tags: %s\n", err.Error())
+		return 74
+	}
+
+	return status
+}
+
+// loxFiles expands the arguments to the files they name: files are kept as they are,
+// directories are replaced by the .lox files in them, and dir/... by the .lox files in the
+// directory and all the ones below it.
+func loxFiles(args []string) ([]string, error) {
+	paths := make([]string, 0)
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "...") {
+			root := filepath.Clean(strings.TrimSuffix(arg, "..."))
+			err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+				if err == nil && !entry.IsDir() && filepath.Ext(path) == ".lox" {
+					paths = append(paths, path)
+				}
+
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(arg, "*.lox"))
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// callgraph prints the static call graph of the file as DOT or JSON.
+func callgraph(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("callgraph", flag.ExitOnError)
+	format := flags.String("format", "dot", "output format, dot or json")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || (*format != "dot" && *format != "json") {
+		fmt.Println("Usage: glox callgraph [--format dot|json] <file>")
+		return 64
+	}
+
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("error reading file: %s\n", err.Error())
+		return 74
+	}
+
+	statements, diagnostics := glox.Parse(string(source), options)
+	if glox.HasErrors(diagnostics) {
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s: %s\n", path, diagnostic)
+		}
+
+		return 65
+	}
+
+	graph := glox.BuildCallGraph(statements)
+	if *format == "json" {
+		if err := graph.WriteJSON(os.Stdout); err != nil {
+			fmt.Printf("error writing call graph: %s\n", err.Error())
+			return 74
+		}
+
+		return 0
+	}
+
+	graph.WriteDot(os.Stdout)
+	return 0
+}
+
+// deadcode lists the functions, classes and global variables the files never use. It exits
+// with 1 if there are any.
+func deadcode(options glox.Options, args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: glox deadcode <file>...")
+		return 64
+	}
+
+	status := 0
+	for _, path := range args {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			status = 74
+			continue
+		}
+
+		statements, diagnostics := glox.Parse(string(source), options)
+		if glox.HasErrors(diagnostics) {
+			for _, diagnostic := range diagnostics {
+				fmt.Printf("%s: %s\n", path, diagnostic)
+			}
+
+			status = 65
+			continue
+		}
+
+		for _, dead := range glox.DeadCode(statements) {
+			fmt.Printf("%s:%d: %s '%s' is never used\n", path, dead.Line, dead.Kind, dead.Name)
+			if status == 0 {
+				status = 1
+			}
+		}
+	}
+
+	return status
+}
+
+// check parses and resolves the files without running them, printing every diagnostic.
+// It exits with 65, like running a script with errors, if any file has errors.
+func check(options glox.Options, args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: glox check <file>...")
+		return 64
+	}
+
+	status := 0
+	for _, path := range args {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			status = 74
+			continue
+		}
+
+		diagnostics := glox.Check(string(source), options)
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s: %s\n", path, diagnostic)
+			if squiggle := diagnostic.Squiggle(string(source)); squiggle != "" {
+				fmt.Println(squiggle)
+			}
+
+			if diagnostic.Fix != nil {
+				fmt.Printf("fix: %s\n", diagnostic.Fix.Description)
+			}
+		}
+
+		if glox.HasErrors(diagnostics) && status == 0 {
+			status = 65
+		}
+	}
+
+	return status
+}
+
+// format rewrites the files in the canonical layout, or with --check, only lists the files
+// that aren't formatted and fails if there are any, for CI.
+func format(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	checkOnly := flags.Bool("check", false, "list the files that aren't formatted instead of writing them, and fail if there are any")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: glox fmt [--check] <file>...")
+		return 64
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			status = 74
+			continue
+		}
+
+		formatted, diagnostics := glox.Format(string(source), options)
+		if glox.HasErrors(diagnostics) {
+			for _, diagnostic := range diagnostics {
+				fmt.Printf("%s: %s\n", path, diagnostic)
+			}
+
+			status = 65
+			continue
+		}
+
+		if formatted == string(source) {
+			continue
+		}
+
+		if *checkOnly {
+			fmt.Println(path)
+			if status == 0 {
+				status = 1
+			}
+
+			continue
+		}
+
+		if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+			fmt.Printf("error writing file: %s\n", err.Error())
+			status = 74
+		}
+	}
+
+	return status
+}
+
+// fix applies the fixes of the diagnostics of the files and writes them back, printing
+// each fix and the diagnostics left without one. With --dry-run it prints the fixed
+// source instead of writing it. It exits with 65 if any file still has errors.
+func fix(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("fix", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "print the fixed source instead of writing the file")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: glox fix [--dry-run] <file>...")
+		return 64
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("error reading file: %s\n", err.Error())
+			status = 74
+			continue
+		}
+
+		fixed, fixes, diagnostics := glox.FixSource(string(source), options)
+		for _, fix := range fixes {
+			fmt.Printf("%s: [line %d] %s\n", path, fix.Line, fix.Description)
+		}
+
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s: %s\n", path, diagnostic)
+		}
+
+		if glox.HasErrors(diagnostics) && status == 0 {
+			status = 65
+		}
+
+		if *dryRun {
+			fmt.Print(fixed)
+			continue
+		}
+
+		if len(fixes) > 0 {
+			if err := os.WriteFile(path, []byte(fixed), 0644); err != nil {
+				fmt.Printf("error writing file: %s\n", err.Error())
+				status = 74
+			}
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/iamsayantan/glox"
+)
+
+// expectPrefix and expectErrorPrefix start the comments that tell a batch run what a test
+// file prints, line by line, and the runtime error it stops with.
+const (
+	expectPrefix      = "// expect: "
+	expectErrorPrefix = "// expect runtime error: "
+)
+
+// Test results of a batch run.
+const (
+	resultPass  = "pass"
+	resultFail  = "fail"
+	resultError = "error"
+)
+
+// testResult is the outcome of running one file of a batch.
+type testResult struct {
+	path   string
+	result string
+	detail string
+}
+
+// run runs a script, like glox <file> does. With --isolate-tests it runs every .lox file
+// in the directories instead, each as a program of its own, and prints a table of the
+// results. It exits with 1 if any file didn't pass.
+func run(options glox.Options, args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	isolate := flags.Bool("isolate-tests", false, "run every .lox file in the directories in a fresh interpreter and summarize the results")
+	flags.Parse(args)
+
+	if !*isolate {
+		if flags.NArg() != 1 {
+			fmt.Println("Usage: glox run <file> | glox run --isolate-tests <dir>...")
+			return 64
+		}
+
+		glox.NewRuntimeWithOptions(options).RunFile(flags.Arg(0))
+		return 0
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: glox run --isolate-tests <dir>...")
+		return 64
+	}
+
+	paths := make([]string, 0)
+	for _, dir := range flags.Args() {
+		err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && filepath.Ext(path) == ".lox" {
+				paths = append(paths, path)
+			}
+
+			return err
+		})
+
+		if err != nil {
+			fmt.Printf("error reading directory: %s\n", err.Error())
+			return 74
+		}
+	}
+
+	sort.Strings(paths)
+	results := make([]testResult, 0, len(paths))
+	for _, path := range paths {
+		results = append(results, runTest(options, path))
+	}
+
+	return printResults(results)
+}
+
+// runTest compiles and runs the file as a program of its own. A file passes if it runs
+// without errors and prints what its expect comments say, if it has any. A file that
+// expects a runtime error passes if it stops with that error.
+func runTest(options glox.Options, path string) (result testResult) {
+	result.path = path
+	defer func() {
+		if r := recover(); r != nil {
+			result.result, result.detail = resultError, fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return testResult{path: path, result: resultError, detail: err.Error()}
+	}
+
+	program, diagnostics := glox.Compile(string(source), options)
+	if glox.HasErrors(diagnostics) {
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Severity == glox.SeverityError {
+				return testResult{path: path, result: resultError, detail: diagnostic.String()}
+			}
+		}
+	}
+
+	expected, expectedError := expectations(string(source))
+	var out bytes.Buffer
+	err = program.Run(&out)
+
+	if err != nil && expectedError == "" {
+		return testResult{path: path, result: resultError, detail: "runtime error: " + err.Error()}
+	}
+
+	if expectedError != "" {
+		if err == nil {
+			return testResult{path: path, result: resultFail, detail: "expected runtime error: " + expectedError}
+		}
+
+		if err.Error() != expectedError {
+			return testResult{path: path, result: resultFail, detail: fmt.Sprintf("expected runtime error %q, got %q", expectedError, err.Error())}
+		}
+	}
+
+	if expected != nil {
+		printed := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if out.Len() == 0 {
+			printed = []string{}
+		}
+
+		for n := 0; n < len(expected) || n < len(printed); n++ {
+			switch {
+			case n >= len(printed):
+				return testResult{path: path, result: resultFail, detail: fmt.Sprintf("output line %d: expected %q, got nothing", n+1, expected[n])}
+			case n >= len(expected):
+				return testResult{path: path, result: resultFail, detail: fmt.Sprintf("output line %d: got unexpected %q", n+1, printed[n])}
+			case printed[n] != expected[n]:
+				return testResult{path: path, result: resultFail, detail: fmt.Sprintf("output line %d: expected %q, got %q", n+1, expected[n], printed[n])}
+			}
+		}
+	}
+
+	return testResult{path: path, result: resultPass}
+}
+
+// expectations returns the lines the expect comments of the source say it prints, nil if
+// there are none, and the runtime error it's expected to stop with.
+func expectations(source string) ([]string, string) {
+	var expected []string
+	expectedError := ""
+	for _, line := range strings.Split(source, "\n") {
+		if index := strings.Index(line, expectPrefix); index >= 0 {
+			expected = append(expected, strings.TrimRight(line[index+len(expectPrefix):], "\r"))
+		} else if index := strings.Index(line, expectErrorPrefix); index >= 0 {
+			expectedError = strings.TrimSpace(line[index+len(expectErrorPrefix):])
+		}
+	}
+
+	return expected, expectedError
+}
+
+// printResults prints a row per file and a summary line. It returns the exit status of the
+// batch, 1 if any file didn't pass.
+func printResults(results []testResult) int {
+	width := len("FILE")
+	for _, result := range results {
+		if len(result.path) > width {
+			width = len(result.path)
+		}
+	}
+
+	counts := make(map[string]int)
+	fmt.Printf("%-*s  %-6s  %s\n", width, "FILE", "RESULT", "DETAIL")
+	for _, result := range results {
+		counts[result.result]++
+		row := fmt.Sprintf("%-*s  %-6s  %s", width, result.path, result.result, result.detail)
+		fmt.Println(strings.TrimRight(row, " "))
+	}
+
+	fmt.Printf("\n%d files: %d passed, %d failed, %d errors\n", len(results), counts[resultPass], counts[resultFail], counts[resultError])
+	if counts[resultPass] != len(results) {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,367 @@
+package glox
+
+import (
+	"regexp"
+	"sort"
+)
+
+// diagnosticPhase is the stage of glox a diagnostic comes from. Every phase has a range of
+// codes of its own: E1 for the scanner, E2 for the parser, E3 for the resolver and E4 for
+// runtime errors.
+type diagnosticPhase int
+
+const (
+	phaseScanner diagnosticPhase = iota
+	phaseParser
+	phaseResolver
+	phaseRuntime
+)
+
+// DiagnosticCode describes a kind of diagnostic, for glox explain. Codes are stable, a code
+// is never reused for a different kind of diagnostic.
+type DiagnosticCode struct {
+	Code        string
+	Title       string
+	Explanation string
+	// Example is code that gets the diagnostic, Fix the same code with the problem fixed.
+	Example string
+	Fix     string
+
+	phase diagnosticPhase
+	// pattern matches the messages of the diagnostics of this kind. The last code of every
+	// phase has no pattern, it's the code of the messages no other code matches.
+	pattern *regexp.Regexp
+}
+
+// diagnosticCodes are tried in order, the first code of the phase whose pattern matches the
+// message is the code of a diagnostic.
+var diagnosticCodes = []DiagnosticCode{
+	{
+		Code: "E1001", Title: "Unexpected character", phase: phaseScanner, pattern: regexp.MustCompile(`^Unexpected characters?$`),
+		Explanation: "The characters can't start any token of lox. They are skipped, and the rest of the line is still parsed.",
+		Example:     "print 2 # 3;",
+		Fix:         "print 2 + 3;",
+	},
+	{
+		Code: "E1002", Title: "Unterminated string", phase: phaseScanner, pattern: regexp.MustCompile(`^Unterminated string`),
+		Explanation: "A string literal was opened with '\"' but the file ended before the closing '\"'. Strings can span lines, so the missing quote may be far above the end of the file.",
+		Example:     "print \"hello;",
+		Fix:         "print \"hello\";",
+	},
+	{
+		Code: "E1003", Title: "Unterminated template tag", phase: phaseScanner, pattern: regexp.MustCompile(`^Unterminated '.*' tag`),
+		Explanation: "A '{{' or '{%' tag of a template has no closing '}}' or '%}'.",
+		Example:     "Hello {{ name!",
+		Fix:         "Hello {{ name }}!",
+	},
+	{
+		Code: "E1000", Title: "Invalid token", phase: phaseScanner,
+		Explanation: "The scanner couldn't turn the source into tokens.",
+	},
+	{
+		Code: "E2001", Title: "Missing semicolon", phase: phaseParser, pattern: regexp.MustCompile(`^Expect (a )?'?;`),
+		Explanation: "Statements end with a ';'. With --optional-semicolons a line break ends a statement too.",
+		Example:     "print \"hello\"",
+		Fix:         "print \"hello\";",
+	},
+	{
+		Code: "E2002", Title: "For loop without parentheses", phase: phaseParser, pattern: regexp.MustCompile(`^Expect '\(' after 'for'|^Expect for clauses`),
+		Explanation: "The clauses of a for loop go in parentheses, separated by semicolons.",
+		Example:     "for var i = 0; i < 3; i = i + 1 print i;",
+		Fix:         "for (var i = 0; i < 3; i = i + 1) print i;",
+	},
+	{
+		Code: "E2003", Title: "Missing closing bracket", phase: phaseParser, pattern: regexp.MustCompile(`^Expect '[)\]}]'`),
+		Explanation: "A '(', '[' or '{' was opened but the parser found something else where it expected it to be closed.",
+		Example:     "print (1 + 2;",
+		Fix:         "print (1 + 2);",
+	},
+	{
+		Code: "E2004", Title: "Missing opening bracket", phase: phaseParser, pattern: regexp.MustCompile(`^Expected? '[({\[]'`),
+		Explanation: "The construct needs a '(' or '{' here, like the condition of an if statement or the body of a class.",
+		Example:     "if x > 1 print x;",
+		Fix:         "if (x > 1) print x;",
+	},
+	{
+		Code: "E2005", Title: "Missing name", phase: phaseParser, pattern: regexp.MustCompile(`^Expect .*name`),
+		Explanation: "A declaration, parameter list or property access needs an identifier here.",
+		Example:     "var = 1;",
+		Fix:         "var count = 1;",
+	},
+	{
+		Code: "E2006", Title: "Missing expression", phase: phaseParser, pattern: regexp.MustCompile(`^Expect Expression`),
+		Explanation: "The parser expected a value, like a literal, a variable or a call, but found a token that can't start one.",
+		Example:     "var x = ;",
+		Fix:         "var x = 1;",
+	},
+	{
+		Code: "E2007", Title: "Missing left-hand operand", phase: phaseParser, pattern: regexp.MustCompile(`^Missing left-hand operand`),
+		Explanation: "A binary operator has nothing on its left. Only '-' and '!' can be used in front of a single operand.",
+		Example:     "var x = * 2;",
+		Fix:         "var x = y * 2;",
+	},
+	{
+		Code: "E2008", Title: "Invalid assignment target", phase: phaseParser, pattern: regexp.MustCompile(`^Invalid assignment target`),
+		Explanation: "Only variables, fields and elements of arrays and maps can be assigned to.",
+		Example:     "a + b = c;",
+		Fix:         "a = c - b;",
+	},
+	{
+		Code: "E2009", Title: "Too many parameters or arguments", phase: phaseParser, pattern: regexp.MustCompile(`^Can't have more than`),
+		Explanation: "Functions are limited in the number of parameters they declare and calls in the number of arguments they pass, 255 unless --max-args says otherwise.",
+		Example:     "fun f(a1, a2, ..., a256) {}",
+		Fix:         "fun f(options) {}",
+	},
+	{
+		Code: "E2010", Title: "Extension not enabled", phase: phaseParser, pattern: regexp.MustCompile(`are not enabled in the`),
+		Explanation: "The syntax belongs to an extension of lox that the edition doesn't enable. The canonical edition only has the lox of the book.",
+		Example:     "glox --edition canonical: var a = [1, 2];",
+		Fix:         "glox --edition glox: var a = [1, 2];",
+	},
+	{
+		Code: "E2011", Title: "Misplaced keyword argument", phase: phaseParser, pattern: regexp.MustCompile(`^Duplicate keyword argument|^Positional argument can't follow`),
+		Explanation: "Keyword arguments come after the positional ones, and every parameter can be passed by name only once.",
+		Example:     "greet(name: \"Ada\", \"hello\");",
+		Fix:         "greet(\"hello\", name: \"Ada\");",
+	},
+	{
+		Code: "E2012", Title: "print used like a function", phase: phaseParser, pattern: regexp.MustCompile(`'print'`),
+		Explanation: "print is a statement that prints one value. It can't be used as a value, and it doesn't take an argument list.",
+		Example:     "print(\"a\", \"b\");",
+		Fix:         "print \"a\";\nprint \"b\";",
+	},
+	{
+		Code: "E2013", Title: "Assignment used as a condition", phase: phaseParser, pattern: regexp.MustCompile(`^Assignment used as a condition`),
+		Explanation: "A warning for '=' in a condition, which is usually a mistyped '=='. Wrap the assignment in parentheses if it's intended.",
+		Example:     "if (x = 1) print x;",
+		Fix:         "if (x == 1) print x;",
+	},
+	{
+		Code: "E2014", Title: "Function declared without fun", phase: phaseParser, pattern: regexp.MustCompile(`^Expect 'fun' before`),
+		Explanation: "Function declarations start with the fun keyword.",
+		Example:     "add(a, b) { return a + b; }",
+		Fix:         "fun add(a, b) { return a + b; }",
+	},
+	{
+		Code: "E2015", Title: "Operator can't be chained", phase: phaseParser, pattern: regexp.MustCompile(`can't be chained`),
+		Explanation: "The operator is not associative, so two of them in a row are ambiguous. Parentheses say which one applies first.",
+		Example:     "var r = 1..2..3;",
+		Fix:         "var r = 1..(2 + 3);",
+	},
+	{
+		Code: "E2016", Title: "try without catch or finally", phase: phaseParser, pattern: regexp.MustCompile(`^Expect 'catch' or 'finally'`),
+		Explanation: "A try block needs a catch clause, a finally block or both.",
+		Example:     "try { risky(); }",
+		Fix:         "try { risky(); } catch (e) { print e; }",
+	},
+	{
+		Code: "E2000", Title: "Syntax error", phase: phaseParser,
+		Explanation: "The tokens don't follow the grammar of lox. The message says what the parser expected.",
+	},
+	{
+		Code: "E3001", Title: "Undefined variable", phase: phaseResolver, pattern: regexp.MustCompile(`^Undefined variable`),
+		Explanation: "Scripts are checked as a whole before they run, and the variable isn't declared anywhere in scope. It's often a misspelled name.",
+		Example:     "var count = 1;\nprint cuont;",
+		Fix:         "var count = 1;\nprint count;",
+	},
+	{
+		Code: "E3002", Title: "Variable read in its own initializer", phase: phaseResolver, pattern: regexp.MustCompile(`in its own initializer`),
+		Explanation: "A local variable doesn't exist until its initializer has run, so the initializer can't read it.",
+		Example:     "{ var a = a + 1; }",
+		Fix:         "{ var b = a + 1; }",
+	},
+	{
+		Code: "E3003", Title: "Variable declared twice", phase: phaseResolver, pattern: regexp.MustCompile(`^Already a variable with this name`),
+		Explanation: "A scope can only declare a local variable once. Assign to it instead of declaring it again.",
+		Example:     "{ var a = 1; var a = 2; }",
+		Fix:         "{ var a = 1; a = 2; }",
+	},
+	{
+		Code: "E3004", Title: "this or super outside of a class", phase: phaseResolver, pattern: regexp.MustCompile(`^Can't use '(this|super)' outside of a class`),
+		Explanation: "this and super refer to the instance a method is called on, so they only have a meaning in methods.",
+		Example:     "fun f() { return this; }",
+		Fix:         "class C { f() { return this; } }",
+	},
+	{
+		Code: "E3005", Title: "super without a superclass", phase: phaseResolver, pattern: regexp.MustCompile(`^Can't use 'super' in class with no superclass`),
+		Explanation: "super calls a method of the superclass, which the class doesn't have.",
+		Example:     "class B { f() { super.f(); } }",
+		Fix:         "class B < A { f() { super.f(); } }",
+	},
+	{
+		Code: "E3006", Title: "return outside of a function", phase: phaseResolver, pattern: regexp.MustCompile(`^Can't return from top-level code`),
+		Explanation: "return leaves a function, there's none to leave at the top level of a script.",
+		Example:     "return 1;",
+		Fix:         "fun f() { return 1; }",
+	},
+	{
+		Code: "E3007", Title: "Value returned from an initializer", phase: phaseResolver, pattern: regexp.MustCompile(`^Can't return a value from initializer`),
+		Explanation: "init always returns the new instance. It can return early with a bare return.",
+		Example:     "class P { init() { return 1; } }",
+		Fix:         "class P { init() { return; } }",
+	},
+	{
+		Code: "E3008", Title: "Class inherits from itself", phase: phaseResolver, pattern: regexp.MustCompile(`^A class can't inherit from itself`),
+		Explanation: "The superclass of a class must be another class.",
+		Example:     "class A < A {}",
+		Fix:         "class A < Base {}",
+	},
+	{
+		Code: "E3009", Title: "Keyword arguments don't match the parameters", phase: phaseResolver, pattern: regexp.MustCompile(`has no parameter named|already passed positionally|^Missing argument for parameter`),
+		Explanation: "The called function is a local function known where it's called, and the keyword arguments of the call don't fit its parameters. Calls of globals are checked when they run, see E4007.",
+		Example:     "fun main() {\n  fun greet(name) {}\n  greet(nmae: \"Ada\");\n}",
+		Fix:         "fun main() {\n  fun greet(name) {}\n  greet(name: \"Ada\");\n}",
+	},
+	{
+		Code: "E3010", Title: "Global used before its declaration", phase: phaseResolver, pattern: regexp.MustCompile(`is used before its declaration|is declared below`),
+		Explanation: "A warning for a global that is declared further down the script than where it's used, which fails if the use runs first. Functions may use globals declared below them, the warning is at a top level call that runs before the declaration. Disable it with a '//glox:disable late-binding' directive.",
+		Example:     "print limit;\nvar limit = 10;",
+		Fix:         "var limit = 10;\nprint limit;",
+	},
+	{
+		Code: "E3011", Title: "Unused local variable", phase: phaseResolver, pattern: regexp.MustCompile(`is never used`),
+		Explanation: "A warning for a local variable that is declared but never read. Disable it with a '//glox:disable unused-variable' directive.",
+		Example:     "fun f() { var unused = 1; return 2; }",
+		Fix:         "fun f() { return 2; }",
+	},
+	{
+		Code: "E3012", Title: "Redefinition changes the arity", phase: phaseResolver, pattern: regexp.MustCompile(`^Redefining .* changes its arity`),
+		Explanation: "A warning of the interactive terminal for a function or class declared again with a different number of parameters, so calls written for the old one won't work anymore.",
+		Example:     ">>> fun f(a) { return a; }\n>>> fun f(a, b) { return a + b; }",
+		Fix:         ">>> fun f2(a, b) { return a + b; }",
+	},
+	{
+		Code: "E3000", Title: "Invalid program", phase: phaseResolver,
+		Explanation: "The program is well formed but uses names or constructs where they aren't allowed.",
+	},
+	{
+		Code: "E4001", Title: "Wrong operand types", phase: phaseRuntime, pattern: regexp.MustCompile(`[Oo]perands? must|^Range bounds must|^The result is not a number`),
+		Explanation: "The operator doesn't apply to the values of its operands. Arithmetic and comparisons need numbers, '+' also joins a string with a string or a number and '*' repeats a string.",
+		Example:     "print 1 - \"2\";",
+		Fix:         "print 1 - 2;",
+	},
+	{
+		Code: "E4002", Title: "Undefined variable", phase: phaseRuntime, pattern: regexp.MustCompile(`^Undefined variable`),
+		Explanation: "No variable of that name exists when the code runs, in the interactive terminal it may not be declared yet.",
+		Example:     ">>> print total;",
+		Fix:         ">>> var total = 0;\n>>> print total;",
+	},
+	{
+		Code: "E4003", Title: "Undefined property", phase: phaseRuntime, pattern: regexp.MustCompile(`^Undefined property`),
+		Explanation: "The instance has no field and its class no method of that name. Fields only exist once they are assigned.",
+		Example:     "class P {}\nprint P().name;",
+		Fix:         "class P { init() { this.name = \"\"; } }\nprint P().name;",
+	},
+	{
+		Code: "E4004", Title: "Not an instance", phase: phaseRuntime, pattern: regexp.MustCompile(`^Only instances have`),
+		Explanation: "Only instances have fields, a property of any other value can't be read or set.",
+		Example:     "var n = 1;\nn.x = 2;",
+		Fix:         "class Box {}\nvar n = Box();\nn.x = 2;",
+	},
+	{
+		Code: "E4005", Title: "Not callable", phase: phaseRuntime, pattern: regexp.MustCompile(`^Can only call`),
+		Explanation: "Only functions and classes can be called.",
+		Example:     "var n = 1;\nn();",
+		Fix:         "fun n() { return 1; }\nn();",
+	},
+	{
+		Code: "E4006", Title: "Wrong number of arguments", phase: phaseRuntime, pattern: regexp.MustCompile(`^Expected .*arguments but got`),
+		Explanation: "The call passes more or fewer arguments than the function has parameters.",
+		Example:     "fun add(a, b) { return a + b; }\nadd(1);",
+		Fix:         "fun add(a, b) { return a + b; }\nadd(1, 2);",
+	},
+	{
+		Code: "E4007", Title: "Keyword arguments don't match the parameters", phase: phaseRuntime, pattern: regexp.MustCompile(`has no parameter named|already passed positionally|^Missing argument for parameter|does not accept keyword arguments`),
+		Explanation: "The keyword arguments of the call don't fit the parameters of the function it calls. Native functions only take positional arguments.",
+		Example:     "fun greet(name) {}\nvar g = greet;\ng(nmae: \"Ada\");",
+		Fix:         "fun greet(name) {}\nvar g = greet;\ng(name: \"Ada\");",
+	},
+	{
+		Code: "E4008", Title: "Invalid index", phase: phaseRuntime, pattern: regexp.MustCompile(`^Index|can be indexed|^Tuples can't be changed|^Key not found|^Map keys must`),
+		Explanation: "Arrays and tuples are indexed by whole numbers from 0 to their length minus 1, maps by the keys they hold. Tuples can't be changed.",
+		Example:     "var a = [1, 2];\nprint a[2];",
+		Fix:         "var a = [1, 2];\nprint a[1];",
+	},
+	{
+		Code: "E4009", Title: "Not iterable", phase: phaseRuntime, pattern: regexp.MustCompile(`^Can only iterate`),
+		Explanation: "for-in loops iterate over strings, ranges, arrays, tuples, maps and other collections.",
+		Example:     "for (var x in 10) print x;",
+		Fix:         "for (var x in 0..10) print x;",
+	},
+	{
+		Code: "E4010", Title: "Invalid operand of in", phase: phaseRuntime, pattern: regexp.MustCompile(`operand of 'in'`),
+		Explanation: "in looks for a substring in a string, or for a value in a range, a collection or an instance with a has method.",
+		Example:     "print 1 in \"123\";",
+		Fix:         "print \"1\" in \"123\";",
+	},
+	{
+		Code: "E4011", Title: "Can't destructure", phase: phaseRuntime, pattern: regexp.MustCompile(`destructure`),
+		Explanation: "A destructuring declaration needs a tuple or an array with exactly one element per name.",
+		Example:     "var (a, b) = (1, 2, 3);",
+		Fix:         "var (a, b, c) = (1, 2, 3);",
+	},
+	{
+		Code: "E4012", Title: "Superclass is not a class", phase: phaseRuntime, pattern: regexp.MustCompile(`^Superclass must be a class`),
+		Explanation: "A class can only inherit from another class.",
+		Example:     "var Base = 1;\nclass A < Base {}",
+		Fix:         "class Base {}\nclass A < Base {}",
+	},
+	{
+		Code: "E4013", Title: "Uncaught exception", phase: phaseRuntime, pattern: regexp.MustCompile(`^Uncaught`),
+		Explanation: "A thrown value reached the top of the program without a try statement catching it.",
+		Example:     "throw \"oops\";",
+		Fix:         "try { throw \"oops\"; } catch (e) { print e; }",
+	},
+	{
+		Code: "E4014", Title: "Stack overflow", phase: phaseRuntime, pattern: regexp.MustCompile(`^Stack overflow`),
+		Explanation: "The calls nested deeper than --max-call-depth allows, usually because a recursive function never reaches its base case. It can't be caught.",
+		Example:     "fun f(n) { return f(n - 1); }",
+		Fix:         "fun f(n) { if (n <= 0) return 0; return f(n - 1); }",
+	},
+	{
+		Code: "E4015", Title: "Interrupted", phase: phaseRuntime, pattern: regexp.MustCompile(`^Interrupted`),
+		Explanation: "The Go program that called into lox canceled the call or its deadline passed. It can't be caught.",
+	},
+	{
+		Code: "E4016", Title: "Not allowed in a sandbox", phase: phaseRuntime, pattern: regexp.MustCompile(`in a sandbox`),
+		Explanation: "Sandboxed expressions can only compute a value from their variables, they can't assign or use this and super.",
+		Example:     "total = price * 2",
+		Fix:         "price * 2",
+	},
+	{
+		Code: "E4000", Title: "Runtime error", phase: phaseRuntime,
+		Explanation: "The program failed while running, often in a native function. The message says why.",
+	},
+}
+
+// diagnosticCode returns the code of a diagnostic of the phase with the message.
+func diagnosticCode(phase diagnosticPhase, message string) string {
+	for _, code := range diagnosticCodes {
+		if code.phase == phase && (code.pattern == nil || code.pattern.MatchString(message)) {
+			return code.Code
+		}
+	}
+
+	return ""
+}
+
+// Explain returns the description of the diagnostic code, like E2001.
+func Explain(code string) (DiagnosticCode, bool) {
+	for _, c := range diagnosticCodes {
+		if c.Code == code {
+			return c, true
+		}
+	}
+
+	return DiagnosticCode{}, false
+}
+
+// DiagnosticCodes returns every diagnostic code, sorted.
+func DiagnosticCodes() []DiagnosticCode {
+	codes := append([]DiagnosticCode{}, diagnosticCodes...)
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+
+	return codes
+}
@@ -0,0 +1,60 @@
+package glox
+
+import (
+	"context"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// LoxContext wraps a Go context, so scripts running on behalf of a request can stop once
+// it's cancelled. Scripts use the methods done(), err() and value(key). Scripts can only
+// make strings, so value only finds the values stored under a string key, not the ones
+// under the unexported key types Go packages usually use.
+type LoxContext struct {
+	ctx context.Context
+}
+
+// NewLoxContext wraps the context.
+func NewLoxContext(ctx context.Context) *LoxContext {
+	return &LoxContext{ctx: ctx}
+}
+
+func (lc *LoxContext) String() string {
+	return "<context>"
+}
+
+func (lc *LoxContext) property(name ast.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "done":
+		doc := "done() returns true once the context is cancelled or its deadline passed, without waiting."
+		return NewNativeFunction("done", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return lc.ctx.Err() != nil, nil
+		}), nil
+	case "err":
+		doc := "err() returns why the context is done, or nil if it's not."
+		return NewNativeFunction("err", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if err := lc.ctx.Err(); err != nil {
+				return err.Error(), nil
+			}
+
+			return nil, nil
+		}), nil
+	case "value":
+		doc := "value(key) returns the value the context holds for the string key, or nil."
+		return NewNativeFunction("value", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			key, ok := arguments[0].(string)
+			if !ok {
+				return nil, NewNativeError("value expects a string key")
+			}
+
+			value, err := sandboxValue(lc.ctx.Value(key))
+			if err != nil {
+				return nil, NewNativeError("Can't use the value of '" + key + "': " + err.Error())
+			}
+
+			return value, nil
+		}), nil
+	}
+
+	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+}
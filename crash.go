@@ -0,0 +1,122 @@
+package glox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// issuesURL is where bugs in glox are reported.
+const issuesURL = "https://github.com/iamsayantan/glox/issues"
+
+// crashCheckTimeout bounds every run of a candidate source while minimizing a crash, since
+// removing lines can leave a loop that never ends.
+const crashCheckTimeout = 2 * time.Second
+
+// crashBase returns the globals code runs with, to run the candidates of a minimized crash
+// with later, nil unless crashes are minimized.
+func (r *Runtime) crashBase() *Environment {
+	if !r.options.MinimizeCrashes {
+		return nil
+	}
+
+	return r.interpreter.globals.Snapshot()
+}
+
+// recoverCrash is deferred around running code. A panic is a bug in glox and not in the
+// script, so instead of the Go stack the user gets a crash report to attach to an issue:
+// the stack, the version and the source, along with the smallest source that still
+// crashes the same way if base is set. The runtime is left ready to run the next prompt
+// line.
+func (r *Runtime) recoverCrash(source string, base *Environment) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	message := fmt.Sprint(value)
+	r.interpreter.environment, r.interpreter.frames = r.interpreter.globals, nil
+	r.hadRuntimeError = true
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "glox crash report\n\nversion: %s\ntime: %s\npanic: %s\n\n%s\nsource:\n%s\n", VersionInfo(), time.Now().Format(time.RFC3339), message, stack, source)
+	if base != nil {
+		minimized := Minimize(source, func(candidate string) bool {
+			return r.crashes(candidate, base, message)
+		})
+
+		fmt.Fprintf(&report, "\nminimized source:\n%s\n", minimized)
+	}
+
+	file, err := os.CreateTemp("", "glox-crash-*.txt")
+	if err == nil {
+		_, err = file.WriteString(report.String())
+		file.Close()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glox crashed, which is a bug in glox and not in your script. Writing the crash report failed: %s\n\n%s\n", err.Error(), report.String())
+		fmt.Fprintf(os.Stderr, "Please file an issue at %s with the report above.\n", issuesURL)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "glox crashed, which is a bug in glox and not in your script.\n")
+	fmt.Fprintf(os.Stderr, "The crash report is in %s, please file an issue at %s and attach it.\n", file.Name(), issuesURL)
+}
+
+// crashes reports if the source panics with the message when it's compiled and run from the
+// globals of base.
+func (r *Runtime) crashes(source string, base *Environment, message string) bool {
+	outcome := runQuietly(source, r.options, base, r.interpreter)
+	return outcome.Panicked && outcome.Panic == message
+}
+
+// runQuietly compiles and runs the source from a snapshot of the globals of base, with the
+// options and the locals of the parent interpreter. It runs without output or input, and
+// gives up after crashCheckTimeout.
+func runQuietly(source string, options Options, base *Environment, parent *Interpreter) (outcome Outcome) {
+	defer func() {
+		if value := recover(); value != nil {
+			outcome.Panicked, outcome.Panic = true, fmt.Sprint(value)
+		}
+	}()
+
+	globals := base.Snapshot()
+	globals.watchpoints = nil
+
+	diagnostics := &diagnosticList{}
+	scanner := NewScanner(bytes.NewBufferString(source), diagnostics)
+	statements := NewParser(scanner.ScanTokens(), diagnostics, options).Parse()
+	resolver := NewResolver(diagnostics)
+	resolver.SetDirectives(scanner.Directives())
+	resolver.CheckGlobals(globals.Names())
+	resolver.ResolveProgram(statements)
+	outcome.Diagnostics = diagnostics.diagnostics
+	if HasErrors(diagnostics.diagnostics) {
+		return outcome
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), crashCheckTimeout)
+	defer cancel()
+
+	interpreter := newInterpreter(nil, globals)
+	interpreter.out, interpreter.in, interpreter.ctx = io.Discard, bufio.NewReader(strings.NewReader("")), ctx
+	interpreter.applyOptions(options)
+	interpreter.addLocals(parent.locals)
+	interpreter.addLocals(resolver.Locals())
+	for _, stmt := range statements {
+		if err := interpreter.execute(stmt); err != nil {
+			outcome.Err = interpreter.uncaught(err)
+			return outcome
+		}
+	}
+
+	return outcome
+}
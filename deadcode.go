@@ -0,0 +1,173 @@
+package glox
+
+import (
+	"sort"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// DeadVariable is the kind of dead global variables.
+const DeadVariable = "variable"
+
+// DeadDeclaration is a function, class or global variable that is never used. Kind is
+// CallGraphFunction, CallGraphClass or DeadVariable.
+type DeadDeclaration struct {
+	Name string
+	Kind string
+	Line int
+}
+
+// deadCode is the result of the analysis: the report, and the statements that can be
+// removed without changing what the program does.
+type deadCode struct {
+	declarations []DeadDeclaration
+	removable    map[ast.Stmt]bool
+}
+
+// DeadCode reports the functions, classes and global variables that the program never
+// uses. It starts from the statements at the top level and follows the references to
+// variables found by the call graph builder, so a function that is only used by dead
+// functions is dead too. A function that is passed around as a value is used, even if it's
+// never called. Methods are called dynamically, so they live as long as their class. Only
+// the outermost of nested dead functions is reported, in the order they are declared.
+func DeadCode(statements []ast.Stmt) []DeadDeclaration {
+	return findDeadCode(statements).declarations
+}
+
+// StripDeadCode returns the statements without the dead functions and classes, and without
+// the dead global variables whose initializer is a literal, the only ones whose removal
+// can't change the output or the errors of the program. The statements are not modified.
+func StripDeadCode(statements []ast.Stmt) []ast.Stmt {
+	return stripStatements(statements, findDeadCode(statements).removable)
+}
+
+func findDeadCode(statements []ast.Stmt) *deadCode {
+	b := newCallGraphBuilder()
+	b.build(statements)
+
+	// uses are the variables used by every node, methods are used by their class.
+	uses := make(map[int][]*callBinding)
+	for _, reference := range b.references {
+		uses[reference.from] = append(uses[reference.from], reference.binding)
+	}
+
+	for _, class := range b.classes {
+		for _, method := range class.methods {
+			uses[class.node] = append(uses[class.node], &callBinding{node: method})
+		}
+	}
+
+	live := map[int]bool{0: true}
+	usedBindings := make(map[*callBinding]bool)
+	queue := []int{0}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, binding := range uses[node] {
+			usedBindings[binding] = true
+			if binding.node >= 0 && !live[binding.node] {
+				live[binding.node] = true
+				queue = append(queue, binding.node)
+			}
+		}
+	}
+
+	dead := &deadCode{declarations: make([]DeadDeclaration, 0), removable: make(map[ast.Stmt]bool)}
+	for stmt, node := range b.functions {
+		if !live[node] && b.graph.Nodes[node].Kind == CallGraphFunction {
+			dead.removable[stmt] = true
+			if live[b.parents[node]] {
+				dead.declarations = append(dead.declarations, DeadDeclaration{Name: stmt.Name.Lexeme, Kind: CallGraphFunction, Line: stmt.Name.Line})
+			}
+		}
+	}
+
+	for stmt, class := range b.classes {
+		if !live[class.node] {
+			dead.removable[stmt] = true
+			if live[b.parents[class.node]] {
+				dead.declarations = append(dead.declarations, DeadDeclaration{Name: stmt.Name.Lexeme, Kind: CallGraphClass, Line: stmt.Name.Line})
+			}
+		}
+	}
+
+	for _, binding := range b.scopes[0] {
+		if usedBindings[binding] || len(binding.declarations) == 0 {
+			continue
+		}
+
+		first := binding.declarations[0]
+		dead.declarations = append(dead.declarations, DeadDeclaration{Name: first.Name.Lexeme, Kind: DeadVariable, Line: first.Name.Line})
+		for _, stmt := range binding.declarations {
+			if _, ok := stmt.Initializer.(*ast.Literal); ok || stmt.Initializer == nil {
+				dead.removable[stmt] = true
+			}
+		}
+	}
+
+	sort.Slice(dead.declarations, func(i, j int) bool {
+		if dead.declarations[i].Line != dead.declarations[j].Line {
+			return dead.declarations[i].Line < dead.declarations[j].Line
+		}
+
+		return dead.declarations[i].Name < dead.declarations[j].Name
+	})
+
+	return dead
+}
+
+// stripStatements copies the statements without the removable ones, copying the nodes with
+// removable statements in their bodies.
+func stripStatements(statements []ast.Stmt, removable map[ast.Stmt]bool) []ast.Stmt {
+	stripped := make([]ast.Stmt, 0, len(statements))
+	for _, stmt := range statements {
+		if removable[stmt] {
+			continue
+		}
+
+		stripped = append(stripped, stripStatement(stmt, removable))
+	}
+
+	return stripped
+}
+
+func stripStatement(stmt ast.Stmt, removable map[ast.Stmt]bool) ast.Stmt {
+	switch stmt := stmt.(type) {
+	case *ast.Block:
+		return &ast.Block{Statements: stripStatements(stmt.Statements, removable)}
+	case *ast.IfStmt:
+		stripped := &ast.IfStmt{Keyword: stmt.Keyword, Condition: stmt.Condition, ThenBranch: stripStatement(stmt.ThenBranch, removable)}
+		if stmt.ElseBranch != nil {
+			stripped.ElseBranch = stripStatement(stmt.ElseBranch, removable)
+		}
+
+		return stripped
+	case *ast.WhileStmt:
+		return &ast.WhileStmt{Keyword: stmt.Keyword, Condition: stmt.Condition, Body: stripStatement(stmt.Body, removable)}
+	case *ast.ForInStmt:
+		return &ast.ForInStmt{Keyword: stmt.Keyword, Name: stmt.Name, Collection: stmt.Collection, Body: stripStatement(stmt.Body, removable)}
+	case *ast.TryStmt:
+		stripped := &ast.TryStmt{Keyword: stmt.Keyword, Body: stripStatements(stmt.Body, removable)}
+		if stmt.Catch != nil {
+			stripped.Catch = &ast.CatchClause{Name: stmt.Catch.Name, Body: stripStatements(stmt.Catch.Body, removable)}
+		}
+
+		if stmt.Finally != nil {
+			stripped.Finally = stripStatements(stmt.Finally, removable)
+		}
+
+		return stripped
+	case *ast.FunctionStmt:
+		return &ast.FunctionStmt{Name: stmt.Name, Params: stmt.Params, Body: stripStatements(stmt.Body, removable)}
+	case *ast.ClassStmt:
+		methods := make([]*ast.FunctionStmt, 0, len(stmt.Methods))
+		for _, method := range stmt.Methods {
+			methods = append(methods, stripStatement(method, removable).(*ast.FunctionStmt))
+		}
+
+		return &ast.ClassStmt{Name: stmt.Name, Superclass: stmt.Superclass, Methods: methods}
+	}
+
+	return stmt
+}
@@ -0,0 +1,47 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// EnableEnvDebugging keeps the environment a runtime error is raised in, so the environment
+// chain can be printed along with the error. The globals defined when it's enabled, like the
+// native functions and the prelude, are the baseline left out of the printed chain unless
+// they were changed, so it's enabled once the prelude is loaded.
+func (i *Interpreter) EnableEnvDebugging() {
+	i.envBaseline = i.globals.Snapshot()
+}
+
+// keepEnvironment records the current environment in the runtime error, if environment
+// debugging is enabled and the error doesn't have one yet. The error is seen first by the
+// innermost statement, so it keeps the scope the error was raised in.
+func (i *Interpreter) keepEnvironment(err error) {
+	if runErr, ok := err.(*RuntimeError); ok && i.envBaseline != nil && runErr.environment == nil {
+		runErr.environment = i.environment
+	}
+}
+
+// writeEnvironment prints the variables of the environment chain, innermost scope first.
+// The globals that are defined in the baseline with the same value are left out.
+func (i *Interpreter) writeEnvironment(w io.Writer, env *Environment, baseline *Environment) {
+	depth := 0
+	for ; env != nil; env = env.enclosing {
+		names := env.Names()
+		scope := fmt.Sprintf("scope %d", depth)
+		if env.enclosing == nil {
+			names = changedSince(env, baseline)
+			scope = "globals"
+		}
+
+		fmt.Fprintf(w, "%s:\n", scope)
+		for _, name := range names {
+			value, _ := env.lookup(ast.Intern(name))
+			fmt.Fprintf(w, "  %s = %s\n", name, i.stringify(value))
+		}
+
+		depth++
+	}
+}
@@ -0,0 +1,159 @@
+package glox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Severity tells how serious a diagnostic is. Errors stop the program from running,
+// warnings are only reported.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "Warning"
+	}
+
+	return "Error"
+}
+
+// Diagnostic is a problem found in the source code while scanning, parsing or resolving.
+type Diagnostic struct {
+	Severity Severity
+	Line     int
+	// Where describes the location on the line, e.g. " at 'foo'" or " at end". It's empty
+	// for diagnostics reported by the scanner.
+	Where   string
+	Message string
+	// Column and EndColumn are the span of the diagnostic on its line, the columns of the
+	// first character and of the character after the last one, counted from 1. They are 0
+	// if the diagnostic has no span.
+	Column    int
+	EndColumn int
+	// Code identifies the kind of diagnostic, like E2001. glox explain describes it.
+	Code string
+	// Fix is the edit that resolves the diagnostic, for mechanical mistakes like a missing
+	// ';'. It's nil if there is no fix. glox fix applies them.
+	Fix *Fix
+}
+
+func (d Diagnostic) String() string {
+	code := ""
+	if d.Code != "" {
+		code = "[" + d.Code + "]"
+	}
+
+	return fmt.Sprintf("[line %d] %s%s%s: %s", d.Line, d.Severity, code, d.Where, d.Message)
+}
+
+// JSONDiagnostic is a diagnostic in the JSON output of glox, like the frames of the JSON
+// prompt and the verdicts of Judge.
+type JSONDiagnostic struct {
+	Severity  string `json:"severity"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message"`
+}
+
+// JSONError is a runtime error in the JSON output of glox. Errors that aren't runtime
+// errors, like an invalid request, have no code or line.
+type JSONError struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// JSON returns the diagnostic as it's written in JSON output, the severity in lower case.
+func (d Diagnostic) JSON() JSONDiagnostic {
+	return JSONDiagnostic{
+		Severity:  strings.ToLower(d.Severity.String()),
+		Line:      d.Line,
+		Column:    d.Column,
+		EndColumn: d.EndColumn,
+		Code:      d.Code,
+		Message:   d.Message,
+	}
+}
+
+// Squiggle returns the line of the source the diagnostic is on, with a line of carets under
+// its span. It's empty if the diagnostic has no span.
+func (d Diagnostic) Squiggle(source string) string {
+	lines := strings.Split(source, "\n")
+	if d.Column == 0 || d.Line < 1 || d.Line > len(lines) {
+		return ""
+	}
+
+	line := []rune(strings.TrimRight(lines[d.Line-1], "\r"))
+	if d.Column > len(line)+1 {
+		return ""
+	}
+
+	// Tabs are kept in the padding, so the carets line up however wide tabs are shown.
+	padding := make([]rune, 0, d.Column-1)
+	for _, r := range line[:d.Column-1] {
+		if r == '\t' {
+			padding = append(padding, '\t')
+		} else {
+			padding = append(padding, ' ')
+		}
+	}
+
+	width := d.EndColumn - d.Column
+	if width < 1 {
+		width = 1
+	}
+
+	return string(line) + "\n" + string(padding) + strings.Repeat("^", width)
+}
+
+// Reporter receives the diagnostics produced by the scanner, parser and resolver.
+type Reporter interface {
+	Report(diagnostic Diagnostic)
+}
+
+// errorAt creates an error diagnostic of the phase pointing at the token.
+func errorAt(phase diagnosticPhase, token ast.Token, message string) Diagnostic {
+	return Diagnostic{Severity: SeverityError, Line: token.Line, Where: where(token), Message: message, Code: diagnosticCode(phase, message)}
+}
+
+// warningAt creates a warning diagnostic of the phase pointing at the token.
+func warningAt(phase diagnosticPhase, token ast.Token, message string) Diagnostic {
+	return Diagnostic{Severity: SeverityWarning, Line: token.Line, Where: where(token), Message: message, Code: diagnosticCode(phase, message)}
+}
+
+func where(token ast.Token) string {
+	if token.Type == ast.Eof {
+		return " at end"
+	}
+
+	return " at '" + token.Lexeme + "'"
+}
+
+// HasErrors reports if any of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diagnosticList is a Reporter that collects the diagnostics instead of printing them.
+type diagnosticList struct {
+	diagnostics []Diagnostic
+}
+
+func (dl *diagnosticList) Report(diagnostic Diagnostic) {
+	dl.diagnostics = append(dl.diagnostics, diagnostic)
+}
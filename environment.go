@@ -1,77 +1,292 @@
 package glox
 
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+
+	"github.com/iamsayantan/glox/ast"
+	"github.com/iamsayantan/glox/util"
+)
+
 type Environment struct {
-	// values uses string for the keys and not Token because token represents
-	// a unit of code at a specific place in the source text, but when it comes
-	// to variables, all identifier tokens using the same name should refer to
+	// values uses the interned symbol of the name for the keys and not Token because
+	// token represents a unit of code at a specific place in the source text, but when
+	// it comes to variables, all identifier tokens using the same name should refer to
 	// the same variable (ignorig scope for now).
-	values map[string]interface{}
+	values map[ast.Symbol]interface{}
+
+	// persistent holds the values instead of the values map when the environment is
+	// persistent. Every change creates a new version of the map, so a snapshot of the
+	// environment is only a copy of the map's root.
+	persistent   util.PersistentMap[interface{}]
+	isPersistent bool
 
 	// enclosing works as the parent of this Environment. For the global scope,
 	// this should be null breaking the chain. But for each local scope, we must
 	// enclose the parent scope.
 	enclosing *Environment
+
+	// watchpoints are the variables that pause execution when they are defined or
+	// assigned, inherited from the parent. It's nil unless a variable is watched.
+	watchpoints *watchpoints
+
+	// definitions records when each variable was defined, on the clock, so --debug-resolver
+	// can tell the variables a closure sees from the ones declared after it. It's nil unless
+	// resolver debugging is enabled.
+	definitions map[ast.Symbol]uint64
+
+	// clock orders the definitions of variables and the creation of functions. It belongs to
+	// the global environment and is shared by the environments enclosed by it, so separate
+	// interpreters don't share one. It's nil unless resolver debugging is enabled.
+	clock *uint64
+
+	// horizon is set on the environment of a function call when definitions are recorded,
+	// to the time the function was created. Variables defined later in the environments
+	// enclosing the call are declared after the function, so it can't see them.
+	horizon uint64
 }
 
+// NewEnvironment creates an environment enclosed by the parent. It's persistent if the
+// parent is.
 func NewEnvironment(parent *Environment) *Environment {
-	return &Environment{values: make(map[string]interface{}, 0), enclosing: parent}
+	if parent == nil {
+		return &Environment{values: make(map[ast.Symbol]interface{}, 0)}
+	}
+
+	env := &Environment{enclosing: parent, watchpoints: parent.watchpoints, isPersistent: parent.isPersistent}
+	if !parent.isPersistent {
+		env.values = make(map[ast.Symbol]interface{}, 0)
+	}
+
+	if parent.clock != nil {
+		env.definitions = make(map[ast.Symbol]uint64)
+		env.clock = parent.clock
+	}
+
+	return env
+}
+
+// NewPersistentEnvironment creates a global environment backed by persistent maps, as are
+// all the environments enclosed by it. Snapshots of them are cheap enough to take one after
+// every statement.
+func NewPersistentEnvironment() *Environment {
+	return &Environment{isPersistent: true}
+}
+
+// Snapshot returns a copy of the environment chain as it is now. Later changes to the
+// environment don't show in the snapshot. Taking a snapshot is only cheap for persistent
+// environments, the values of the others are copied.
+func (e *Environment) Snapshot() *Environment {
+	if e == nil {
+		return nil
+	}
+
+	if e.isPersistent {
+		return &Environment{persistent: e.persistent, isPersistent: true, enclosing: e.enclosing.Snapshot(), watchpoints: e.watchpoints}
+	}
+
+	values := make(map[ast.Symbol]interface{}, len(e.values))
+	for symbol, value := range e.values {
+		values[symbol] = value
+	}
+
+	return &Environment{values: values, enclosing: e.enclosing.Snapshot(), watchpoints: e.watchpoints}
+}
+
+// restore sets the values of the environment to the ones of the snapshot, which must have
+// been taken from this environment.
+func (e *Environment) restore(snapshot *Environment) {
+	e.persistent = snapshot.persistent
+	if e.isPersistent {
+		return
+	}
+
+	e.values = make(map[ast.Symbol]interface{}, len(snapshot.values))
+	for symbol, value := range snapshot.values {
+		e.values[symbol] = value
+	}
+}
+
+// lookup returns the value of the variable defined in this environment.
+func (e *Environment) lookup(symbol ast.Symbol) (interface{}, bool) {
+	if e.isPersistent {
+		return e.persistent.Get(uint32(symbol))
+	}
+
+	value, ok := e.values[symbol]
+	return value, ok
+}
+
+// store sets the value of the variable in this environment.
+func (e *Environment) store(symbol ast.Symbol, value interface{}) {
+	if e.isPersistent {
+		e.persistent = e.persistent.Set(uint32(symbol), value)
+		return
+	}
+
+	e.values[symbol] = value
+}
+
+// Names returns the names of the variables defined in this environment, without the
+// enclosing ones, sorted.
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.values))
+	if e.isPersistent {
+		e.persistent.Range(func(key uint32, value interface{}) {
+			names = append(names, ast.Symbol(key).String())
+		})
+	}
+
+	for symbol := range e.values {
+		names = append(names, symbol.String())
+	}
+
+	sort.Strings(names)
+	return names
 }
 
 // Define defines a new variable in the current innermost scope.
 func (e *Environment) Define(name string, value interface{}) {
-	e.values[name] = value
+	e.DefineSymbol(ast.Intern(name), value)
+}
+
+// DefineSymbol defines a new variable by its interned name, saving the lookup of the name
+// in the symbol table.
+func (e *Environment) DefineSymbol(symbol ast.Symbol, value interface{}) {
+	e.store(symbol, value)
+	if e.clock != nil {
+		e.definitions[symbol] = atomic.AddUint64(e.clock, 1)
+	}
+
+	if e.watchpoints != nil {
+		e.watchpoints.hit(symbol, value, 0, true)
+	}
 }
 
 // Get looks up a variable in the environment. It starts by looking into the innermost
 // environment and goes up till it reaches the global scope.
-func (e *Environment) Get(name Token) (interface{}, error) {
-	val, ok := e.values[name.Lexeme]
-	if ok {
-		return val, nil
+func (e *Environment) Get(name ast.Token) (interface{}, error) {
+	symbol := name.Symbol()
+	for env := e; env != nil; env = env.enclosing {
+		if val, ok := env.lookup(symbol); ok {
+			return val, nil
+		}
 	}
 
-	if e.enclosing != nil {
-		return e.enclosing.Get(name)
+	return nil, NewRuntimeError(name, undefinedMessage("variable", name.Lexeme, e.visibleNames()))
+}
+
+// visibleNames returns the names of the variables defined in this environment and the
+// enclosing ones.
+func (e *Environment) visibleNames() []string {
+	names := make([]string, 0)
+	for env := e; env != nil; env = env.enclosing {
+		names = append(names, env.Names()...)
 	}
 
-	return nil, NewRuntimeError(name, "Undefined variable '"+name.Lexeme+"'")
+	return names
 }
 
 // Assign will assign value to the variable. If the variable is not available in the current
 // environment, it will try to assign it recursively to the out environments until it reaches
 // the global environment.
-func (e *Environment) Assign(name Token, value interface{}) error {
-	_, ok := e.values[name.Lexeme]
+func (e *Environment) Assign(name ast.Token, value interface{}) error {
+	symbol := name.Symbol()
+	for env := e; env != nil; env = env.enclosing {
+		if _, ok := env.lookup(symbol); ok {
+			env.store(symbol, value)
+			if env.watchpoints != nil {
+				env.watchpoints.hit(symbol, value, name.Line, false)
+			}
 
-	if ok {
-		e.values[name.Lexeme] = value
-		return nil
+			return nil
+		}
 	}
 
-	if e.enclosing != nil {
-		return e.enclosing.Assign(name, value)
-	}
-
-	return NewRuntimeError(name, "Undefined variable '"+name.Lexeme+"'.")
+	return NewRuntimeError(name, undefinedMessage("variable", name.Lexeme, e.visibleNames()))
 }
 
 // GetAt will get the exact environment where the variable is defined in the environment chain and
-// return the value.
-func (e *Environment) GetAt(distance int, name string) interface{} {
-	return e.ancestor(distance).values[name]
+// return the value. The distance comes from the resolver, so an error here means the resolver and
+// the interpreter disagree about the scopes, which is a bug in glox rather than in the script.
+func (e *Environment) GetAt(distance int, name ast.Symbol) (interface{}, error) {
+	env, err := e.ancestor(distance, name)
+	if err != nil {
+		return nil, err
+	}
+
+	val, ok := env.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("internal error: variable '%s' resolved at distance %d is not defined there", name, distance)
+	}
+
+	return val, nil
 }
 
 // AssignAt walks fixed numbers of steps and stuffs the variable into that map.
-func (e *Environment) AssignAt(distance int, name Token, value interface{}) {
-	e.ancestor(distance).values[name.Lexeme] = value
+func (e *Environment) AssignAt(distance int, name ast.Token, value interface{}) error {
+	symbol := name.Symbol()
+	env, err := e.ancestor(distance, symbol)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := env.lookup(symbol); !ok {
+		return fmt.Errorf("internal error: variable '%s' resolved at distance %d is not defined there", name.Lexeme, distance)
+	}
+
+	env.store(symbol, value)
+	if env.watchpoints != nil {
+		env.watchpoints.hit(symbol, value, name.Line, false)
+	}
+
+	return nil
 }
 
 // ancestor walks a fixed number of hops up the parent chain and returns the environment there.
-func (e *Environment) ancestor(distance int) *Environment {
+func (e *Environment) ancestor(distance int, name ast.Symbol) (*Environment, error) {
 	env := e
 	for i := 0; i < distance; i++ {
+		if env.enclosing == nil {
+			return nil, fmt.Errorf("internal error: variable '%s' resolved at distance %d but the environment chain is only %d deep", name, distance, i)
+		}
+
 		env = env.enclosing
 	}
 
-	return env
+	return env, nil
+}
+
+// distanceOf returns the number of hops from this environment to the first one up the
+// chain that defines the variable. When definitions are recorded, the variables defined
+// after a function was created are skipped in the environments enclosing its call, as the
+// function can't see them.
+func (e *Environment) distanceOf(name ast.Symbol) (int, bool) {
+	horizon := uint64(math.MaxUint64)
+	distance := 0
+	for env := e; env != nil; env = env.enclosing {
+		if _, ok := env.lookup(name); ok && env.definitions[name] <= horizon {
+			return distance, true
+		}
+
+		if env.horizon != 0 && env.horizon < horizon {
+			horizon = env.horizon
+		}
+
+		distance++
+	}
+
+	return 0, false
+}
+
+// depth returns the number of hops from this environment to the global environment.
+func (e *Environment) depth() int {
+	depth := 0
+	for env := e; env.enclosing != nil; env = env.enclosing {
+		depth++
+	}
+
+	return depth
 }
@@ -0,0 +1,144 @@
+package glox
+
+import (
+	"math"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// equality compares values with the == operator. Arrays, tuples and maps are compared
+// element by element, instances with the equals method of their class if it has one, and
+// every other value by its Go value, which is the identity of instances without one.
+type equality struct {
+	interpreter *Interpreter
+	operator    ast.Token
+
+	// comparing are the pairs of arrays and maps being compared further up. A collection
+	// that holds itself is equal to another one where the cycles match up. It's created by
+	// the first comparison of two collections, most comparisons never need it.
+	comparing map[[2]interface{}]bool
+
+	// err is the error of the first equals method that failed, the comparison stops there.
+	err error
+}
+
+// valuesEqual compares the values with the == operator. The error is the error of an equals
+// method, reported at the operator.
+func (i *Interpreter) valuesEqual(operator ast.Token, a, b interface{}) (bool, error) {
+	e := &equality{interpreter: i, operator: operator}
+	equal := e.equal(a, b)
+	return equal, e.err
+}
+
+func (e *equality) equal(a, b interface{}) bool {
+	if e.err != nil || isNaN(a) || isNaN(b) {
+		return false
+	}
+
+	if x, y, ok := bigOperands(a, b); ok {
+		return x.Cmp(y) == 0
+	}
+
+	switch x := a.(type) {
+	case *LoxArray:
+		y, ok := b.(*LoxArray)
+		return ok && e.collections(x, y, func() bool { return e.elements(x.elements, y.elements) })
+	case *LoxTuple:
+		y, ok := b.(*LoxTuple)
+		return ok && e.elements(x.elements, y.elements)
+	case *LoxMap:
+		y, ok := b.(*LoxMap)
+		return ok && e.collections(x, y, func() bool { return e.entries(x, y) })
+	case *LoxInstance:
+		if equal, ok := e.callEquals(x, b); ok {
+			return equal
+		}
+	}
+
+	// An instance with an equals method on the right is asked when the left operand has no
+	// say, so 1 == point and point == 1 agree.
+	if y, ok := b.(*LoxInstance); ok {
+		if equal, ok := e.callEquals(y, a); ok {
+			return equal
+		}
+	}
+
+	return a == b
+}
+
+// collections compares two arrays or two maps with compare, unless they are the same one or
+// are being compared already.
+func (e *equality) collections(x, y interface{}, compare func() bool) bool {
+	pair := [2]interface{}{x, y}
+	if x == y || e.comparing[pair] {
+		return true
+	}
+
+	if e.comparing == nil {
+		e.comparing = make(map[[2]interface{}]bool)
+	}
+
+	e.comparing[pair] = true
+	defer delete(e.comparing, pair)
+	return compare()
+}
+
+// elements compares the elements of two arrays or tuples in order.
+func (e *equality) elements(x, y []interface{}) bool {
+	if len(x) != len(y) {
+		return false
+	}
+
+	for n := range x {
+		if !e.equal(x[n], y[n]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// entries compares two maps, which are equal if they have the same keys with equal values,
+// in any order.
+func (e *equality) entries(x, y *LoxMap) bool {
+	if x.Len() != y.Len() {
+		return false
+	}
+
+	for n, key := range x.keys {
+		value, ok := y.Get(key)
+		if !ok || !e.equal(x.values[n], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// callEquals calls the equals method of the instance with the other value, and returns if it
+// returned a truthy value. It returns false as second value if the class has no equals
+// method.
+func (e *equality) callEquals(instance *LoxInstance, other interface{}) (bool, bool) {
+	method, err := instance.klass.findMethod("equals")
+	if err != nil {
+		return false, false
+	}
+
+	result, err := callValue(e.interpreter, method.Bind(instance), []interface{}{other})
+	if nativeErr, ok := err.(*nativeError); ok {
+		err = NewRuntimeError(e.operator, nativeErr.message)
+	}
+
+	if err != nil {
+		e.err = err
+		return false, true
+	}
+
+	return e.interpreter.isTruthy(result), true
+}
+
+// isNaN reports if the value is the float NaN, which isn't equal to anything.
+func isNaN(value interface{}) bool {
+	f, ok := value.(float64)
+	return ok && math.IsNaN(f)
+}
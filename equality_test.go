@@ -0,0 +1,42 @@
+package glox
+
+import (
+	"testing"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// TestEqualityOfScalarsDoesntAllocate compares values that aren't collections, which must
+// not allocate the set of collections being compared.
+func TestEqualityOfScalarsDoesntAllocate(t *testing.T) {
+	interpreter := NewInterpreter(nil)
+	operator := ast.Token{Type: ast.EqualEqual, Lexeme: "=="}
+	allocations := testing.AllocsPerRun(100, func() {
+		interpreter.valuesEqual(operator, "a", "b")
+		interpreter.valuesEqual(operator, 1.0, 1.0)
+		interpreter.valuesEqual(operator, nil, true)
+	})
+
+	if allocations != 0 {
+		t.Errorf("comparing scalars allocated %v times", allocations)
+	}
+}
+
+func TestEqualityOfCollections(t *testing.T) {
+	output, err := runSource(t, `
+var a = [1, {"k": [2]}];
+var b = [1, {"k": [2]}];
+print a == b;
+a.push(a);
+b.push(b);
+print a == b;
+print [1, 2] == [1, 3];
+`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if expected := "true\ntrue\nfalse\n"; output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
@@ -0,0 +1,79 @@
+package glox
+
+import "time"
+
+// Event is emitted by the interpreter as it runs, for tools that want to observe execution
+// without changing the interpreter, e.g. timelines, visualizers and tracers. Events are
+// delivered synchronously to every handler added with AddEventHandler, in the order they
+// were added.
+type Event interface {
+	event()
+}
+
+// FunctionEntered is emitted right before a callable is called.
+type FunctionEntered struct {
+	Function string
+	// Line is the line of the call expression.
+	Line int
+	// Depth is the depth of the call stack including this call.
+	Depth int
+	Time  time.Time
+}
+
+// FunctionExited is emitted right after a callable returns, or fails with an error.
+type FunctionExited struct {
+	Function string
+	Line     int
+	Depth    int
+	Time     time.Time
+	Err      error
+}
+
+// VariableDefined is emitted when a variable, function or class declaration binds a name.
+type VariableDefined struct {
+	Name  string
+	Value interface{}
+	// Global is set when the name is defined in the global scope.
+	Global bool
+}
+
+// ErrorRaised is emitted when a runtime error stops the program.
+type ErrorRaised struct {
+	Message string
+	Line    int
+}
+
+func (FunctionEntered) event() {}
+func (FunctionExited) event()  {}
+func (VariableDefined) event() {}
+func (ErrorRaised) event()     {}
+
+// EventHandler receives the events emitted by the interpreter.
+type EventHandler func(event Event)
+
+// SetEventHandler replaces the handlers that receive the interpreter's events with the
+// handler. Passing nil stops emitting events.
+func (i *Interpreter) SetEventHandler(handler EventHandler) {
+	i.eventHandlers = nil
+	i.AddEventHandler(handler)
+}
+
+// AddEventHandler adds a handler receiving the interpreter's events after the handlers
+// added before it. Passing nil does nothing.
+func (i *Interpreter) AddEventHandler(handler EventHandler) {
+	if handler != nil {
+		i.eventHandlers = append(i.eventHandlers, handler)
+	}
+}
+
+func (i *Interpreter) emit(event Event) {
+	for _, handler := range i.eventHandlers {
+		handler(event)
+	}
+}
+
+// define defines the variable in the current environment and emits a VariableDefined event.
+func (i *Interpreter) define(name string, value interface{}) {
+	i.environment.Define(name, value)
+	i.emit(VariableDefined{Name: name, Value: value, Global: i.environment == i.globals})
+}
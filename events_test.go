@@ -0,0 +1,49 @@
+package glox
+
+import (
+	"io"
+	"testing"
+)
+
+// calledFunctions returns a handler recording the names of the functions entered.
+func calledFunctions(names *[]string) EventHandler {
+	return func(event Event) {
+		if entered, ok := event.(FunctionEntered); ok {
+			*names = append(*names, entered.Function)
+		}
+	}
+}
+
+func TestEventHandlersReceiveEveryEvent(t *testing.T) {
+	options := DefaultOptions()
+	options.NoPrelude = true
+	options.TraceOut = "trace.json"
+	r := NewRuntimeWithOptions(options)
+	r.SetOutput(io.Discard)
+
+	var replaced, set, added []string
+	r.SetEventHandler(calledFunctions(&replaced))
+	r.SetEventHandler(calledFunctions(&set))
+	r.AddEventHandler(calledFunctions(&added))
+
+	r.scriptMode = true
+	r.run("fun f() {} fun g() { f(); } g();")
+	if r.hadError || r.hadRuntimeError {
+		t.Fatalf("the script failed")
+	}
+
+	if len(replaced) != 0 {
+		t.Errorf("the replaced handler received %v", replaced)
+	}
+
+	for _, names := range [][]string{set, added} {
+		if len(names) != 2 || names[0] != "g" || names[1] != "f" {
+			t.Errorf("expected the calls of g and f, got %v", names)
+		}
+	}
+
+	// Both calls are traced, with an event for entering and one for exiting each.
+	if len(r.tracer.events) != 4 {
+		t.Errorf("expected the tracer to keep receiving events, it recorded %d", len(r.tracer.events))
+	}
+}
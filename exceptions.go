@@ -0,0 +1,92 @@
+package glox
+
+import "github.com/iamsayantan/glox/ast"
+
+// ThrowErr carries a thrown value up to the closest try statement with a catch clause.
+// Like ReturnErr, it's an error only so it unwinds the interpreter like one.
+type ThrowErr struct {
+	Value interface{}
+
+	// keyword is the throw keyword, where the exception is reported if nothing catches it.
+	keyword ast.Token
+}
+
+func (te *ThrowErr) Error() string {
+	return "Uncaught exception"
+}
+
+func (i *Interpreter) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	value, err := i.evaluate(stmt.Value)
+	if err != nil {
+		return err
+	}
+
+	return &ThrowErr{Value: value, keyword: stmt.Keyword}
+}
+
+// VisitTryStmt runs the try block, and the catch clause if the block raised an exception.
+// Returns, and any other way of leaving the block, are not caught. The finally block runs
+// however the statement is left, and if it raises an exception itself, that one wins.
+func (i *Interpreter) VisitTryStmt(stmt *ast.TryStmt) error {
+	err := i.executeBlock(stmt.Body, NewEnvironment(i.environment))
+	if err != nil && stmt.Catch != nil {
+		if exception, ok := i.exception(err); ok {
+			env := NewEnvironment(i.environment)
+			env.DefineSymbol(stmt.Catch.Name.Symbol(), exception)
+			err = i.executeBlock(stmt.Catch.Body, env)
+		}
+	}
+
+	if stmt.Finally != nil {
+		if finallyErr := i.executeBlock(stmt.Finally, NewEnvironment(i.environment)); finallyErr != nil {
+			return finallyErr
+		}
+	}
+
+	return err
+}
+
+// exception returns the value a catch clause catches for the error. Runtime errors are
+// caught as instances of the Error class, with their message and line.
+func (i *Interpreter) exception(err error) (interface{}, bool) {
+	switch err := err.(type) {
+	case *ThrowErr:
+		return err.Value, true
+	case *RuntimeError:
+		if err.fatal {
+			return nil, false
+		}
+
+		value, _ := i.globals.lookup(ast.Intern("Error"))
+		class, ok := value.(*LoxClass)
+
+		// Without the prelude there is no Error class, the instance gets a bare one.
+		if !ok {
+			class = NewLoxClass("Error", nil, make(map[string]LoxFunction))
+		}
+
+		instance := NewLoxInstance(class)
+		instance.fields["message"] = err.message
+		instance.fields["line"] = float64(err.token.Line)
+		return instance, true
+	}
+
+	return nil, false
+}
+
+// uncaught turns an exception that nothing caught into the runtime error that stops the
+// program. Other errors are returned as they are.
+func (i *Interpreter) uncaught(err error) error {
+	throwErr, ok := err.(*ThrowErr)
+	if !ok {
+		return err
+	}
+
+	if instance, ok := throwErr.Value.(*LoxInstance); ok {
+		if message, ok := instance.fields["message"]; ok {
+			return NewRuntimeError(throwErr.keyword, "Uncaught "+instance.klass.name+": "+i.stringify(message))
+		}
+	}
+
+	return NewRuntimeError(throwErr.keyword, "Uncaught exception: "+i.stringify(throwErr.Value))
+}
@@ -0,0 +1,139 @@
+package glox
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// maxFixRounds limits how many times FixSource checks the source again after applying fixes.
+const maxFixRounds = 10
+
+// Fix is a machine applicable edit that resolves a diagnostic. It replaces the characters
+// of the line from Column up to EndColumn with Replacement, the columns are counted like
+// the span of a diagnostic. A fix with Column equal to EndColumn is an insertion.
+type Fix struct {
+	Description string
+	Line        int
+	Column      int
+	EndColumn   int
+	Replacement string
+}
+
+// insertAfter returns the fix inserting the text right after the token. It's nil for
+// tokens without a position and for tokens spanning lines, like multi-line strings.
+func insertAfter(token ast.Token, text string) *Fix {
+	if token.Column == 0 || strings.Contains(token.Lexeme, "\n") {
+		return nil
+	}
+
+	column := token.Column + utf8.RuneCountInString(token.Lexeme)
+	description := "insert '" + strings.TrimSpace(text) + "'"
+	return &Fix{Description: description, Line: token.Line, Column: column, EndColumn: column, Replacement: text}
+}
+
+// replaceToken returns the fix replacing the token with the text.
+func replaceToken(token ast.Token, text string) *Fix {
+	if token.Column == 0 || strings.Contains(token.Lexeme, "\n") {
+		return nil
+	}
+
+	description := "replace '" + token.Lexeme + "' with '" + text + "'"
+	end := token.Column + utf8.RuneCountInString(token.Lexeme)
+	return &Fix{Description: description, Line: token.Line, Column: token.Column, EndColumn: end, Replacement: text}
+}
+
+// ApplyFixes applies the fixes to the source. Fixes overlapping one applied before, and
+// fixes pointing outside of the source, are skipped. It returns the fixed source and the
+// fixes that were applied, in source order.
+func ApplyFixes(source string, fixes []Fix) (string, []Fix) {
+	runes := []rune(source)
+
+	// lineStarts are the offsets of the first character of each line.
+	lineStarts := []int{0}
+	for n, r := range runes {
+		if r == '\n' {
+			lineStarts = append(lineStarts, n+1)
+		}
+	}
+
+	offset := func(line, column int) (int, bool) {
+		if line < 1 || line > len(lineStarts) || column < 1 {
+			return 0, false
+		}
+
+		lineEnd := len(runes)
+		if line < len(lineStarts) {
+			lineEnd = lineStarts[line] - 1
+		}
+
+		n := lineStarts[line-1] + column - 1
+		return n, n <= lineEnd
+	}
+
+	sorted := append([]Fix(nil), fixes...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		if sorted[a].Line != sorted[b].Line {
+			return sorted[a].Line > sorted[b].Line
+		}
+
+		return sorted[a].Column > sorted[b].Column
+	})
+
+	// Applying the fixes from the end of the source keeps the offsets of the others valid.
+	applied := make([]Fix, 0, len(sorted))
+	limit := len(runes) + 1
+	for _, fix := range sorted {
+		start, startOk := offset(fix.Line, fix.Column)
+		end, endOk := offset(fix.Line, fix.EndColumn)
+		if !startOk || !endOk || end < start || end >= limit {
+			continue
+		}
+
+		replaced := append([]rune(fix.Replacement), runes[end:]...)
+		runes = append(runes[:start], replaced...)
+		limit = start
+		applied = append(applied, fix)
+	}
+
+	for a, b := 0, len(applied)-1; a < b; a, b = a+1, b-1 {
+		applied[a], applied[b] = applied[b], applied[a]
+	}
+
+	return string(runes), applied
+}
+
+// FixSource applies the fixes of the diagnostics of the source until none are left. Fixing
+// one error can reveal the next, which the parser skipped while recovering from the first,
+// so the source is checked again after every round. It returns the fixed source, the fixes
+// applied and the diagnostics still left.
+func FixSource(source string, options Options) (string, []Fix, []Diagnostic) {
+	applied := make([]Fix, 0)
+	diagnostics := Check(source, options)
+
+	for round := 0; round < maxFixRounds; round++ {
+		fixes := make([]Fix, 0)
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Fix != nil {
+				fixes = append(fixes, *diagnostic.Fix)
+			}
+		}
+
+		if len(fixes) == 0 {
+			break
+		}
+
+		fixed, roundFixes := ApplyFixes(source, fixes)
+		if len(roundFixes) == 0 {
+			break
+		}
+
+		source = fixed
+		applied = append(applied, roundFixes...)
+		diagnostics = Check(source, options)
+	}
+
+	return source, applied, diagnostics
+}
@@ -0,0 +1,277 @@
+package glox
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// formatIndent is the indentation of a nesting level, the two spaces the prelude is written
+// with.
+const formatIndent = "  "
+
+// Format prints the source back in the canonical layout: every line indented by how deep it
+// is nested, one space between tokens where the layout asks for one and at most one blank
+// line in a row. Line breaks, comments and the tokens themselves are kept as they are, so
+// formatting never changes what the program means, and formatting a formatted source gives
+// it back unchanged.
+//
+// Nothing is printed if the source has errors.
+func Format(source string, options Options) (string, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	tokens := NewScanner(bytes.NewBufferString(source), diagnostics).ScanTokens()
+	NewParser(tokens, diagnostics, options).Parse()
+	if HasErrors(diagnostics.diagnostics) {
+		return "", diagnostics.diagnostics
+	}
+
+	f := &formatter{tokens: tokens}
+	f.split([]rune(source))
+	return f.print(), diagnostics.diagnostics
+}
+
+// formatLine is a line of the formatted source: the indexes of its tokens and the comment
+// ending it. Comment lines have no tokens.
+type formatLine struct {
+	tokens      []int
+	comment     string
+	blankBefore bool
+}
+
+// openBracket is a bracket that isn't closed yet, with the indentation of its line.
+type openBracket struct {
+	indent int
+	// header is set for the parentheses after if, while and for, whose statement is
+	// indented one more level when it goes on the next line and isn't a block.
+	header bool
+}
+
+// formatter lays out the tokens of a source line by line.
+type formatter struct {
+	tokens []ast.Token
+	lines  []*formatLine
+}
+
+// split finds the tokens in the source, in order, and groups them into lines along with the
+// comments. Everything between two tokens is whitespace or comments, so the lexeme of each
+// token starts at the first character that's neither.
+func (f *formatter) split(source []rune) {
+	line := &formatLine{}
+	newlines := 0
+	pos := 0
+
+	// start records the blank line before the first thing on the line.
+	start := func() {
+		if len(line.tokens) == 0 && line.comment == "" {
+			line.blankBefore = newlines > 1 && len(f.lines) > 0
+		}
+
+		newlines = 0
+	}
+
+	for n, token := range f.tokens {
+		for pos < len(source) {
+			if source[pos] == '\n' {
+				if len(line.tokens) > 0 || line.comment != "" {
+					f.lines = append(f.lines, line)
+					line = &formatLine{}
+				}
+
+				newlines++
+				pos++
+			} else if source[pos] == '/' && pos+1 < len(source) && source[pos+1] == '/' {
+				end := pos
+				for end < len(source) && source[end] != '\n' {
+					end++
+				}
+
+				start()
+				line.comment = strings.TrimRightFunc(string(source[pos:end]), unicode.IsSpace)
+				pos = end
+			} else if unicode.IsSpace(source[pos]) {
+				pos++
+			} else {
+				break
+			}
+		}
+
+		if token.Type == ast.Eof {
+			break
+		}
+
+		start()
+		line.tokens = append(line.tokens, n)
+		pos += len([]rune(token.Lexeme))
+	}
+
+	if len(line.tokens) > 0 || line.comment != "" {
+		f.lines = append(f.lines, line)
+	}
+}
+
+// print indents the lines and spaces out their tokens.
+func (f *formatter) print() string {
+	var out strings.Builder
+	var open []openBracket
+
+	// continued is the indentation of the statement of an if, while, for or else that goes
+	// on the next line, -1 if the line before didn't end with one.
+	continued := -1
+
+	for _, line := range f.lines {
+		if line.blankBefore {
+			out.WriteString("\n")
+		}
+
+		indent := 0
+		if len(open) > 0 {
+			indent = open[len(open)-1].indent + 1
+		}
+
+		if len(line.tokens) > 0 {
+			first := f.tokens[line.tokens[0]].Type
+			if isClosingBracket(first) {
+				indent = open[len(open)-1].indent
+			} else if continued >= 0 && first != ast.LeftBrace {
+				indent = continued
+			}
+		} else if continued >= 0 {
+			indent = continued
+		}
+
+		out.WriteString(strings.Repeat(formatIndent, indent))
+		if len(line.tokens) > 0 {
+			continued = -1
+		}
+
+		for k, n := range line.tokens {
+			token := f.tokens[n]
+			if k > 0 && f.spaceBefore(n) {
+				out.WriteString(" ")
+			}
+
+			out.WriteString(token.Lexeme)
+			last := k == len(line.tokens)-1
+			switch {
+			case isOpeningBracket(token.Type):
+				header := token.Type == ast.LeftParen && n > 0 && isHeaderKeyword(f.tokens[n-1].Type)
+				open = append(open, openBracket{indent: indent, header: header})
+			case isClosingBracket(token.Type):
+				if open[len(open)-1].header && last {
+					continued = indent + 1
+				}
+
+				open = open[:len(open)-1]
+			case token.Type == ast.Else && last:
+				continued = indent + 1
+			}
+		}
+
+		if line.comment != "" {
+			if len(line.tokens) > 0 {
+				out.WriteString(" ")
+			}
+
+			out.WriteString(line.comment)
+		}
+
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// spaceBefore reports if the nth token is separated from the token before it on its line.
+func (f *formatter) spaceBefore(n int) bool {
+	previous, token := f.tokens[n-1], f.tokens[n]
+	switch previous.Type {
+	case ast.LeftParen, ast.LeftBracket, ast.Dot, ast.DotDot, ast.DotDotEqual, ast.Bang:
+		return false
+	case ast.Minus:
+		if f.isUnary(n - 1) {
+			return false
+		}
+	case ast.LeftBrace:
+		if token.Type == ast.RightBrace || !f.isBlock(n-1) {
+			return false
+		}
+	}
+
+	switch token.Type {
+	case ast.RightParen, ast.RightBracket, ast.Comma, ast.Semicolon, ast.Dot, ast.Colon, ast.DotDot, ast.DotDotEqual:
+		return false
+	case ast.LeftParen, ast.LeftBracket:
+		return !endsOperand(previous.Type)
+	case ast.RightBrace:
+		return f.isBlock(f.matchingBrace(n))
+	}
+
+	return true
+}
+
+// isUnary reports if the minus sign at n negates the operand after it, rather than
+// subtracting it from the one before.
+func (f *formatter) isUnary(n int) bool {
+	return n == 0 || !endsOperand(f.tokens[n-1].Type)
+}
+
+// isBlock reports if the brace at n opens a block or a class body, and not a map literal.
+// Map literals are where an operand is expected.
+func (f *formatter) isBlock(n int) bool {
+	if n == 0 {
+		return true
+	}
+
+	switch f.tokens[n-1].Type {
+	case ast.RightParen, ast.Semicolon, ast.LeftBrace, ast.RightBrace, ast.Else, ast.Identifiers, ast.Try, ast.Finally:
+		return true
+	}
+
+	return false
+}
+
+// matchingBrace returns the index of the brace that the brace at n closes.
+func (f *formatter) matchingBrace(n int) int {
+	depth := 0
+	for k := n; k >= 0; k-- {
+		switch f.tokens[k].Type {
+		case ast.RightBrace:
+			depth++
+		case ast.LeftBrace:
+			depth--
+			if depth == 0 {
+				return k
+			}
+		}
+	}
+
+	return 0
+}
+
+// endsOperand reports if a token of the type can end an operand, so a bracket after it is
+// a call or an index and a minus after it is a subtraction.
+func endsOperand(tokenType ast.TokenType) bool {
+	switch tokenType {
+	case ast.Identifiers, ast.String, ast.Number, ast.True, ast.False, ast.Nil, ast.This, ast.Super,
+		ast.RightParen, ast.RightBracket, ast.RightBrace:
+		return true
+	}
+
+	return false
+}
+
+func isOpeningBracket(tokenType ast.TokenType) bool {
+	return tokenType == ast.LeftParen || tokenType == ast.LeftBracket || tokenType == ast.LeftBrace
+}
+
+func isClosingBracket(tokenType ast.TokenType) bool {
+	return tokenType == ast.RightParen || tokenType == ast.RightBracket || tokenType == ast.RightBrace
+}
+
+// isHeaderKeyword reports if the keyword starts a statement with a parenthesized header and
+// a statement of its own.
+func isHeaderKeyword(tokenType ast.TokenType) bool {
+	return tokenType == ast.If || tokenType == ast.While || tokenType == ast.For
+}
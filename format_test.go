@@ -0,0 +1,158 @@
+package glox
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// unformattedScript is laid out badly on purpose, with the constructs the formatter has to
+// tell apart: map literals and blocks, unary and binary minus, and statements after an if
+// or a loop header on the next line.
+const unformattedScript = `
+class   Point{
+init(x,y){this.x=x;   this.y =y;}
+    // the distance from the origin, squared
+  norm( ) { return this.x*this.x+this.y*this.y ; }
+}
+fun  negate(n){return -n;}
+var m={"a" :1,"b":[1,2 , -3]};
+
+
+for(var i=0;i<3;i=i+1)
+print negate(i)-1;
+if (m["a"]==1)
+    print "one";
+else { print   "other" ; }
+while(false)
+{}
+`
+
+// formatCorpus returns the sources the formatter is checked on: the prelude, the scripts of
+// the other tests, a badly laid out script and every one of them with its indentation
+// thrown away.
+func formatCorpus(t *testing.T) map[string]string {
+	t.Helper()
+
+	corpus := map[string]string{
+		"unformatted": unformattedScript,
+		"concurrent":  concurrentScript,
+		"callback":    callbackScript,
+	}
+
+	paths, err := fs.Glob(preludeFiles, "prelude/*.lox")
+	if err != nil {
+		t.Fatalf("listing the prelude: %s", err.Error())
+	}
+
+	for _, path := range paths {
+		source, err := preludeFiles.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %s", path, err.Error())
+		}
+
+		corpus[path] = string(source)
+	}
+
+	for name, source := range corpus {
+		lines := strings.Split(source, "\n")
+		for n, line := range lines {
+			lines[n] = "   " + strings.TrimSpace(line)
+		}
+
+		corpus[name+" unindented"] = strings.Join(lines, "\n")
+	}
+
+	return corpus
+}
+
+// significantTokens returns the types and lexemes of the tokens of the source, what
+// formatting must not change.
+func significantTokens(t *testing.T, source string) []string {
+	t.Helper()
+
+	tokens, diagnostics := ScanSource(source)
+	if len(diagnostics) > 0 {
+		t.Fatalf("scan errors: %v", diagnostics)
+	}
+
+	significant := make([]string, len(tokens))
+	for n, token := range tokens {
+		significant[n] = fmt.Sprintf("%d %s", token.Type, token.Lexeme)
+	}
+
+	return significant
+}
+
+func TestFormatIsIdempotent(t *testing.T) {
+	for name, source := range formatCorpus(t) {
+		t.Run(name, func(t *testing.T) {
+			formatted, diagnostics := Format(source, DefaultOptions())
+			if HasErrors(diagnostics) {
+				t.Fatalf("format errors: %v", diagnostics)
+			}
+
+			again, diagnostics := Format(formatted, DefaultOptions())
+			if HasErrors(diagnostics) {
+				t.Fatalf("format errors in the formatted source: %v", diagnostics)
+			}
+
+			if again != formatted {
+				t.Errorf("formatting twice changed the source:\n%s\nto:\n%s", formatted, again)
+			}
+		})
+	}
+}
+
+func TestFormatKeepsTokens(t *testing.T) {
+	for name, source := range formatCorpus(t) {
+		t.Run(name, func(t *testing.T) {
+			formatted, diagnostics := Format(source, DefaultOptions())
+			if HasErrors(diagnostics) {
+				t.Fatalf("format errors: %v", diagnostics)
+			}
+
+			before, after := significantTokens(t, source), significantTokens(t, formatted)
+			if len(before) != len(after) {
+				t.Fatalf("formatting changed the number of tokens from %d to %d", len(before), len(after))
+			}
+
+			for n := range before {
+				if before[n] != after[n] {
+					t.Fatalf("formatting changed token %d from %q to %q", n, before[n], after[n])
+				}
+			}
+		})
+	}
+}
+
+// TestFormatLayout checks the layout of the badly laid out script: indentation by nesting,
+// one space between tokens where lox puts one, and runs of blank lines kept to one.
+func TestFormatLayout(t *testing.T) {
+	expected := `class Point {
+  init(x, y) { this.x = x; this.y = y; }
+  // the distance from the origin, squared
+  norm() { return this.x * this.x + this.y * this.y; }
+}
+fun negate(n) { return -n; }
+var m = {"a": 1, "b": [1, 2, -3]};
+
+for (var i = 0; i < 3; i = i + 1)
+  print negate(i) - 1;
+if (m["a"] == 1)
+  print "one";
+else { print "other"; }
+while (false)
+{}
+`
+
+	formatted, diagnostics := Format(unformattedScript, DefaultOptions())
+	if HasErrors(diagnostics) {
+		t.Fatalf("format errors: %v", diagnostics)
+	}
+
+	if formatted != expected {
+		t.Errorf("formatted to:\n%s\nexpected:\n%s", formatted, expected)
+	}
+}
@@ -0,0 +1,73 @@
+package glox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EvaluateInFrame evaluates a single lox expression against the environment of a frame of
+// the current call stack, as a debugger does when execution is paused. Frame 0 is the
+// innermost call, as returned by CallStack, and frame len(CallStack()) is the top level
+// script. The variables of the expression, and its 'this' and 'super', are looked up
+// dynamically through the frame's environment chain since the expression was never
+// resolved, the functions it calls still run as resolved. The interpreter's environment is
+// restored afterwards, so execution can carry on undisturbed.
+func (i *Interpreter) EvaluateInFrame(frameIndex int, source string) (interface{}, error) {
+	env, err := i.frameEnvironment(frameIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, diagnostics := parseExpr(source, i.options())
+	if HasErrors(diagnostics) {
+		return nil, diagnosticsError(diagnostics)
+	}
+
+	previousEnv, previousDynamic := i.environment, i.dynamicLookup
+	i.environment, i.dynamicLookup = env, true
+	defer func() {
+		i.environment, i.dynamicLookup = previousEnv, previousDynamic
+	}()
+
+	return i.evaluate(expr)
+}
+
+// frameEnvironment returns the environment that was active in the frame. The innermost frame
+// is running right now, every other frame is suspended at the call to the frame inside it,
+// which recorded the caller's environment.
+func (i *Interpreter) frameEnvironment(frameIndex int) (*Environment, error) {
+	if frameIndex < 0 || frameIndex > len(i.frames) {
+		return nil, fmt.Errorf("frame %d is out of range, the call stack has %d frames", frameIndex, len(i.frames)+1)
+	}
+
+	if frameIndex == 0 {
+		return i.environment, nil
+	}
+
+	return i.frames[len(i.frames)-frameIndex].callerEnv, nil
+}
+
+// options returns the options of the interpreter's runtime, the default ones for an
+// interpreter made without one.
+func (i *Interpreter) options() Options {
+	if i.runtime == nil {
+		return DefaultOptions()
+	}
+
+	return i.runtime.options
+}
+
+// diagnosticsError joins the diagnostics into a single error.
+func diagnosticsError(diagnostics []Diagnostic) error {
+	messages := make([]string, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, diagnostic.String())
+	}
+
+	if len(messages) == 0 {
+		return errors.New("invalid expression")
+	}
+
+	return errors.New(strings.Join(messages, "\n"))
+}
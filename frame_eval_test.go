@@ -0,0 +1,60 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+const frameEvalScript = `
+var a = "global";
+{
+  fun show() { return a; }
+  var a = "block";
+  print inspect("show()");
+  print inspect("a");
+}
+
+class Base {
+  greet() { return "base"; }
+}
+
+class Derived < Base {
+  greet() { return "derived"; }
+  check() {
+    var local = 1;
+    print inspect("super.greet()");
+    print inspect("this.greet()");
+    print inspect("local + 1");
+  }
+}
+
+Derived().check();
+print inspect("super.greet()");
+`
+
+// TestEvaluateInFrame evaluates expressions in the innermost frame through a native, as a
+// debugger paused there would.
+func TestEvaluateInFrame(t *testing.T) {
+	options := DefaultOptions()
+	options.Globals = map[string]interface{}{
+		"inspect": NewNativeFunction("inspect", "", 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			value, err := interpreter.EvaluateInFrame(0, arguments[0].(string))
+			if err != nil {
+				return "error: " + err.Error(), nil
+			}
+
+			return value, nil
+		}),
+	}
+
+	output, err := runSource(t, frameEvalScript, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	// show() was resolved to the global a, evaluating it in a frame must not change that.
+	expected := []string{"global", "block", "base", "derived", "2", "error: Can't use 'super' outside of a method of a subclass."}
+	if lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n"); strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("printed %q, expected %q", lines, expected)
+	}
+}
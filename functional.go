@@ -0,0 +1,263 @@
+package glox
+
+import (
+	"fmt"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// ComposedFunction is the callable returned by compose(f, g). Calling it calls g with the
+// arguments and then f with the result, so compose(f, g)(x) is f(g(x)).
+type ComposedFunction struct {
+	outer LoxCallable
+	inner LoxCallable
+}
+
+func NewComposedFunction(outer, inner LoxCallable) *ComposedFunction {
+	return &ComposedFunction{outer: outer, inner: inner}
+}
+
+func (cf *ComposedFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	value, err := callValue(interpreter, cf.inner, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return callValue(interpreter, cf.outer, []interface{}{value})
+}
+
+// Arity of a composed function is the arity of the inner function, as that's the one that
+// receives the arguments.
+func (cf *ComposedFunction) Arity() int {
+	return cf.inner.Arity()
+}
+
+func (cf *ComposedFunction) MaxArity() int {
+	_, max := arityRange(cf.inner)
+	return max
+}
+
+func (cf *ComposedFunction) Name() string {
+	return "compose(" + cf.outer.Name() + ", " + cf.inner.Name() + ")"
+}
+
+func (cf *ComposedFunction) Doc() string {
+	return cf.Name() + " calls " + cf.inner.Name() + " and passes the result to " + cf.outer.Name() + "."
+}
+
+func (cf *ComposedFunction) String() string {
+	return "<fn " + cf.Name() + ">"
+}
+
+// PartialFunction is the callable returned by partial(f, ...args) and f.bindArgs(...args).
+// It remembers the bound arguments and passes them before the arguments of each call.
+type PartialFunction struct {
+	function LoxCallable
+	bound    []interface{}
+}
+
+func NewPartialFunction(function LoxCallable, bound []interface{}) *PartialFunction {
+	return &PartialFunction{function: function, bound: bound}
+}
+
+func (pf *PartialFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	all := make([]interface{}, 0, len(pf.bound)+len(arguments))
+	all = append(all, pf.bound...)
+	all = append(all, arguments...)
+
+	return callValue(interpreter, pf.function, all)
+}
+
+func (pf *PartialFunction) Arity() int {
+	min, _ := arityRange(pf.function)
+	if min < len(pf.bound) {
+		return 0
+	}
+
+	return min - len(pf.bound)
+}
+
+func (pf *PartialFunction) MaxArity() int {
+	_, max := arityRange(pf.function)
+	if max == VariadicArity {
+		return max
+	}
+
+	return max - len(pf.bound)
+}
+
+// Parameters returns the parameters of the wrapped function that are not bound yet, so
+// the remaining arguments can still be passed by name.
+func (pf *PartialFunction) Parameters() []string {
+	named, ok := pf.function.(ParameterNames)
+	if !ok {
+		return nil
+	}
+
+	return named.Parameters()[len(pf.bound):]
+}
+
+func (pf *PartialFunction) Name() string {
+	return "partial(" + pf.function.Name() + ")"
+}
+
+func (pf *PartialFunction) Doc() string {
+	return fmt.Sprintf("%s with %d bound arguments: %s", pf.function.Name(), len(pf.bound), pf.function.Doc())
+}
+
+func (pf *PartialFunction) String() string {
+	return "<fn " + pf.Name() + ">"
+}
+
+// callValue calls a callable from go code, checking the number of arguments just like a
+// call expression in lox would.
+func callValue(interpreter *Interpreter, callable LoxCallable, arguments []interface{}) (interface{}, error) {
+	if message, ok := checkArity(callable, len(arguments)); !ok {
+		return nil, NewNativeError(message)
+	}
+
+	return callable.Call(interpreter, arguments)
+}
+
+// callableProperty returns the properties that every callable has, e.g. fn.bindArgs.
+func callableProperty(callable LoxCallable, name ast.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "bindArgs":
+		doc := "bindArgs(...args) returns " + callable.Name() + " with the given arguments bound."
+		return NewVariadicNativeFunction("bindArgs", doc, 0, VariadicArity, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return bindArguments(callable, arguments)
+		}), nil
+	}
+
+	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+}
+
+func compose(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	outer, ok := arguments[0].(LoxCallable)
+	if !ok {
+		return nil, NewNativeError("compose expects functions as arguments")
+	}
+
+	inner, ok := arguments[1].(LoxCallable)
+	if !ok {
+		return nil, NewNativeError("compose expects functions as arguments")
+	}
+
+	if message, ok := checkArity(outer, 1); !ok {
+		return nil, NewNativeError(message)
+	}
+
+	return NewComposedFunction(outer, inner), nil
+}
+
+func partial(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	function, ok := arguments[0].(LoxCallable)
+	if !ok {
+		return nil, NewNativeError("partial expects a function as the first argument")
+	}
+
+	return bindArguments(function, arguments[1:])
+}
+
+// bindArguments creates a partial function, making sure the callable can take the number
+// of arguments being bound.
+func bindArguments(callable LoxCallable, arguments []interface{}) (interface{}, error) {
+	if _, max := arityRange(callable); max != VariadicArity && len(arguments) > max {
+		return nil, NewNativeError(fmt.Sprintf("Can't bind %d arguments to '%s', it takes at most %d", len(arguments), callable.Name(), max))
+	}
+
+	return NewPartialFunction(callable, arguments), nil
+}
+
+// MemoizedFunction is the callable returned by memoize(fn). It caches the result of each
+// call keyed on the argument values, so calling it again with equal arguments returns the
+// cached result without calling fn.
+type MemoizedFunction struct {
+	function LoxCallable
+	cache    *memoEntry
+}
+
+// memoEntry is a node in a trie of argument keys. Each level of the trie is keyed by the
+// argument at that position, which lets us cache calls with any number of arguments.
+type memoEntry struct {
+	children map[interface{}]*memoEntry
+	value    interface{}
+	cached   bool
+}
+
+func NewMemoizedFunction(function LoxCallable) *MemoizedFunction {
+	return &MemoizedFunction{function: function, cache: &memoEntry{}}
+}
+
+func (mf *MemoizedFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	entry := mf.cache
+	for _, argument := range arguments {
+		key, ok := hashKey(argument)
+		if !ok {
+			return nil, NewNativeError("Can't memoize a call with an unhashable argument")
+		}
+
+		if entry.children == nil {
+			entry.children = make(map[interface{}]*memoEntry)
+		}
+
+		child, ok := entry.children[key]
+		if !ok {
+			child = &memoEntry{}
+			entry.children[key] = child
+		}
+
+		entry = child
+	}
+
+	if entry.cached {
+		return entry.value, nil
+	}
+
+	value, err := callValue(interpreter, mf.function, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.value = value
+	entry.cached = true
+	return value, nil
+}
+
+func (mf *MemoizedFunction) Arity() int {
+	return mf.function.Arity()
+}
+
+func (mf *MemoizedFunction) MaxArity() int {
+	_, max := arityRange(mf.function)
+	return max
+}
+
+func (mf *MemoizedFunction) Parameters() []string {
+	if named, ok := mf.function.(ParameterNames); ok {
+		return named.Parameters()
+	}
+
+	return nil
+}
+
+func (mf *MemoizedFunction) Name() string {
+	return mf.function.Name()
+}
+
+func (mf *MemoizedFunction) Doc() string {
+	return "memoized " + mf.function.Doc()
+}
+
+func (mf *MemoizedFunction) String() string {
+	return "<fn " + mf.Name() + ">"
+}
+
+func memoize(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	function, ok := arguments[0].(LoxCallable)
+	if !ok {
+		return nil, NewNativeError("memoize expects a function as argument")
+	}
+
+	return NewMemoizedFunction(function), nil
+}
@@ -4,22 +4,184 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
-)
+	"strings"
 
-var interpreter *Interpreter
+	"github.com/iamsayantan/glox/ast"
+)
 
 type Runtime struct {
 	hadError        bool
 	hadRuntimeError bool
+
+	// scriptMode is set when running a whole script rather than prompt lines, so every
+	// global the script uses is known up front and can be checked before running it.
+	scriptMode bool
+
+	// source is the script or prompt line being run, to show the spans of diagnostics. It's
+	// empty when the source isn't what the user wrote, like the program of a template.
+	source string
+
+	options Options
+
+	// interpreter runs the statements of the script or prompt. Every runtime has its own.
+	interpreter *Interpreter
+
+	// tracer records function call spans when a trace output file is set.
+	tracer *ChromeTracer
+
+	// definitions are the globals declared at the prompt, for :history defs.
+	definitions []definition
+
+	// frame collects the result, the diagnostics and the runtime error of the request the
+	// JSON prompt is evaluating, instead of printing them. It's nil otherwise.
+	frame *replFrame
+}
+
+// Options configures how a Runtime scans, parses and runs lox code.
+type Options struct {
+	// MaxArguments is the maximum number of parameters a function can declare and the
+	// maximum number of arguments a call can pass.
+	MaxArguments int
+
+	// MaxCallDepth is the deepest the lox call stack can get before a call fails with a
+	// stack overflow, 0 for no limit.
+	MaxCallDepth int
+
+	// LanguageOptions are the extensions of the grammar that are enabled.
+	LanguageOptions
+
+	// Stats collects call counts, time and allocations of every lox function and prints
+	// them when the script or prompt exits.
+	Stats bool
+
+	// DebugResolver checks every variable access against a dynamic lookup to validate the
+	// distances computed by the resolver.
+	DebugResolver bool
+
+	// DebugEnv prints the environment chain, scope by scope, along with a runtime error.
+	DebugEnv bool
+
+	// TraceOut is the path of a Chrome trace file of the function calls, written when the
+	// script or prompt exits. No trace is recorded if it's empty.
+	TraceOut string
+
+	// PrintAst prints the syntax tree of the source before running it. The tree is printed
+	// even when there are parse errors, with markers where the parser lost track.
+	PrintAst bool
+
+	// RecordOut is the path of a log of the results of the nondeterministic native
+	// functions, written when the script or prompt exits. ReplayIn is the path of such a
+	// log to reproduce a recorded run.
+	RecordOut string
+	ReplayIn  string
+
+	// History records a snapshot of the environment after every statement, which the
+	// prompt can list and go back to.
+	History bool
+
+	// BigNumbers backs every number with an arbitrary precision float instead of a
+	// float64, trading speed for exactness.
+	BigNumbers bool
+
+	// StrictLogical makes 'and' and 'or' require boolean operands, so they evaluate to
+	// booleans. They still short-circuit.
+	StrictLogical bool
+
+	// NoPrelude leaves out the standard library written in lox, only the native functions
+	// are defined in the global environment.
+	NoPrelude bool
+
+	// Watch are the names of the variables to watch. Every time one of them is defined or
+	// assigned, its new value and the call stack are printed.
+	Watch []string
+
+	// MinimizeCrashes adds the smallest source that still crashes glox the same way to the
+	// crash report written when glox panics, found by running parts of the source again.
+	MinimizeCrashes bool
+
+	// Breakpoints pause the script when their line is reached, to prompt for expressions to
+	// evaluate in the paused frame.
+	Breakpoints []Breakpoint
+
+	// Globals are extra global variables of the programs made by Compile, like the native
+	// functions of the application embedding glox. Values are converted like the variables
+	// of a Sandbox. A Runtime defines its extra globals with Define instead.
+	Globals map[string]interface{}
+}
+
+// DefaultOptions returns the options used by NewRuntime.
+func DefaultOptions() Options {
+	language, _ := EditionOptions(EditionGlox)
+	return Options{MaxArguments: 255, MaxCallDepth: defaultMaxCallDepth, LanguageOptions: language}
 }
 
 func NewRuntime() *Runtime {
+	return NewRuntimeWithOptions(DefaultOptions())
+}
+
+func NewRuntimeWithOptions(options Options) *Runtime {
 	r := &Runtime{
 		hadError: false,
+		options:  options,
+	}
+
+	r.interpreter = NewInterpreter(r)
+	r.interpreter.applyOptions(options)
+	if options.Stats {
+		r.interpreter.EnableStats()
+	}
+
+	if options.History {
+		r.interpreter.EnableHistory()
+	}
+
+	if options.RecordOut != "" {
+		r.interpreter.StartRecording()
+	}
+
+	if options.ReplayIn != "" {
+		if err := r.loadReplay(options.ReplayIn); err != nil {
+			fmt.Printf("error reading replay log: %s\n", err.Error())
+			os.Exit(74)
+		}
+	}
+
+	r.interpreter.defineBuildInfo(options)
+	if !options.NoPrelude {
+		if err := r.interpreter.loadPrelude(); err != nil {
+			panic("glox: loading the prelude: " + err.Error())
+		}
+	}
+
+	r.interpreter.forgetHistory()
+	if len(options.Watch) > 0 {
+		r.interpreter.SetWatchHandler(r.interpreter.printWatchHit(os.Stdout))
+		for _, name := range options.Watch {
+			r.interpreter.Watch(name)
+		}
+	}
+
+	if len(options.Breakpoints) > 0 {
+		r.interpreter.SetBreakHandler(r.interpreter.debugPrompt(os.Stdout))
+		for _, bp := range options.Breakpoints {
+			if err := r.interpreter.SetBreakpoint(bp); err != nil {
+				fmt.Printf("invalid breakpoint condition on line %d: %s\n", bp.Line, err.Error())
+				os.Exit(64)
+			}
+		}
+	}
+
+	if options.DebugEnv {
+		r.interpreter.EnableEnvDebugging()
+	}
+
+	if options.TraceOut != "" {
+		r.tracer = NewChromeTracer()
+		r.interpreter.AddEventHandler(r.tracer.HandleEvent)
 	}
 
-	interpreter = NewInterpreter(r)
 	return r
 }
 
@@ -41,7 +203,10 @@ func (r *Runtime) RunFile(path string) {
 		return
 	}
 
-	r.run(string(data))
+	r.scriptMode = true
+	r.source = string(data)
+	r.run(r.source)
+	r.finish()
 
 	if r.hadError {
 		os.Exit(65)
@@ -65,9 +230,53 @@ func (r *Runtime) RunPrompt() {
 			break
 		}
 
-		r.run(line)
+		if strings.HasPrefix(line, ":") {
+			r.runCommand(line)
+		} else if expr, diagnostics := parseExpr(line, r.options); !HasErrors(diagnostics) {
+			r.printExpression(expr, line)
+		} else {
+			r.source = line
+			r.run(line)
+			r.source = ""
+		}
+
 		r.hadError = false
 	}
+
+	r.finish()
+}
+
+// finish prints the per function statistics to stderr and writes the trace file, if they
+// are enabled.
+func (r *Runtime) finish() {
+	if r.options.Stats {
+		printStats(os.Stderr, r.interpreter.Stats())
+		if classes := r.interpreter.ClassStats(); len(classes) > 0 {
+			fmt.Fprintln(os.Stderr)
+			printClassStats(os.Stderr, classes)
+		}
+	}
+
+	if r.tracer != nil {
+		r.writeTrace()
+	}
+
+	if r.options.RecordOut != "" {
+		r.writeRecording()
+	}
+}
+
+func (r *Runtime) writeTrace() {
+	f, err := os.Create(r.options.TraceOut)
+	if err != nil {
+		fmt.Printf("error writing trace: %s\n", err.Error())
+		return
+	}
+
+	defer f.Close()
+	if _, err := r.tracer.WriteTo(f); err != nil {
+		fmt.Printf("error writing trace: %s\n", err.Error())
+	}
 }
 
 func (r *Runtime) Error(line int, message string) {
@@ -75,42 +284,159 @@ func (r *Runtime) Error(line int, message string) {
 }
 
 func (r *Runtime) run(source string) {
+	defer r.recoverCrash(source, r.crashBase())
+
+	statements, ok := r.compile(source)
+	if !ok {
+		return
+	}
+
+	if !r.scriptMode {
+		r.trackDefinitions(statements)
+	}
+
+	r.interpreter.Interpret(statements)
+}
+
+// printExpression evaluates an expression typed at the prompt and prints its value, so
+// there's no need to wrap it in a print statement. A crash is reported with the print
+// statement it stands for as the source.
+func (r *Runtime) printExpression(expr ast.Expr, line string) {
+	defer r.recoverCrash("print "+line+";", r.crashBase())
+
+	if r.options.PrintAst {
+		fmt.Println(NewAstPrinter().PrintExpr(expr))
+	}
+
+	resolver := NewResolver(r)
+	resolver.resolveExpr(expr)
+	if r.hadError {
+		return
+	}
+
+	r.interpreter.addLocals(resolver.Locals())
+	value, err := r.interpreter.evaluate(expr)
+	if err != nil {
+		err = r.interpreter.uncaught(err)
+		r.interpreter.keepEnvironment(err)
+		if runErr, ok := err.(*RuntimeError); ok {
+			r.interpreter.emit(ErrorRaised{Message: runErr.message, Line: runErr.token.Line})
+		}
+
+		r.runtimeError(err)
+		return
+	}
+
+	if r.frame != nil {
+		result := r.interpreter.stringify(value)
+		r.frame.Result = &result
+		return
+	}
+
+	fmt.Fprintln(r.interpreter.out, r.interpreter.stringify(value))
+}
+
+// compile scans, parses and resolves the source, reporting any diagnostics. The resolved
+// locals and the source map are handed to the interpreter, so the statements are ready to
+// be interpreted if there were no errors.
+func (r *Runtime) compile(source string) ([]ast.Stmt, bool) {
 	scanner := NewScanner(bytes.NewBuffer([]byte(source)), r)
 	tokens := scanner.ScanTokens()
 
-	parser := NewParser(tokens, r)
+	parser := NewParser(tokens, r, r.options)
 	statements := parser.Parse()
 
+	if r.options.PrintAst {
+		fmt.Print(NewAstPrinter().Print(statements))
+	}
+
 	if r.hadError {
-		return
+		return nil, false
+	}
+
+	resolver := NewResolver(r)
+	resolver.SetDirectives(scanner.Directives())
+	if r.scriptMode {
+		resolver.CheckGlobals(r.interpreter.globals.Names())
 	}
 
-	resolver := NewResolver(interpreter, r)
-	resolver.resolveStatements(statements)
+	resolver.ResolveProgram(statements)
 
 	if r.hadError {
+		return nil, false
+	}
+
+	r.interpreter.addLocals(resolver.Locals())
+	r.interpreter.addSourceMap(parser.SourceMap())
+	return statements, true
+}
+
+// SetOutput sets where print statements write to, os.Stdout by default. Diagnostics and
+// runtime errors are still written to os.Stdout.
+func (r *Runtime) SetOutput(w io.Writer) {
+	r.interpreter.SetOutput(w)
+}
+
+// Define defines a global variable for the scripts the runtime runs. Values are converted
+// like the variables of a Sandbox, so Go channels and contexts can be passed to scripts.
+func (r *Runtime) Define(name string, value interface{}) error {
+	value, err := sandboxValue(value)
+	if err != nil {
+		return fmt.Errorf("variable '%s': %s", name, err.Error())
+	}
+
+	r.interpreter.defineGlobal(name, value)
+	return nil
+}
+
+// SetEventHandler replaces the handlers that receive the events emitted while running code
+// with the handler. The tracer of --trace-out keeps receiving them.
+func (r *Runtime) SetEventHandler(handler EventHandler) {
+	r.interpreter.SetEventHandler(handler)
+	if r.tracer != nil {
+		r.interpreter.AddEventHandler(r.tracer.HandleEvent)
+	}
+}
+
+// AddEventHandler adds a handler that receives the events emitted while running code, after
+// the handlers added before it.
+func (r *Runtime) AddEventHandler(handler EventHandler) {
+	r.interpreter.AddEventHandler(handler)
+}
+
+// Report prints a diagnostic found in the source. Errors prevent the source from being run.
+func (r *Runtime) Report(diagnostic Diagnostic) {
+	if diagnostic.Severity == SeverityError {
+		r.hadError = true
+	}
+
+	if r.frame != nil {
+		r.frame.addDiagnostic(diagnostic)
 		return
 	}
-	
-	interpreter.Interpret(statements)
+
+	fmt.Println(diagnostic)
+	if squiggle := diagnostic.Squiggle(r.source); squiggle != "" {
+		fmt.Println(squiggle)
+	}
 }
 
 func (r *Runtime) report(line int, where string, message string) {
-	errMessage := fmt.Sprintf("[line %d] Error%s: %s", line, where, message)
-	r.hadError = true
-	fmt.Println(errMessage)
+	r.Report(Diagnostic{Severity: SeverityError, Line: line, Where: where, Message: message})
 }
 
 func (r *Runtime) runtimeError(err error) {
 	runErr := err.(*RuntimeError)
-	fmt.Printf("%s \n[line %d ]\n", runErr.Error(), runErr.token.Line)
-	r.hadRuntimeError = true
-}
+	if r.frame != nil {
+		r.frame.Error = &JSONError{Code: runErr.Code(), Message: runErr.Error(), Line: runErr.token.Line}
+		r.hadRuntimeError = true
+		return
+	}
 
-func (r *Runtime) tokenError(token Token, message string) {
-	if token.Type == Eof {
-		r.report(token.Line, " at end ", message)
-	} else {
-		r.report(token.Line, " at '"+token.Lexeme+"'", message)
+	fmt.Printf("Error[%s]: %s \n[line %d ]\n", runErr.Code(), runErr.Error(), runErr.token.Line)
+	if runErr.environment != nil {
+		r.interpreter.writeEnvironment(os.Stdout, runErr.environment, r.interpreter.envBaseline)
 	}
+
+	r.hadRuntimeError = true
 }
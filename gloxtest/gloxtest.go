@@ -0,0 +1,150 @@
+// Package gloxtest helps Go programs that embed glox test their lox scripts, and the native
+// functions they give to scripts, with the standard testing package.
+//
+//	func TestGreeting(t *testing.T) {
+//		gloxtest.AssertOutput(t, `print greet("ada");`, "hello ada\n",
+//			gloxtest.WithNative("greet", 1, greet))
+//	}
+package gloxtest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iamsayantan/glox"
+)
+
+// Option changes how a script is compiled and run.
+type Option func(options *glox.Options)
+
+// WithOptions replaces the default options the script is compiled and run with. Globals
+// added by other options are kept if they come after it.
+func WithOptions(options glox.Options) Option {
+	return func(o *glox.Options) {
+		*o = options
+	}
+}
+
+// WithGlobal defines a global variable for the script. Values are converted like the
+// variables of a glox.Sandbox.
+func WithGlobal(name string, value interface{}) Option {
+	return func(o *glox.Options) {
+		globals := make(map[string]interface{}, len(o.Globals)+1)
+		for global, value := range o.Globals {
+			globals[global] = value
+		}
+
+		globals[name] = value
+		o.Globals = globals
+	}
+}
+
+// WithNative defines a native function taking arity arguments for the script.
+func WithNative(name string, arity int, fn glox.NativeFn) Option {
+	return WithGlobal(name, glox.NewNativeFunction(name, "", arity, fn))
+}
+
+// RunScript compiles and runs the source with the default options changed by opts. It
+// returns what the script printed and the diagnostics of compiling it. A script with errors
+// doesn't run and prints nothing, a runtime error fails the test.
+func RunScript(t testing.TB, source string, opts ...Option) (string, []glox.Diagnostic) {
+	t.Helper()
+
+	output, diagnostics, err := run(source, opts)
+	if err != nil {
+		t.Fatalf("gloxtest: runtime error: %s\noutput:\n%s", err.Error(), output)
+	}
+
+	return output, diagnostics
+}
+
+// RunScriptError runs the script like RunScript, but expects it to stop with a runtime
+// error. It returns what the script printed before the error and the error, and fails the
+// test if the script has compile errors or runs to the end.
+func RunScriptError(t testing.TB, source string, opts ...Option) (string, error) {
+	t.Helper()
+
+	output, diagnostics, err := run(source, opts)
+	if glox.HasErrors(diagnostics) {
+		t.Fatalf("gloxtest: compile errors:\n%s", formatDiagnostics(diagnostics))
+	}
+
+	if err == nil {
+		t.Fatalf("gloxtest: expected a runtime error\noutput:\n%s", output)
+	}
+
+	return output, err
+}
+
+// AssertOutput runs the script and fails the test unless it compiles without errors, runs
+// to the end and prints exactly the expected output.
+func AssertOutput(t testing.TB, source, expected string, opts ...Option) {
+	t.Helper()
+
+	output, diagnostics := RunScript(t, source, opts...)
+	if glox.HasErrors(diagnostics) {
+		t.Fatalf("gloxtest: compile errors:\n%s", formatDiagnostics(diagnostics))
+	}
+
+	if output != expected {
+		t.Errorf("gloxtest: %s", outputMismatch(expected, output))
+	}
+}
+
+// AssertError runs the script and fails the test unless it stops with a runtime error with
+// the message.
+func AssertError(t testing.TB, source, message string, opts ...Option) {
+	t.Helper()
+
+	if _, err := RunScriptError(t, source, opts...); err.Error() != message {
+		t.Errorf("gloxtest: expected runtime error %q, got %q", message, err.Error())
+	}
+}
+
+// run compiles and runs the source, returning the output, the diagnostics and the runtime
+// error.
+func run(source string, opts []Option) (string, []glox.Diagnostic, error) {
+	options := glox.DefaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	program, diagnostics := glox.Compile(source, options)
+	if program == nil {
+		return "", diagnostics, nil
+	}
+
+	var out bytes.Buffer
+	err := program.Run(&out)
+	return out.String(), diagnostics, err
+}
+
+// outputMismatch describes the first line where the output differs from the expected one.
+func outputMismatch(expected, output string) string {
+	expectedLines := strings.Split(expected, "\n")
+	outputLines := strings.Split(output, "\n")
+
+	for n := 0; n < len(expectedLines) || n < len(outputLines); n++ {
+		switch {
+		case n >= len(outputLines):
+			return fmt.Sprintf("output line %d: expected %q, got nothing", n+1, expectedLines[n])
+		case n >= len(expectedLines):
+			return fmt.Sprintf("output line %d: got unexpected %q", n+1, outputLines[n])
+		case expectedLines[n] != outputLines[n]:
+			return fmt.Sprintf("output line %d: expected %q, got %q", n+1, expectedLines[n], outputLines[n])
+		}
+	}
+
+	return fmt.Sprintf("expected output %q, got %q", expected, output)
+}
+
+func formatDiagnostics(diagnostics []glox.Diagnostic) string {
+	lines := make([]string, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		lines = append(lines, diagnostic.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
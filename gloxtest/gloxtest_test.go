@@ -0,0 +1,183 @@
+package gloxtest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/iamsayantan/glox"
+)
+
+// fakeTB records the failures reported to it instead of failing the test. Like testing.T,
+// Fatalf stops the goroutine calling it, so the helpers must be called through check.
+type fakeTB struct {
+	testing.TB
+	failed   bool
+	fatal    bool
+	messages []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.failed = true
+	f.messages = append(f.messages, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.Errorf(format, args...)
+	f.fatal = true
+	runtime.Goexit()
+}
+
+// check calls the helper with a fake testing.TB on a goroutine of its own, which Fatalf can
+// stop, and returns the fake with the failures reported.
+func check(helper func(t testing.TB)) *fakeTB {
+	fake := &fakeTB{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		helper(fake)
+	}()
+
+	<-done
+	return fake
+}
+
+// expectFailure fails the test unless the fake was failed with a message containing text.
+func expectFailure(t *testing.T, fake *fakeTB, fatal bool, text string) {
+	t.Helper()
+
+	if !fake.failed {
+		t.Fatalf("expected a failure containing %q, the test passed", text)
+	}
+
+	if fake.fatal != fatal {
+		t.Errorf("expected Fatalf to be called: %t, it was: %t", fatal, fake.fatal)
+	}
+
+	message := strings.Join(fake.messages, "\n")
+	if !strings.Contains(message, text) {
+		t.Errorf("expected a failure containing %q, got %q", text, message)
+	}
+}
+
+func double(interpreter *glox.Interpreter, arguments []interface{}) (interface{}, error) {
+	n, ok := arguments[0].(float64)
+	if !ok {
+		return nil, glox.NewNativeError("double expects a number")
+	}
+
+	return n * 2, nil
+}
+
+func TestRunScript(t *testing.T) {
+	var output string
+	var diagnostics []glox.Diagnostic
+	fake := check(func(tb testing.TB) {
+		output, diagnostics = RunScript(tb, `print double(x);`, WithNative("double", 1, double), WithGlobal("x", 21))
+	})
+
+	if fake.failed {
+		t.Fatalf("unexpected failure: %v", fake.messages)
+	}
+
+	if output != "42\n" || glox.HasErrors(diagnostics) {
+		t.Errorf("got output %q and diagnostics %v", output, diagnostics)
+	}
+}
+
+func TestRunScriptReturnsCompileErrors(t *testing.T) {
+	var output string
+	var diagnostics []glox.Diagnostic
+	fake := check(func(tb testing.TB) {
+		output, diagnostics = RunScript(tb, `print ;`)
+	})
+
+	if fake.failed {
+		t.Fatalf("unexpected failure: %v", fake.messages)
+	}
+
+	if output != "" || !glox.HasErrors(diagnostics) {
+		t.Errorf("expected compile errors and no output, got output %q and diagnostics %v", output, diagnostics)
+	}
+}
+
+func TestRunScriptFailsOnRuntimeError(t *testing.T) {
+	fake := check(func(tb testing.TB) {
+		RunScript(tb, `print "before"; print 1 - nil;`)
+	})
+
+	expectFailure(t, fake, true, "runtime error: Both operands must be numbers")
+	expectFailure(t, fake, true, "before")
+}
+
+func TestAssertOutput(t *testing.T) {
+	fake := check(func(tb testing.TB) {
+		AssertOutput(tb, `print double(2); print "done";`, "4\ndone\n", WithNative("double", 1, double))
+	})
+
+	if fake.failed {
+		t.Fatalf("unexpected failure: %v", fake.messages)
+	}
+}
+
+func TestAssertOutputFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+		fatal    bool
+		message  string
+	}{
+		{name: "different line", source: `print 1; print 2;`, expected: "1\n3\n", message: `output line 2: expected "3", got "2"`},
+		{name: "missing line", source: `print 1;`, expected: "1\n2\n", message: `output line 2: expected "2", got ""`},
+		{name: "extra line", source: `print 1; print 2;`, expected: "1\n", message: `output line 2: expected "", got "2"`},
+		{name: "compile error", source: `print ;`, expected: "", fatal: true, message: "compile errors"},
+		{name: "runtime error", source: `print -"a";`, expected: "", fatal: true, message: "runtime error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := check(func(tb testing.TB) {
+				AssertOutput(tb, test.source, test.expected)
+			})
+
+			expectFailure(t, fake, test.fatal, test.message)
+		})
+	}
+}
+
+func TestAssertError(t *testing.T) {
+	fake := check(func(tb testing.TB) {
+		AssertError(tb, `print double("a");`, "double expects a number", WithNative("double", 1, double))
+	})
+
+	if fake.failed {
+		t.Fatalf("unexpected failure: %v", fake.messages)
+	}
+}
+
+func TestAssertErrorFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		fatal   bool
+		message string
+	}{
+		{name: "other error", source: `print 1 - nil;`, message: `expected runtime error "double expects a number", got "Both operands must be numbers"`},
+		{name: "no error", source: `print double(1);`, fatal: true, message: "expected a runtime error\noutput:\n2\n"},
+		{name: "compile error", source: `print ;`, fatal: true, message: "compile errors"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := check(func(tb testing.TB) {
+				AssertError(tb, test.source, "double expects a number", WithNative("double", 1, double))
+			})
+
+			expectFailure(t, fake, test.fatal, test.message)
+		})
+	}
+}
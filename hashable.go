@@ -0,0 +1,41 @@
+package glox
+
+import (
+	"math"
+	"math/big"
+)
+
+// Hashable is implemented by runtime values that can be used as keys, e.g. by memoize.
+// HashKey must return a comparable go value, and values that are equal in lox must
+// return equal keys.
+type Hashable interface {
+	HashKey() interface{}
+}
+
+// hashKey returns a comparable go value identifying the lox value. Numbers, strings,
+// booleans and nil are keyed by their value, except NaN which can't be a key, and big
+// numbers share the key of the float64 they are equal to. Instances, classes and functions
+// are keyed by their identity, and so are arrays and maps, since they are mutable, and
+// instances with an equals method, although == compares them by value.
+func hashKey(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case float64:
+		// NaN isn't equal to itself, so it could be added as a key but never found again.
+		return value, !math.IsNaN(value)
+	case nil, bool, string:
+		return value, true
+	case *big.Float:
+		// A big number equal to a float64 must find the same entry as the float64, 3n as 3.
+		if number, accuracy := value.Float64(); accuracy == big.Exact {
+			return number, true
+		}
+
+		return value.Text('g', -1), true
+	case Hashable:
+		return value.HashKey(), true
+	case *LoxInstance, *LoxClass, LoxFunction, *NativeFunction, *ComposedFunction, *PartialFunction, *MemoizedFunction, *LoxChannel, *LoxContext, *LoxSequence, *LoxArray, *LoxMap:
+		return value, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,131 @@
+package glox
+
+import (
+	"reflect"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// historyLimit is the number of statements the history keeps, older ones are dropped.
+const historyLimit = 10000
+
+// HistoryEntry is a statement that was executed along with a snapshot of the environment
+// right after it ran.
+type HistoryEntry struct {
+	Statement   ast.Stmt
+	Environment *Environment
+}
+
+// EnableHistory switches the interpreter to persistent environments and records a snapshot
+// after every statement, which lets a debugger or the prompt look at, or go back to, any
+// earlier point of the run. It must be called before running any code.
+func (i *Interpreter) EnableHistory() {
+	global := NewPersistentEnvironment()
+	for _, name := range i.globals.Names() {
+		value, _ := i.globals.lookup(ast.Intern(name))
+		global.Define(name, value)
+	}
+
+	i.globals = global
+	i.environment = global
+	i.history = make([]HistoryEntry, 0)
+}
+
+// History returns the recorded statements, oldest first. It's nil unless the history is
+// enabled. Once the history is full, the entries are copied out of the ring they are kept
+// in.
+func (i *Interpreter) History() []HistoryEntry {
+	if i.historyHead == 0 {
+		return i.history
+	}
+
+	history := make([]HistoryEntry, 0, len(i.history))
+	history = append(history, i.history[i.historyHead:]...)
+	return append(history, i.history[:i.historyHead]...)
+}
+
+// record adds the statement to the history, if it's enabled. Once the history holds
+// historyLimit entries, it's a ring: the new entry takes the place of the oldest one at
+// the head, which moves on to the next oldest.
+func (i *Interpreter) record(stmt ast.Stmt) {
+	if i.history == nil {
+		return
+	}
+
+	entry := HistoryEntry{Statement: stmt, Environment: i.environment.Snapshot()}
+	if len(i.history) < historyLimit {
+		i.history = append(i.history, entry)
+		return
+	}
+
+	i.history[i.historyHead] = entry
+	i.historyHead = (i.historyHead + 1) % historyLimit
+}
+
+// forgetHistory drops the recorded statements, e.g. the ones of the prelude. The globals
+// as they are now become the baseline that listings of the history leave out.
+func (i *Interpreter) forgetHistory() {
+	if i.history != nil {
+		i.history = i.history[:0]
+		i.historyHead = 0
+		i.historyBaseline = i.globals.Snapshot()
+	}
+}
+
+// changedSince returns the names of the environment that are not defined in the baseline,
+// or hold a different value than they do there.
+func changedSince(env *Environment, baseline *Environment) []string {
+	names := make([]string, 0)
+	for _, name := range env.Names() {
+		symbol := ast.Intern(name)
+		value, _ := env.lookup(symbol)
+		if baseline != nil {
+			if old, ok := baseline.lookup(symbol); ok && identical(old, value) {
+				continue
+			}
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// identical reports if two values are the same lox value. Unlike ==, it doesn't panic for
+// values whose type is not comparable, those are only identical to themselves if they are
+// the same reference.
+func identical(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+
+	if !reflect.TypeOf(a).Comparable() {
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+
+	return a == b
+}
+
+// RestoreHistory steps back to the entry of the history: the globals get the values they
+// had right after its statement ran, and the later entries are dropped. It's meant to be
+// used between top level statements, when no function is running.
+func (i *Interpreter) RestoreHistory(index int) bool {
+	if index < 0 || index >= len(i.history) {
+		return false
+	}
+
+	history := i.History()
+	global := history[index].Environment
+	for global.enclosing != nil {
+		global = global.enclosing
+	}
+
+	i.globals.restore(global)
+	i.history = history[:index+1]
+	i.historyHead = 0
+	return true
+}
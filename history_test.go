@@ -0,0 +1,87 @@
+package glox
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// historyValue returns the value of x right after the statement of the history entry.
+func historyValue(t *testing.T, entry HistoryEntry) interface{} {
+	t.Helper()
+
+	value, ok := entry.Environment.lookup(ast.Intern("x"))
+	if !ok {
+		t.Fatalf("x isn't defined in the history entry")
+	}
+
+	return value
+}
+
+// executeWithHistory runs the source on the interpreter, which records it in its history.
+func executeWithHistory(t *testing.T, interpreter *Interpreter, source string) {
+	t.Helper()
+
+	tokens, diagnostics := ScanSource(source)
+	statements, parseDiagnostics := ParseTokens(tokens)
+	if diagnostics = append(diagnostics, parseDiagnostics...); len(diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+
+	for _, stmt := range statements {
+		if err := interpreter.execute(stmt); err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+}
+
+func TestHistoryKeepsTheLatestStatements(t *testing.T) {
+	interpreter := NewInterpreter(nil)
+	interpreter.EnableHistory()
+
+	const statements = historyLimit + 25
+	var source strings.Builder
+	source.WriteString("var x = 1;\n")
+	for n := 2; n <= statements; n++ {
+		fmt.Fprintf(&source, "x = %d;\n", n)
+	}
+
+	executeWithHistory(t, interpreter, source.String())
+
+	history := interpreter.History()
+	if len(history) != historyLimit {
+		t.Fatalf("expected %d entries, got %d", historyLimit, len(history))
+	}
+
+	for n, entry := range history {
+		if expected := float64(statements - historyLimit + 1 + n); historyValue(t, entry) != expected {
+			t.Fatalf("entry %d: expected x to be %v, got %v", n, expected, historyValue(t, entry))
+		}
+	}
+
+	// Going back keeps the entries up to the one restored, in order, and new statements
+	// are recorded after it.
+	if !interpreter.RestoreHistory(99) {
+		t.Fatalf("restoring entry 99 failed")
+	}
+
+	if x, _ := interpreter.Global("x"); x != float64(statements-historyLimit+100) {
+		t.Errorf("expected x to be restored to %d, got %v", statements-historyLimit+100, x)
+	}
+
+	executeWithHistory(t, interpreter, "x = -1;")
+	history = interpreter.History()
+	if len(history) != 101 {
+		t.Fatalf("expected 101 entries after going back, got %d", len(history))
+	}
+
+	if historyValue(t, history[99]) != float64(statements-historyLimit+100) || historyValue(t, history[100]) != float64(-1) {
+		t.Errorf("unexpected entries after going back: x was %v, then %v", historyValue(t, history[99]), historyValue(t, history[100]))
+	}
+
+	if interpreter.RestoreHistory(101) || interpreter.RestoreHistory(-1) {
+		t.Errorf("restored an entry out of the history")
+	}
+}
@@ -1,34 +1,177 @@
 package glox
 
 import (
+	"bufio"
+	"context"
 	"fmt"
-
-	"github.com/iamsayantan/glox/tools"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iamsayantan/glox/ast"
 )
 
 type Interpreter struct {
 	runtime     *Runtime
 	globals     *Environment
 	environment *Environment
-	locals      map[Expr]int
+	locals      Locals
+
+	// frames is the lox call stack, the innermost call is the last element.
+	frames []CallFrame
+
+	// profiler collects per function statistics, it's nil unless stats are enabled.
+	profiler *profiler
+
+	// debugResolver cross validates every resolved variable against a dynamic lookup.
+	debugResolver bool
+
+	// eventHandlers receive the events emitted while interpreting, in order.
+	eventHandlers []EventHandler
+
+	// out is where print statements write to.
+	out io.Writer
+
+	// dynamicLookup looks variables up through the environment chain instead of using
+	// the resolved distances, for evaluating expressions that were never resolved.
+	dynamicLookup bool
+
+	// history records every executed statement with a snapshot of its environment, it's
+	// nil unless the history is enabled.
+	history []HistoryEntry
+
+	// historyHead is the index of the oldest entry of the history once it's full, and 0
+	// before.
+	historyHead int
+
+	// historyBaseline are the globals defined before the history started, like the
+	// native functions.
+	historyBaseline *Environment
+
+	// bigNumbers makes every number literal a big number.
+	bigNumbers bool
+
+	// bigLiterals caches the number literals parsed as big numbers, in big number mode or
+	// because of their n suffix.
+	bigLiterals map[*ast.Literal]*big.Float
+
+	// recorder records or replays the results of the nondeterministic native functions,
+	// it's nil unless a run is recorded or replayed.
+	recorder *recorder
+
+	// in reads the standard input for the input native function and the debugger prompt.
+	in *bufio.Reader
+
+	// breakpoints are the breakpoints by line, it's nil unless one was set.
+	breakpoints map[int]*breakpoint
+
+	// breakHandler is called when a breakpoint stops execution.
+	breakHandler BreakHandler
+
+	// sourceMap has the synthetic statements the parser created while desugaring, which
+	// breakpoints don't stop at. It's nil until code is compiled for the interpreter.
+	sourceMap ast.SourceMap
+
+	// envBaseline are the globals left out when printing the environment of a runtime
+	// error, it's nil unless environment debugging is enabled.
+	envBaseline *Environment
+
+	// usage counts the resources used by the current or the last run.
+	usage RunStats
+
+	// maxCallDepth is the deepest the lox call stack can get, 0 for no limit.
+	maxCallDepth int
+
+	// strictLogical makes 'and' and 'or' require boolean operands.
+	strictLogical bool
+
+	// stringOperators lets + concatenate strings with numbers and * repeat strings.
+	stringOperators bool
+
+	// ctx is the context of the call from Go that is running, it's nil unless a call is
+	// running.
+	ctx context.Context
+
+	// base are the globals Reset restores, shared by the interpreters of a pool. It's nil
+	// unless the interpreter comes from a Pool.
+	base *Environment
+}
+
+// CallFrame is an entry in the lox call stack. It records the callable being called and
+// the line of the call expression that called it.
+type CallFrame struct {
+	Function string
+	Line     int
+
+	// callerEnv is the environment of the caller at the time of the call.
+	callerEnv *Environment
 }
 
 func NewInterpreter(runtime *Runtime) *Interpreter {
 	global := NewEnvironment(nil)
-	global.Define("clock", Clock{})
-	return &Interpreter{runtime: runtime, environment: global, globals: global, locals: make(map[Expr]int)}
+	for _, native := range natives() {
+		global.Define(native.Name(), native)
+	}
+
+	return newInterpreter(runtime, global)
+}
+
+// newInterpreter creates an interpreter with the globals, which it runs in. Every
+// interpreter is created here and then set up with applyOptions, so none of them misses
+// a setting.
+func newInterpreter(runtime *Runtime, globals *Environment) *Interpreter {
+	return &Interpreter{runtime: runtime, environment: globals, globals: globals, locals: make(Locals), out: os.Stdout, maxCallDepth: defaultMaxCallDepth, stringOperators: true}
+}
+
+// applyOptions applies the options that change how the interpreter runs a script. The
+// options of the runtime, like recording or watching variables, are left to it.
+func (i *Interpreter) applyOptions(options Options) {
+	i.SetMaxCallDepth(options.MaxCallDepth)
+	if options.DebugResolver {
+		i.EnableResolverDebugging()
+	}
+
+	if options.BigNumbers {
+		i.EnableBigNumbers()
+	}
+
+	if options.StrictLogical {
+		i.EnableStrictLogical()
+	}
+
+	i.SetStringOperators(options.StringOperators)
 }
 
+// defaultMaxCallDepth is deep enough for any reasonable recursion, and shallow enough that
+// the Go stack doesn't overflow first, which would crash the whole program.
+const defaultMaxCallDepth = 100000
+
 type RuntimeError struct {
-	token   Token
+	token   ast.Token
 	message string
+
+	// environment is the scope the error was raised in, it's only kept when environment
+	// debugging is enabled.
+	environment *Environment
+
+	// fatal errors, like a stack overflow or an interrupted call, can't be caught by a
+	// try statement.
+	fatal bool
 }
 
 func (r *RuntimeError) Error() string {
 	return r.message
 }
 
-func NewRuntimeError(token Token, message string) error {
+// Code returns the diagnostic code of the error, see glox explain.
+func (r *RuntimeError) Code() string {
+	return diagnosticCode(phaseRuntime, r.message)
+}
+
+func NewRuntimeError(token ast.Token, message string) error {
 	return &RuntimeError{token: token, message: message}
 }
 
@@ -44,10 +187,14 @@ func NewReturn(value interface{}) *ReturnErr {
 	return &ReturnErr{Value: value}
 }
 
-func (i *Interpreter) Interpret(statements []Stmt) {
+func (i *Interpreter) Interpret(statements []ast.Stmt) {
 	for _, stmt := range statements {
 		err := i.execute(stmt)
 		if err != nil {
+			err = i.uncaught(err)
+			if runErr, ok := err.(*RuntimeError); ok {
+				i.emit(ErrorRaised{Message: runErr.message, Line: runErr.token.Line})
+			}
 
 			i.runtime.runtimeError(err)
 			return
@@ -55,16 +202,29 @@ func (i *Interpreter) Interpret(statements []Stmt) {
 	}
 }
 
-func (i *Interpreter) execute(stmt Stmt) error {
+func (i *Interpreter) execute(stmt ast.Stmt) error {
+	i.usage.Nodes++
+	if i.breakpoints != nil {
+		i.checkBreakpoint(stmt)
+	}
+
 	err := stmt.Accept(i)
 	if err != nil {
+		i.keepEnvironment(err)
 		return err
 	}
 
+	i.record(stmt)
 	return nil
 }
 
-func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) error {
+// VisitBadStmt refuses to run a statement that failed to parse. The parser reports an error
+// for every BadStmt, so they never reach the interpreter unless the errors were ignored.
+func (i *Interpreter) VisitBadStmt(stmt *ast.BadStmt) error {
+	return NewRuntimeError(stmt.At, "Can't run a statement that failed to parse.")
+}
+
+func (i *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) error {
 	var superclass interface{}
 	var err error
 	if stmt.Superclass != nil {
@@ -74,18 +234,18 @@ func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) error {
 		}
 
 		if _, ok := superclass.(*LoxClass); !ok {
-			i.runtime.runtimeError(NewRuntimeError(stmt.Superclass.Name, "Superclass must be a class"))
+			return NewRuntimeError(stmt.Superclass.Name, "Superclass must be a class")
 		}
 	}
 
-	i.environment.Define(stmt.Name.Lexeme, nil)
+	i.environment.DefineSymbol(stmt.Name.Symbol(), nil)
 
 	// When we are evaluating a subclass definition, we create a new environment for the superclass.
 	// Now when we are creating the LoxFunction instance for the methods, those will capture the current
 	// environment where the super is defined.Once that is done, the environment is popped.
 	if stmt.Superclass != nil {
 		env := NewEnvironment(i.environment)
-		env.Define("super", superclass)
+		env.DefineSymbol(ast.SymbolSuper, superclass)
 		i.environment = env
 	}
 
@@ -103,11 +263,12 @@ func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) error {
 	}
 
 	i.environment.Assign(stmt.Name, klass)
+	i.emit(VariableDefined{Name: stmt.Name.Lexeme, Value: klass, Global: i.environment == i.globals})
 
 	return nil
 }
 
-func (i *Interpreter) VisitGetExpr(expr *GetExpr) (interface{}, error) {
+func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
 	object, err := i.evaluate(expr.Object)
 	if err != nil {
 		return nil, err
@@ -117,10 +278,24 @@ func (i *Interpreter) VisitGetExpr(expr *GetExpr) (interface{}, error) {
 		return loxInstance.Get(expr.Name)
 	}
 
+	if callable, ok := object.(LoxCallable); ok {
+		return callableProperty(callable, expr.Name)
+	}
+
+	if holder, ok := object.(propertyHolder); ok {
+		return holder.property(expr.Name)
+	}
+
 	return nil, NewRuntimeError(expr.Name, "Only instances have properties")
 }
 
-func (i *Interpreter) VisitSetExpr(expr *SetExpr) (interface{}, error) {
+// propertyHolder is implemented by the values besides instances that have properties, like
+// ranges and channels. Their properties can't be set.
+type propertyHolder interface {
+	property(name ast.Token) (interface{}, error)
+}
+
+func (i *Interpreter) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
 	object, err := i.evaluate(expr.Object)
 	if err != nil {
 		return nil, err
@@ -140,37 +315,69 @@ func (i *Interpreter) VisitSetExpr(expr *SetExpr) (interface{}, error) {
 	return value, nil
 }
 
-func (i *Interpreter) VisitSuperExpr(expr *SuperExpr) (interface{}, error) {
-	distance, ok := i.locals[expr]
-	if !ok {
-		return nil, NewRuntimeError(expr.Keyword, "invalid code")
+func (i *Interpreter) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	superValue, thisValue, err := i.lookupSuper(expr)
+	if err != nil {
+		return nil, err
 	}
 
-	superclass, ok := i.environment.GetAt(distance, "super").(*LoxClass)
+	superclass, ok := superValue.(*LoxClass)
 	if !ok {
 		return nil, NewRuntimeError(expr.Keyword, "invalid code")
 	}
 
-	// The environment where "this" is bound, is always right inside the environment where
-	// we store "super". So offsetting distance by one looks up "this" in an inner environment.
-	object, ok := i.environment.GetAt(distance-1, "this").(*LoxInstance)
+	object, ok := thisValue.(*LoxInstance)
 	if !ok {
 		return nil, NewRuntimeError(expr.Keyword, "invalid code")
 	}
 
 	method, err := superclass.findMethod(expr.Method.Lexeme)
 	if err != nil {
-		return nil, NewRuntimeError(expr.Method, "Undefined property '"+expr.Keyword.Lexeme+"'")
+		return nil, NewRuntimeError(expr.Method, undefinedMessage("property", expr.Method.Lexeme, superclass.methodNames()))
 	}
 
 	return method.Bind(object), nil
 }
 
-func (i *Interpreter) VisitBlockStmt(stmt *Block) error {
+// lookupSuper returns the values of "super" and "this" for a super expression. An expression
+// evaluated in a frame was never resolved, its "super" and "this" are the ones the
+// environment chain of the frame, which is the one of the method's closure, finds first.
+func (i *Interpreter) lookupSuper(expr *ast.SuperExpr) (interface{}, interface{}, error) {
+	if i.dynamicLookup {
+		superValue, err := i.environment.Get(ast.Token{Type: ast.Super, Lexeme: "super", Line: expr.Keyword.Line})
+		if err != nil {
+			return nil, nil, NewRuntimeError(expr.Keyword, "Can't use 'super' outside of a method of a subclass.")
+		}
+
+		thisValue, err := i.environment.Get(ast.Token{Type: ast.This, Lexeme: "this", Line: expr.Keyword.Line})
+		return superValue, thisValue, err
+	}
+
+	distance, ok := i.locals[expr]
+	if !ok {
+		return nil, nil, NewRuntimeError(expr.Keyword, "invalid code")
+	}
+
+	superValue, err := i.environment.GetAt(distance, ast.SymbolSuper)
+	if err != nil {
+		return nil, nil, NewRuntimeError(expr.Keyword, err.Error())
+	}
+
+	// The environment where "this" is bound, is always right inside the environment where
+	// we store "super". So offsetting distance by one looks up "this" in an inner environment.
+	thisValue, err := i.environment.GetAt(distance-1, ast.SymbolThis)
+	if err != nil {
+		return nil, nil, NewRuntimeError(expr.Keyword, err.Error())
+	}
+
+	return superValue, thisValue, nil
+}
+
+func (i *Interpreter) VisitBlockStmt(stmt *ast.Block) error {
 	return i.executeBlock(stmt.Statements, NewEnvironment(i.environment))
 }
 
-func (i *Interpreter) executeBlock(statements []Stmt, env *Environment) error {
+func (i *Interpreter) executeBlock(statements []ast.Stmt, env *Environment) error {
 	previousEnv := i.environment
 
 	i.environment = env
@@ -191,7 +398,7 @@ func (i *Interpreter) executeBlock(statements []Stmt, env *Environment) error {
 // nil value for it. Thus it allows us to define an uninitialized variable.
 // Like other dynamically typed languages, we just assign nil if the variable
 // is not initialized.
-func (i *Interpreter) VisitVarStmt(expr *VarStmt) error {
+func (i *Interpreter) VisitVarStmt(expr *ast.VarStmt) error {
 	var val interface{}
 	var err error
 	if expr.Initializer != nil {
@@ -201,12 +408,16 @@ func (i *Interpreter) VisitVarStmt(expr *VarStmt) error {
 		}
 	}
 
-	i.environment.Define(expr.Name.Lexeme, val)
+	i.define(expr.Name.Lexeme, val)
 	return nil
 }
 
-func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) error {
+func (i *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) error {
 	for {
+		if err := i.interrupted(stmt.Keyword); err != nil {
+			return err
+		}
+
 		condition, err := i.evaluate(stmt.Condition)
 		if err != nil {
 			return err
@@ -225,7 +436,43 @@ func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) error {
 	return nil
 }
 
-func (i *Interpreter) VisitVarExpr(expr *VarExpr) (interface{}, error) {
+// VisitForInStmt runs the body once for every element of the collection. Every iteration
+// gets a new environment with the loop variable, so closures created in the body capture
+// the element of their own iteration.
+func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) error {
+	collection, err := i.evaluate(stmt.Collection)
+	if err != nil {
+		return err
+	}
+
+	iterator, ok := iterate(collection)
+	if !ok {
+		return NewRuntimeError(stmt.Keyword, "Can only iterate over strings and collections")
+	}
+
+	for {
+		if err := i.interrupted(stmt.Keyword); err != nil {
+			return err
+		}
+
+		element, ok := iterator.Next()
+		if !ok {
+			if nativeErr, ok := iteratorErr(iterator).(*nativeError); ok {
+				return NewRuntimeError(stmt.Keyword, nativeErr.message)
+			}
+
+			return iteratorErr(iterator)
+		}
+
+		env := NewEnvironment(i.environment)
+		env.DefineSymbol(stmt.Name.Symbol(), element)
+		if err := i.executeBlock([]ast.Stmt{stmt.Body}, env); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *Interpreter) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
 	return i.lookupVariable(expr.Name, expr)
 }
 
@@ -235,15 +482,26 @@ func (i *Interpreter) VisitVarExpr(expr *VarExpr) (interface{}, error) {
 // expression and can be nested inside other expression.
 // var a = 1;
 // print a = 2; // "2"
-func (i *Interpreter) VisitAssignExpr(expr *Assign) (interface{}, error) {
+func (i *Interpreter) VisitAssignExpr(expr *ast.Assign) (interface{}, error) {
 	val, err := i.evaluate(expr.Value)
 	if err != nil {
 		return nil, err
 	}
 
 	distance, ok := i.locals[expr]
-	if ok {
-		i.environment.AssignAt(distance, expr.Name, val)
+	if i.dynamicLookup {
+		err = i.environment.Assign(expr.Name, val)
+		if err != nil {
+			return nil, err
+		}
+	} else if ok {
+		if err := i.checkResolution(expr.Name, distance); err != nil {
+			return nil, err
+		}
+
+		if err := i.environment.AssignAt(distance, expr.Name, val); err != nil {
+			return nil, NewRuntimeError(expr.Name, err.Error())
+		}
 	} else {
 		err = i.environment.Assign(expr.Name, val)
 		if err != nil {
@@ -257,7 +515,7 @@ func (i *Interpreter) VisitAssignExpr(expr *Assign) (interface{}, error) {
 // VisitExpressionExpr interprets expression statements. As statements do not
 // produce any value, we are discarding the expression generated from evaluating
 // the statement's expression.
-func (i *Interpreter) VisitExpressionExpr(expr *Expression) error {
+func (i *Interpreter) VisitExpressionExpr(expr *ast.Expression) error {
 	_, err := i.evaluate(expr.Expression)
 	if err != nil {
 		return err
@@ -270,13 +528,21 @@ func (i *Interpreter) VisitExpressionExpr(expr *Expression) error {
 // and we look at its value to check if we can short circuit. If not and only then we evaluate
 // the right operand.
 // Another interesting thing is we are returning the value with appropriate truthiness.
-func (i *Interpreter) VisitLogicalExpr(expr *Logical) (interface{}, error) {
+// VisitLogicalExpr short-circuits: the right operand is only evaluated when the left one
+// doesn't decide the result. 'or' evaluates to the left operand if it's truthy, 'and' if
+// it's falsey, and both to the right operand otherwise, so nil or "default" is "default".
+// With strict logical operators both operands must be booleans, so the result is one too.
+func (i *Interpreter) VisitLogicalExpr(expr *ast.Logical) (interface{}, error) {
 	left, err := i.evaluate(expr.Left)
 	if err != nil {
 		return nil, err
 	}
 
-	if expr.Operator.Type == Or {
+	if err := i.checkLogicalOperand(expr.Operator, left); err != nil {
+		return nil, err
+	}
+
+	if expr.Operator.Type == ast.Or {
 		if i.isTruthy(left) {
 			return left, nil
 		}
@@ -286,10 +552,29 @@ func (i *Interpreter) VisitLogicalExpr(expr *Logical) (interface{}, error) {
 		}
 	}
 
-	return i.evaluate(expr.Right)
+	right, err := i.evaluate(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.checkLogicalOperand(expr.Operator, right); err != nil {
+		return nil, err
+	}
+
+	return right, nil
 }
 
-func (i *Interpreter) VisitIfStmt(stmt *IfStmt) error {
+// checkLogicalOperand checks an operand of 'and' or 'or' is a boolean when the logical
+// operators are strict.
+func (i *Interpreter) checkLogicalOperand(operator ast.Token, operand interface{}) error {
+	if _, ok := operand.(bool); i.strictLogical && !ok {
+		return NewRuntimeError(operator, "Operands of '"+operator.Lexeme+"' must be booleans")
+	}
+
+	return nil
+}
+
+func (i *Interpreter) VisitIfStmt(stmt *ast.IfStmt) error {
 	condition, err := i.evaluate(stmt.Condition)
 	if err != nil {
 		return err
@@ -310,24 +595,25 @@ func (i *Interpreter) VisitIfStmt(stmt *IfStmt) error {
 	return nil
 }
 
-func (i *Interpreter) VisitPrintExpr(expr *Print) error {
+func (i *Interpreter) VisitPrintExpr(expr *ast.Print) error {
 	val, err := i.evaluate(expr.Expression)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(i.stringify(val))
+	fmt.Fprintln(i.out, i.stringify(val))
+	i.flushOutput()
 	return nil
 }
 
-func (i *Interpreter) VisitReturnStmt(stmt *ReturnStmt) error {
+func (i *Interpreter) VisitReturnStmt(stmt *ast.ReturnStmt) error {
 	var value interface{}
 	var err error
 
 	if stmt.Value != nil {
 		value, err = i.evaluate(stmt.Value)
 		if err != nil {
-			return nil
+			return err
 		}
 	}
 
@@ -339,14 +625,30 @@ func (i *Interpreter) stringify(val interface{}) string {
 		return "nil"
 	}
 
-	if tools.IsFloat64(val) {
-		return fmt.Sprintf("%d", int(val.(float64)))
+	if number, ok := val.(float64); ok {
+		return formatNumber(number)
+	}
+
+	if number, ok := val.(*big.Float); ok {
+		return formatBigFloat(number)
+	}
+
+	if array, ok := val.(*LoxArray); ok {
+		return i.stringifyArray(array, make(map[interface{}]bool))
+	}
+
+	if m, ok := val.(*LoxMap); ok {
+		return i.stringifyMap(m, make(map[interface{}]bool))
+	}
+
+	if tuple, ok := val.(*LoxTuple); ok {
+		return i.stringifyTuple(tuple, make(map[interface{}]bool))
 	}
 
 	return fmt.Sprint(val)
 }
 
-func (i *Interpreter) VisitBinaryExpr(expr *Binary) (interface{}, error) {
+func (i *Interpreter) VisitBinaryExpr(expr *ast.Binary) (interface{}, error) {
 	left, err := i.evaluate(expr.Left)
 	if err != nil {
 		return nil, err
@@ -357,75 +659,185 @@ func (i *Interpreter) VisitBinaryExpr(expr *Binary) (interface{}, error) {
 		return nil, err
 	}
 
-	switch expr.Operator.Type {
-	case Greater:
-		err := i.checkNumberOperandBoth(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+	return i.binary(expr.Operator, left, right)
+}
+
+// binary applies the binary operator to the values of its operands.
+func (i *Interpreter) binary(operator ast.Token, left, right interface{}) (interface{}, error) {
+	// The comma operator only sequences its operands, whatever their types.
+	if operator.Type == ast.Comma {
+		return right, nil
+	}
+
+	if operator.Type == ast.In {
+		return i.contains(operator, right, left)
+	}
+
+	// Most operations are on two numbers, they are checked once up front.
+	if x, ok := left.(float64); ok {
+		if y, ok := right.(float64); ok {
+			return numberBinary(operator, x, y), nil
 		}
+	}
 
-		return left.(float64) > right.(float64), nil
-	case GreaterEqual:
-		err := i.checkNumberOperandBoth(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+	if x, y, ok := bigOperands(left, right); ok {
+		return i.bigBinary(operator, x, y)
+	}
+
+	switch operator.Type {
+	case ast.BangEqual:
+		equal, err := i.valuesEqual(operator, left, right)
+		return !equal, err
+	case ast.EqualEqual:
+		return i.valuesEqual(operator, left, right)
+	case ast.Plus:
+		// plus (+) handles both string concatenation and arithmetic addition. A number added
+		// to a string is stringified and concatenated, "count: " + 3 is "count: 3", where
+		// it used to be a runtime error. Other values still are an error, so a nil that slipped
+		// into a concatenation isn't printed silently.
+		x, leftArray := left.(*LoxArray)
+		y, rightArray := right.(*LoxArray)
+		if leftArray && rightArray {
+			return x.concat(y), nil
 		}
 
-		return left.(float64) >= right.(float64), nil
-	case Less:
-		err := i.checkNumberOperandBoth(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+		if leftArray || rightArray {
+			return nil, NewRuntimeError(operator, "Operands must both be arrays to concatenate an array")
 		}
 
-		return left.(float64) < right.(float64), nil
-	case LessEqual:
-		err := i.checkNumberOperandBoth(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+		a, leftString := left.(string)
+		b, rightString := right.(string)
+		if leftString && rightString {
+			return a + b, nil
 		}
 
-		return left.(float64) <= right.(float64), nil
-	case BangEqual:
-		return !(left == right), nil
-	case EqualEqual:
-		return left == right, nil
-	case Minus:
-		err := i.checkNumberOperandBoth(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+		if i.stringOperators && (leftString && isNumber(right) || rightString && isNumber(left)) {
+			return i.stringify(left) + i.stringify(right), nil
+		}
+
+		return nil, NewRuntimeError(operator, "The both operands must be either string or number")
+	case ast.Star:
+		// A string multiplied by a number, on either side, is repeated that many times,
+		// "ab" * 3 is "ababab".
+		if !i.stringOperators {
+			break
 		}
 
-		return left.(float64) - right.(float64), nil
-	case Plus:
-		// plus (+) handles both string concatenation and arithmetic addition.
-		if tools.IsString(left) && tools.IsString(right) {
-			return left.(string) + right.(string), nil
+		if s, ok := left.(string); ok {
+			return repeatString(operator, s, right)
 		}
 
-		if tools.IsFloat64(left) && tools.IsFloat64(right) {
-			return left.(float64) + right.(float64), nil
+		if s, ok := right.(string); ok {
+			return repeatString(operator, s, left)
 		}
+	}
+
+	return nil, NewRuntimeError(operator, "Both operands must be numbers")
+}
+
+// maxRepeatLength is the length in bytes of the longest string a repetition may produce, so
+// a huge count is a runtime error instead of running out of memory.
+const maxRepeatLength = 1 << 26
+
+// repeatString repeats the string count times, the count has to be a whole number that
+// isn't negative.
+func repeatString(operator ast.Token, s string, count interface{}) (interface{}, error) {
+	n, ok := toFloat64(count)
+	if !ok {
+		return nil, NewRuntimeError(operator, "Both operands must be numbers")
+	}
+
+	if n < 0 || n != math.Trunc(n) {
+		return nil, NewRuntimeError(operator, "String repetition count must be a whole number that isn't negative")
+	}
+
+	if n > maxRepeatLength || n*float64(len(s)) > maxRepeatLength {
+		return nil, NewRuntimeError(operator, fmt.Sprintf("String repetition would be longer than %d bytes", maxRepeatLength))
+	}
+
+	return strings.Repeat(s, int(n)), nil
+}
+
+// numberBinary applies the binary operator to two numbers.
+func numberBinary(operator ast.Token, x, y float64) interface{} {
+	switch operator.Type {
+	case ast.Greater:
+		return x > y
+	case ast.GreaterEqual:
+		return x >= y
+	case ast.Less:
+		return x < y
+	case ast.LessEqual:
+		return x <= y
+	case ast.BangEqual:
+		return x != y
+	case ast.EqualEqual:
+		return x == y
+	case ast.Minus:
+		return x - y
+	case ast.Plus:
+		return x + y
+	case ast.Slash:
+		return x / y
+	case ast.Star:
+		return x * y
+	}
+
+	// unreachable
+	return nil
+}
 
-		return nil, NewRuntimeError(expr.Operator, "The both operands must be either string or number")
-	case Slash:
-		err := i.checkNumberOperandBoth(expr.Operator, left, right)
+// VisitComparisonExpr evaluates a chain of comparisons, a < b < c is a < b and b < c with b
+// evaluated only once. Like 'and', it stops at the first comparison that is false.
+func (i *Interpreter) VisitComparisonExpr(expr *ast.Comparison) (interface{}, error) {
+	left, err := i.evaluate(expr.Operands[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for n, operator := range expr.Operators {
+		right, err := i.evaluate(expr.Operands[n+1])
 		if err != nil {
 			return nil, err
 		}
 
-		return left.(float64) / right.(float64), nil
-	case Star:
-		err := i.checkNumberOperandBoth(expr.Operator, left, right)
+		result, err := i.binary(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 
-		return left.(float64) * right.(float64), nil
+		if result != true {
+			return false, nil
+		}
+
+		left = right
 	}
 
-	// unreachable
-	return nil, nil
+	return true, nil
+}
+
+func (i *Interpreter) VisitRangeExpr(expr *ast.Range) (interface{}, error) {
+	start, err := i.evaluate(expr.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := i.evaluate(expr.End)
+	if err != nil {
+		return nil, err
+	}
+
+	from, ok := toFloat64(start)
+	if !ok {
+		return nil, NewRuntimeError(expr.Operator, "Range bounds must be numbers")
+	}
+
+	to, ok := toFloat64(end)
+	if !ok {
+		return nil, NewRuntimeError(expr.Operator, "Range bounds must be numbers")
+	}
+
+	return LoxRange{Start: from, End: to, Inclusive: expr.Inclusive()}, nil
 }
 
 // VisitCallExpr interprts function call tree node. First we evaluate the expression for the
@@ -434,7 +846,7 @@ func (i *Interpreter) VisitBinaryExpr(expr *Binary) (interface{}, error) {
 // them in a list. To call a function we cast the callee to the LoxCallable interface and call
 // the Call() method on it. The go representation of any lox object that can be called like an
 // function will implement this interface.
-func (i *Interpreter) VisitCallExpr(expr *Call) (interface{}, error) {
+func (i *Interpreter) VisitCallExpr(expr *ast.Call) (interface{}, error) {
 	callee, err := i.evaluate(expr.Callee)
 	if err != nil {
 		return nil, err
@@ -455,11 +867,68 @@ func (i *Interpreter) VisitCallExpr(expr *Call) (interface{}, error) {
 		return nil, NewRuntimeError(expr.Paren, "Can only call function and classes")
 	}
 
-	if len(arguments) != function.Arity() {
-		return nil, NewRuntimeError(expr.Paren, fmt.Sprintf("Expected %d arguments but got %d", function.Arity(), len(arguments)))
+	if len(expr.KeywordArguments) > 0 {
+		values := make([]interface{}, 0, len(expr.KeywordArguments))
+		for _, argument := range expr.KeywordArguments {
+			value, err := i.evaluate(argument.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		arguments, err = bindKeywordArguments(function, arguments, expr.KeywordArguments, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if message, ok := checkArity(function, len(arguments)); !ok {
+		return nil, NewRuntimeError(expr.Paren, message)
 	}
 
-	return function.Call(i, arguments)
+	if i.maxCallDepth > 0 && len(i.frames) >= i.maxCallDepth {
+		return nil, &RuntimeError{token: expr.Paren, message: "Stack overflow", fatal: true}
+	}
+
+	if err := i.interrupted(expr.Paren); err != nil {
+		return nil, err
+	}
+
+	loxFunction, profiled := function.(LoxFunction)
+	profiled = profiled && i.profiler != nil
+	if profiled {
+		i.profiler.enter(loxFunction)
+	}
+
+	i.frames = append(i.frames, CallFrame{Function: function.Name(), Line: expr.Paren.Line, callerEnv: i.environment})
+	i.usage.Calls++
+	if len(i.frames) > i.usage.MaxDepth {
+		i.usage.MaxDepth = len(i.frames)
+	}
+
+	i.emit(FunctionEntered{Function: function.Name(), Line: expr.Paren.Line, Depth: len(i.frames), Time: time.Now()})
+
+	value, err := function.Call(i, arguments)
+
+	i.emit(FunctionExited{Function: function.Name(), Line: expr.Paren.Line, Depth: len(i.frames), Time: time.Now(), Err: err})
+	i.frames = i.frames[:len(i.frames)-1]
+
+	if profiled {
+		i.profiler.exit(loxFunction)
+	}
+
+	if nativeErr, ok := err.(*nativeError); ok {
+		return nil, NewRuntimeError(expr.Paren, nativeErr.message)
+	}
+
+	// Natives that notice an interruption don't know where they were called from.
+	if runErr, ok := err.(*RuntimeError); ok && runErr.token.Line == 0 {
+		runErr.token = expr.Paren
+	}
+
+	return value, err
 }
 
 // VisitFunctionStmt interprets a function syntax node. We take FunctionStmt syntax node, which
@@ -467,31 +936,35 @@ func (i *Interpreter) VisitCallExpr(expr *Call) (interface{}, error) {
 // Here that's LoxFunction that wraps the syntax node. Here we also bind the resulting object to
 // a new variable. So after creating LoxFunction, we create a new binding in the current environment
 // and store a reference to it there.
-func (i *Interpreter) VisitFunctionStmt(stmt *FunctionStmt) error {
+func (i *Interpreter) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
 	// When we create the LoxFunction, we capture the current environment. This is the env that is
 	// active when the function is declared, not when it's called.
 	function := NewLoxFunction(stmt, i.environment, false)
-	i.environment.Define(stmt.Name.Lexeme, function)
+	i.define(stmt.Name.Lexeme, function)
 	return nil
 }
 
 // VisitGroupingExpr evaluates the grouping expressions, the node that we get from
 // using parenthesis around an expression. The grouping node has reference to the
 // inner expression, so to evaluate it we recursively evaluate the inner subexpression.
-func (i *Interpreter) VisitGroupingExpr(expr *Grouping) (interface{}, error) {
+func (i *Interpreter) VisitGroupingExpr(expr *ast.Grouping) (interface{}, error) {
 	return i.evaluate(expr.Expression)
 }
 
 // VisitLiteralExpr converts the literal tree node created during parsing to the
 // runtime value. Which simply pulls the literal value back from the Token created
 // during scanning.
-func (i *Interpreter) VisitLiteralExpr(expr *Literal) (interface{}, error) {
+func (i *Interpreter) VisitLiteralExpr(expr *ast.Literal) (interface{}, error) {
+	if (i.bigNumbers || expr.Big) && expr.Raw != "" {
+		return i.bigLiteral(expr)
+	}
+
 	return expr.Value, nil
 }
 
 // VisitUnaryExpr evaluates the unary tree node. Unary expression have single subexpression that
 // we need to evaluate first.
-func (i *Interpreter) VisitUnaryExpr(expr *Unary) (interface{}, error) {
+func (i *Interpreter) VisitUnaryExpr(expr *ast.Unary) (interface{}, error) {
 	// this will evaluate recursively for expressions like !!true, the right operand will be
 	// evaluated first before evaluating the operator.
 	right, err := i.evaluate(expr.Right)
@@ -500,27 +973,33 @@ func (i *Interpreter) VisitUnaryExpr(expr *Unary) (interface{}, error) {
 	}
 
 	switch expr.Operator.Type {
-	case Bang:
+	case ast.Bang:
 		return !i.isTruthy(right), nil
-	case Minus:
-		if err := i.checkNumberOperand(expr.Operator, right); err != nil {
-			return nil, err
+	case ast.Minus:
+		if number, ok := right.(*big.Float); ok {
+			return newBigFloat().Neg(number), nil
+		}
+
+		number, ok := right.(float64)
+		if !ok {
+			return nil, NewRuntimeError(expr.Operator, "Operand must me a number")
 		}
 
-		return -right.(float64), nil
+		return -number, nil
 	}
 
 	// unreachable.
 	return nil, nil
 }
 
-func (i *Interpreter) VisitThisExpr(expr *ThisExpr) (interface{}, error) {
+func (i *Interpreter) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
 	return i.lookupVariable(expr.Keyword, expr)
 }
 
 // evaluate is a helper method that sends the expression back to the interpreter's visitor
 // implementation.
-func (i *Interpreter) evaluate(expr Expr) (interface{}, error) {
+func (i *Interpreter) evaluate(expr ast.Expr) (interface{}, error) {
+	i.usage.Nodes++
 	return expr.Accept(i)
 }
 
@@ -531,42 +1010,177 @@ func (i *Interpreter) isTruthy(val interface{}) bool {
 		return false
 	}
 
-	switch val := val.(type) {
-	case bool:
-		return val
+	if boolean, ok := val.(bool); ok {
+		return boolean
 	}
 
 	return true
 }
 
-func (i *Interpreter) checkNumberOperand(operator Token, operand interface{}) error {
-	if tools.IsFloat64(operand) {
+// SetOutput sets where print statements write to, os.Stdout by default. A writer that
+// implements http.Flusher, like an http.ResponseWriter, is flushed after every print
+// statement, so the output of a long running script streams while it runs.
+func (i *Interpreter) SetOutput(w io.Writer) {
+	i.out = w
+}
+
+// flushOutput flushes the output writer if it's an http.Flusher, which asks to be flushed
+// to send output as it's written. Writers that buffer to save writes, like bufio.Writer,
+// are left for the embedder to flush, flushing them after every print would undo that.
+func (i *Interpreter) flushOutput() {
+	if out, ok := i.out.(interface{ Flush() }); ok {
+		out.Flush()
+	}
+}
+
+// SetMaxCallDepth sets the deepest the lox call stack can get before a call fails with a
+// stack overflow, 0 for no limit.
+func (i *Interpreter) SetMaxCallDepth(depth int) {
+	i.maxCallDepth = depth
+}
+
+// EnableStrictLogical makes 'and' and 'or' require booleans as operands, so they always
+// evaluate to true or false. Any other operand, like the nil of nil or "default", is a
+// runtime error.
+func (i *Interpreter) EnableStrictLogical() {
+	i.strictLogical = true
+}
+
+// SetStringOperators turns concatenating strings with numbers and repeating strings with *
+// on or off. They are on unless the language options turn them off.
+func (i *Interpreter) SetStringOperators(enabled bool) {
+	i.stringOperators = enabled
+}
+
+// EnableResolverDebugging makes the interpreter check every variable access against a dynamic
+// lookup of the variable, reporting a runtime error if the resolver got the scope wrong. The
+// environments record when their variables are defined from now on, for the lookup to skip
+// the ones declared after a closure.
+func (i *Interpreter) EnableResolverDebugging() {
+	i.debugResolver = true
+	if i.globals.clock == nil {
+		i.globals.definitions = make(map[ast.Symbol]uint64)
+		i.globals.clock = new(uint64)
+	}
+}
+
+// EnableStats starts collecting per function statistics.
+func (i *Interpreter) EnableStats() {
+	i.profiler = newProfiler()
+}
+
+// Stats returns the statistics collected for every lox function called since stats were
+// enabled, or nil if they are not enabled.
+func (i *Interpreter) Stats() []FunctionStats {
+	if i.profiler == nil {
 		return nil
 	}
 
-	return NewRuntimeError(operator, "Operand must me a number")
+	return i.profiler.Stats()
 }
 
-func (i *Interpreter) checkNumberOperandBoth(operator Token, left, right interface{}) error {
-	if tools.IsFloat64(left) && tools.IsFloat64(right) {
+// ClassStats returns the instance counts of every class instantiated since stats were
+// enabled, or nil if they are not enabled.
+func (i *Interpreter) ClassStats() []ClassStats {
+	if i.profiler == nil {
 		return nil
 	}
 
-	return NewRuntimeError(operator, "Both operands must be numbers")
+	return i.profiler.ClassStats()
+}
+
+// RunStats returns the resources used by the last run of a program, or evaluation of a
+// sandbox expression, on the interpreter.
+func (i *Interpreter) RunStats() RunStats {
+	return i.usage
+}
+
+// CallStack returns a copy of the current lox call stack, innermost call first.
+func (i *Interpreter) CallStack() []CallFrame {
+	frames := make([]CallFrame, 0, len(i.frames))
+	for f := len(i.frames) - 1; f >= 0; f-- {
+		frames = append(frames, i.frames[f])
+	}
+
+	return frames
 }
 
-func (i *Interpreter) resolve(expr Expr, depth int) {
-	i.locals[expr] = depth
+// defineGlobal defines a global provided by the embedder. It's also added to the baselines
+// of the history and environment debugging, so it's treated like the natives defined before
+// them.
+func (i *Interpreter) defineGlobal(name string, value interface{}) {
+	i.globals.Define(name, value)
+	for _, baseline := range []*Environment{i.historyBaseline, i.envBaseline} {
+		if baseline != nil {
+			baseline.Define(name, value)
+		}
+	}
+}
+
+// addLocals adds the local variables resolved by the resolver, so the interpreter can find
+// them when evaluating the expressions.
+func (i *Interpreter) addLocals(locals Locals) {
+	for expr, depth := range locals {
+		i.locals[expr] = depth
+	}
+}
+
+// addSourceMap adds the synthetic nodes of code compiled for the interpreter to the ones it
+// already knows.
+func (i *Interpreter) addSourceMap(sourceMap ast.SourceMap) {
+	if i.sourceMap == nil {
+		i.sourceMap = make(ast.SourceMap, len(sourceMap))
+	}
+
+	for node, desugaring := range sourceMap {
+		i.sourceMap[node] = desugaring
+	}
 }
 
 // lookupVariable resolves a variable. First we look up the resolved distance in the local map. Remember
 // we only resolved local variables, globals are treated differently and don't end up in the map. So, if
 // we don't find it in the local map, then it must be in the global environment.
-func (i *Interpreter) lookupVariable(name Token, expr Expr) (interface{}, error) {
+func (i *Interpreter) lookupVariable(name ast.Token, expr ast.Expr) (interface{}, error) {
+	if i.dynamicLookup {
+		return i.environment.Get(name)
+	}
+
 	distance, ok := i.locals[expr]
 	if ok {
-		return i.environment.GetAt(distance, name.Lexeme), nil
+		if err := i.checkResolution(name, distance); err != nil {
+			return nil, err
+		}
+
+		val, err := i.environment.GetAt(distance, name.Symbol())
+		if err != nil {
+			return nil, NewRuntimeError(name, err.Error())
+		}
+
+		return val, nil
 	} else {
 		return i.globals.Get(name)
 	}
 }
+
+// checkResolution cross validates the distance the resolver computed for a local variable
+// against a dynamic lookup, walking up the environment chain to the first environment that
+// defines the name. It only runs with --debug-resolver. The variable must be found, it must
+// not be a global and it must be found at the resolved distance, anything else is reported
+// as an internal error.
+func (i *Interpreter) checkResolution(name ast.Token, distance int) error {
+	if !i.debugResolver || distance == -1 {
+		return nil
+	}
+
+	found, ok := i.environment.distanceOf(name.Symbol())
+	switch {
+	case !ok:
+		return NewRuntimeError(name, fmt.Sprintf("internal error: resolver placed local '%s' at distance %d but no environment defines it", name.Lexeme, distance))
+	case found == i.environment.depth():
+		return NewRuntimeError(name, fmt.Sprintf("internal error: resolver placed local '%s' at distance %d but it's a global", name.Lexeme, distance))
+	case found != distance:
+		return NewRuntimeError(name, fmt.Sprintf("internal error: resolver placed local '%s' at distance %d but it's defined at distance %d", name.Lexeme, distance, found))
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package glox
+
+import (
+	"io"
+	"testing"
+)
+
+// benchmarkProgram compiles the source once and measures running it.
+func benchmarkProgram(b *testing.B, source string) {
+	b.Helper()
+
+	program, diagnostics := Compile(source, DefaultOptions())
+	if program == nil {
+		b.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if err := program.Run(io.Discard); err != nil {
+			b.Fatalf("run failed: %s", err.Error())
+		}
+	}
+}
+
+// BenchmarkArithmeticLoop measures the binary operators on numbers: +, -, * and /.
+func BenchmarkArithmeticLoop(b *testing.B) {
+	benchmarkProgram(b, `
+var total = 0;
+for (var i = 0; i < 10000; i = i + 1) {
+  total = total + i * 2 - i / 4;
+}
+`)
+}
+
+// BenchmarkComparisonLoop measures the comparison and equality operators on numbers.
+func BenchmarkComparisonLoop(b *testing.B) {
+	benchmarkProgram(b, `
+var count = 0;
+for (var i = 0; i < 10000; i = i + 1) {
+  if (i > 5000 and i <= 7500) count = count + 1;
+  if (i == 42 or i != i) count = count + 1;
+  if (i >= 9999) count = count + 1;
+}
+`)
+}
+
+// BenchmarkStringConcatenation measures + on strings.
+func BenchmarkStringConcatenation(b *testing.B) {
+	benchmarkProgram(b, `
+var s = "";
+for (var i = 0; i < 1000; i = i + 1) {
+  s = s + "x";
+}
+`)
+}
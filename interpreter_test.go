@@ -0,0 +1,160 @@
+package glox
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStringOperators(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+		err      string
+	}{
+		{source: `print "count: " + 3;`, expected: "count: 3\n"},
+		{source: `print 1.5 + "x";`, expected: "1.5x\n"},
+		{source: `print "ab" * 3;`, expected: "ababab\n"},
+		{source: `print 2 * "ab";`, expected: "abab\n"},
+		{source: `print "ab" * 0;`, expected: "\n"},
+		{source: `print "ab" * -1;`, err: "String repetition count must be a whole number that isn't negative"},
+		{source: `print "ab" * 1.5;`, err: "String repetition count must be a whole number that isn't negative"},
+		{source: `print "ab" * (0 / 0);`, err: "String repetition count must be a whole number that isn't negative"},
+		{source: `print "ab" * 5e18;`, err: "String repetition would be longer than"},
+		{source: `print "ab" * 1e12;`, err: "String repetition would be longer than"},
+		{source: `print "" * (1 / 0);`, err: "String repetition would be longer than"},
+		{source: `print "ab" * nil;`, err: "Both operands must be numbers"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			output, err := runSource(t, test.source, DefaultOptions())
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil || output != test.expected {
+				t.Errorf("printed %q, %v, expected %q", output, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestStringOperatorsAreOffInTheCanonicalEdition(t *testing.T) {
+	language, _ := EditionOptions(EditionCanonical)
+	options := DefaultOptions()
+	options.LanguageOptions = language
+
+	for _, source := range []string{`print "a" + 3;`, `print 3 + "a";`, `print "ab" * 3;`, `print 3 * "ab";`} {
+		if output, err := runSource(t, source, options); err == nil {
+			t.Errorf("%s: expected a runtime error, printed %q", source, output)
+		}
+	}
+
+	if output, err := runSource(t, `print "a" + "b";`, options); err != nil || output != "ab\n" {
+		t.Errorf("concatenating strings printed %q, %v", output, err)
+	}
+}
+
+func TestSandboxStringRepetitionIsBounded(t *testing.T) {
+	sandbox, err := NewSandbox(nil)
+	if err != nil {
+		t.Fatalf("creating the sandbox: %s", err.Error())
+	}
+
+	for _, source := range []string{`"ab" * 5e18`, `"ab" * 1e12`} {
+		if value, err := sandbox.Eval(source); err == nil {
+			t.Errorf("%s: expected an error, got a string of %d bytes", source, len(value.(string)))
+		}
+	}
+}
+
+// flushRecorder is a writer that asks to be flushed like an http.ResponseWriter, counting
+// the flushes.
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushes++
+}
+
+func TestPrintFlushesOnlyFlushers(t *testing.T) {
+	program, diagnostics := Compile(`print 1; print 2; print 3;`, DefaultOptions())
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	var recorder flushRecorder
+	if err := program.Run(&recorder); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if recorder.flushes != 3 || recorder.String() != "1\n2\n3\n" {
+		t.Errorf("printed %q with %d flushes, expected 3 lines and 3 flushes", recorder.String(), recorder.flushes)
+	}
+
+	var out bytes.Buffer
+	buffered := bufio.NewWriter(&out)
+	if err := program.Run(buffered); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("the bufio.Writer was flushed while the program ran, %q", out.String())
+	}
+
+	buffered.Flush()
+	if out.String() != "1\n2\n3\n" {
+		t.Errorf("printed %q, expected %q", out.String(), "1\n2\n3\n")
+	}
+}
+
+// TestNumberOperators checks the results of the operators on numbers and the errors for
+// operands of the wrong type, which every operator checks once before computing.
+func TestNumberOperators(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+		err      string
+	}{
+		{source: `print 7 - 2 * 3 / 4;`, expected: "5.5\n"},
+		{source: `print 1 + 2;`, expected: "3\n"},
+		{source: `print 1 < 2;`, expected: "true\n"},
+		{source: `print 2 <= 1;`, expected: "false\n"},
+		{source: `print 2 > 1;`, expected: "true\n"},
+		{source: `print 1 >= 1;`, expected: "true\n"},
+		{source: `print -3;`, expected: "-3\n"},
+		{source: `print -(1 - 4);`, expected: "3\n"},
+		{source: `print !nil;`, expected: "true\n"},
+		{source: `print 1 - nil;`, err: "Both operands must be numbers"},
+		{source: `print nil * 2;`, err: "Both operands must be numbers"},
+		{source: `print "a" < 1;`, err: "Both operands must be numbers"},
+		{source: `print 1 > "b";`, err: "Both operands must be numbers"},
+		{source: `print -"a";`, err: "Operand must me a number"},
+		{source: `print "a" + nil;`, err: "The both operands must be either string or number"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			output, err := runSource(t, test.source, DefaultOptions())
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil || output != test.expected {
+				t.Errorf("printed %q, %v, expected %q", output, err, test.expected)
+			}
+		})
+	}
+}
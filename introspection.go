@@ -0,0 +1,119 @@
+package glox
+
+import (
+	"sort"
+	"strings"
+)
+
+// className returns the name of the class of an instance, or of a class.
+func className(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case *LoxInstance:
+		return value.klass.name, nil
+	case *LoxClass:
+		return value.name, nil
+	}
+
+	return nil, NewNativeError("className expects an instance or a class")
+}
+
+// superclassOf returns the superclass of a class, nil if it has none.
+func superclassOf(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	class, ok := arguments[0].(*LoxClass)
+	if !ok {
+		return nil, NewNativeError("superclassOf expects a class")
+	}
+
+	if class.Superclass == nil {
+		return nil, nil
+	}
+
+	return class.Superclass, nil
+}
+
+// methodNames returns the sorted names of the methods of a class, or of the class of an
+// instance, including the inherited ones.
+func methodNames(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	class, ok := classOf(arguments[0])
+	if !ok {
+		return nil, NewNativeError("methodNames expects a class or an instance")
+	}
+
+	names := sortedMethodNames(class)
+	elements := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		elements = append(elements, name)
+	}
+
+	return NewLoxArray(elements), nil
+}
+
+// arity returns the number of arguments a function takes, the least number for functions
+// with optional arguments, and VariadicArity for the ones that take any number of them,
+// like partial and the partials of such functions.
+func arity(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	callable, ok := arguments[0].(LoxCallable)
+	if !ok {
+		return nil, NewNativeError("arity expects a function or a class")
+	}
+
+	if _, max := arityRange(callable); max == VariadicArity {
+		return float64(VariadicArity), nil
+	}
+
+	return float64(callable.Arity()), nil
+}
+
+// classOf returns the value if it's a class, or the class of an instance.
+func classOf(value interface{}) (*LoxClass, bool) {
+	switch value := value.(type) {
+	case *LoxClass:
+		return value, true
+	case *LoxInstance:
+		return value.klass, true
+	}
+
+	return nil, false
+}
+
+// sortedMethodNames returns the names of the methods of the class, including the inherited
+// ones, sorted and without the duplicates of overridden methods.
+func sortedMethodNames(class *LoxClass) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, name := range class.methodNames() {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// describe returns the documentation of a value for the prompt's :doc command: the
+// signature or the description of a function, the declaration of a class along with its
+// methods, and the type of anything else.
+func (i *Interpreter) describe(value interface{}) string {
+	var doc strings.Builder
+	if instance, ok := value.(*LoxInstance); ok {
+		doc.WriteString(instance.String() + "\n")
+	}
+
+	if class, ok := classOf(value); ok {
+		doc.WriteString(class.Doc() + "\n")
+		if names := sortedMethodNames(class); len(names) > 0 {
+			doc.WriteString("methods: " + strings.Join(names, ", ") + "\n")
+		}
+
+		return doc.String()
+	}
+
+	if callable, ok := value.(LoxCallable); ok {
+		return callable.Doc() + "\n"
+	}
+
+	kind, _ := typeOf(i, []interface{}{value})
+	return i.stringify(value) + " is a " + kind.(string) + "\n"
+}
@@ -0,0 +1,124 @@
+package glox
+
+import (
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Iterable is implemented by runtime values that for-in loops can iterate over. Iterate
+// returns a new iterator every time, so a collection can be iterated again, or by nested
+// loops at the same time.
+type Iterable interface {
+	Iterate() Iterator
+}
+
+// Iterator produces the elements of a collection one by one. Next returns false once
+// there are no more elements.
+type Iterator interface {
+	Next() (interface{}, bool)
+}
+
+// failingIterator is implemented by iterators that can fail to produce an element, like
+// those of sequences calling functions. Err returns the error that ended the iteration.
+type failingIterator interface {
+	Iterator
+	Err() error
+}
+
+// iteratorErr returns the error that ended the iteration, nil if it ended normally.
+func iteratorErr(iterator Iterator) error {
+	if failing, ok := iterator.(failingIterator); ok {
+		return failing.Err()
+	}
+
+	return nil
+}
+
+// iterate returns an iterator over the value. Strings are iterated by character, every
+// character being a string of its own.
+func iterate(value interface{}) (Iterator, bool) {
+	switch value := value.(type) {
+	case string:
+		return &stringIterator{runes: []rune(value)}, true
+	case Iterable:
+		return value.Iterate(), true
+	}
+
+	return nil, false
+}
+
+type stringIterator struct {
+	runes []rune
+	next  int
+}
+
+func (si *stringIterator) Next() (interface{}, bool) {
+	if si.next >= len(si.runes) {
+		return nil, false
+	}
+
+	si.next++
+	return string(si.runes[si.next-1]), true
+}
+
+// contains tests the membership of the value in the collection for the in operator: a
+// substring in a string, a number in a range, a key of a map, an element of an iterable
+// collection, or a key of an instance whose class has a has method, like Map.
+func (i *Interpreter) contains(operator ast.Token, collection, value interface{}) (interface{}, error) {
+	switch collection := collection.(type) {
+	case string:
+		substring, ok := value.(string)
+		if !ok {
+			return nil, NewRuntimeError(operator, "Left operand of 'in' must be a string when the right operand is a string")
+		}
+
+		return strings.Contains(collection, substring), nil
+	case LoxRange:
+		return collection.Contains(value), nil
+	case *LoxMap:
+		_, ok := collection.Get(value)
+		return ok, nil
+	case Iterable:
+		iterator := collection.Iterate()
+		for element, ok := iterator.Next(); ok; element, ok = iterator.Next() {
+			if err := i.interrupted(operator); err != nil {
+				return nil, err
+			}
+
+			equal, err := i.valuesEqual(operator, element, value)
+			if err != nil {
+				return nil, err
+			}
+
+			if equal {
+				return true, nil
+			}
+		}
+
+		if err := iteratorErr(iterator); err != nil {
+			if nativeErr, ok := err.(*nativeError); ok {
+				return nil, NewRuntimeError(operator, nativeErr.message)
+			}
+
+			return nil, err
+		}
+
+		return false, nil
+	case *LoxInstance:
+		if has, err := collection.klass.findMethod("has"); err == nil {
+			result, err := callValue(i, has.Bind(collection), []interface{}{value})
+			if nativeErr, ok := err.(*nativeError); ok {
+				return nil, NewRuntimeError(operator, nativeErr.message)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			return i.isTruthy(result), nil
+		}
+	}
+
+	return nil, NewRuntimeError(operator, "Right operand of 'in' must be a string, a range, a collection or an instance with a 'has' method")
+}
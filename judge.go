@@ -0,0 +1,88 @@
+package glox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The statuses of a verdict.
+const (
+	VerdictOK                = "ok"
+	VerdictCompileError      = "compile error"
+	VerdictRuntimeError      = "runtime error"
+	VerdictTimeLimitExceeded = "time limit exceeded"
+	VerdictCrashed           = "crashed"
+)
+
+// Verdict is the outcome of judging a program, for autograders and online judges. The exit
+// status is the one glox exits with for the outcome: 0 for a program that ran to the end,
+// 65 for compile errors and 70 for anything that stopped it while running. Time is the
+// wall time of the run, in milliseconds.
+type Verdict struct {
+	Status      string           `json:"status"`
+	ExitStatus  int              `json:"exitStatus"`
+	Output      string           `json:"output"`
+	Time        float64          `json:"timeMs"`
+	Diagnostics []JSONDiagnostic `json:"diagnostics"`
+	Error       *JSONError       `json:"error"`
+}
+
+// Judge compiles and runs the source as a program of its own, reading its input from stdin
+// and stopping it once it runs longer than the time limit, if the limit isn't 0. Nothing is
+// printed, what the program prints is in the verdict, along with the diagnostics and the
+// error that stopped it. A panic in glox is reported as a crash instead of crashing the
+// judge.
+func Judge(source string, stdin io.Reader, timeLimit time.Duration, options Options) (verdict Verdict) {
+	verdict.Diagnostics = make([]JSONDiagnostic, 0)
+	program, diagnostics := Compile(source, options)
+	for _, diagnostic := range diagnostics {
+		verdict.Diagnostics = append(verdict.Diagnostics, diagnostic.JSON())
+	}
+
+	if program == nil {
+		verdict.Status, verdict.ExitStatus = VerdictCompileError, 65
+		return verdict
+	}
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if timeLimit > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeLimit)
+	}
+
+	defer cancel()
+
+	out := &bytes.Buffer{}
+	interpreter := newProgramInterpreter(options)
+	interpreter.out, interpreter.in, interpreter.ctx = out, bufio.NewReader(stdin), ctx
+	interpreter.addLocals(program.locals)
+
+	start := time.Now()
+	defer func() {
+		verdict.Output, verdict.Time = out.String(), float64(time.Since(start).Microseconds())/1000
+		if value := recover(); value != nil {
+			verdict.Status, verdict.ExitStatus = VerdictCrashed, 70
+			verdict.Error = &JSONError{Message: fmt.Sprint(value)}
+		}
+	}()
+
+	err := program.run(interpreter)
+	switch {
+	case err == nil:
+		verdict.Status = VerdictOK
+	case ctx.Err() == context.DeadlineExceeded:
+		verdict.Status, verdict.ExitStatus = VerdictTimeLimitExceeded, 70
+		verdict.Error = &JSONError{Message: "Time limit of " + timeLimit.String() + " exceeded"}
+	default:
+		verdict.Status, verdict.ExitStatus = VerdictRuntimeError, 70
+		verdict.Error = &JSONError{Message: err.Error()}
+		if runErr, ok := err.(*RuntimeError); ok {
+			verdict.Error.Code, verdict.Error.Line = runErr.Code(), runErr.token.Line
+		}
+	}
+
+	return verdict
+}
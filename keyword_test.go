@@ -0,0 +1,59 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestKeywordArgumentsFollowTheCallee calls a global by keyword after it was assigned
+// another function, which must be checked against the function that is called.
+func TestKeywordArgumentsFollowTheCallee(t *testing.T) {
+	output, err := runSource(t, `
+fun greet(name, greeting) { print greeting + ", " + name; }
+fun other(x) { print x; }
+fun swap() { greet = other; }
+greet(name: "Ada", greeting: "Hi");
+swap();
+greet(x: "swapped");
+`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if expected := "Hi, Ada\nswapped\n"; output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
+
+func TestKeywordArgumentErrors(t *testing.T) {
+	tests := map[string]string{
+		`fun f(a, b) {} f(c: 1, a: 2, b: 3);`: "'f' has no parameter named 'c'",
+		`fun f(a, b) {} f(1, a: 2);`:          "Argument 'a' is already passed positionally",
+		`fun f(a, b) {} f(a: 1);`:             "Missing argument for parameter 'b' calling 'f'",
+		`clock(a: 1);`:                        "'clock' does not accept keyword arguments",
+	}
+
+	for source, message := range tests {
+		if _, err := runSource(t, source, DefaultOptions()); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected a runtime error containing %q, got %v", source, message, err)
+		}
+	}
+}
+
+// TestKeywordArgumentCompileErrors checks the errors found before running: keywords passed
+// twice, and calls of local functions, which can't be assigned behind the resolver's back.
+func TestKeywordArgumentCompileErrors(t *testing.T) {
+	tests := map[string]string{
+		`fun f(a, b) {} f(a: 1, a: 2, b: 3);`: "Duplicate keyword argument 'a'",
+		`fun o() { fun f(a) {} f(b: 1); }`:    "'f' has no parameter named 'b'",
+		`fun o() { fun f(a, b) {} f(b: 1); }`: "Missing argument for parameter 'a' calling 'f'",
+		`fun o() { fun f(a) {} f(1, a: 1); }`: "Argument 'a' is already passed positionally",
+	}
+
+	for source, message := range tests {
+		program, diagnostics := Compile(source, DefaultOptions())
+		if program != nil || !strings.Contains(diagnosticsError(diagnostics).Error(), message) {
+			t.Errorf("%s: expected a compile error containing %q, got %v", source, message, diagnostics)
+		}
+	}
+}
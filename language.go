@@ -0,0 +1,91 @@
+package glox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The editions of the language. The canonical edition is the Lox of the book, without any
+// of the glox extensions, for classrooms that follow along. The glox edition, the default,
+// enables the extensions that don't change how canonical programs are written.
+const (
+	EditionCanonical = "canonical"
+	EditionGlox      = "glox"
+)
+
+// LanguageOptions controls which extensions to the canonical Lox grammar are enabled. They
+// are usually picked as a whole with an edition, and individual extensions can be turned on
+// or off afterwards.
+type LanguageOptions struct {
+	// Edition is the name of the edition the options started from.
+	Edition string
+
+	// OptionalSemicolons lets a line break terminate a statement in place of a ';'.
+	OptionalSemicolons bool
+
+	// KeywordArguments allows passing arguments by parameter name, e.g. draw(x: 10).
+	KeywordArguments bool
+
+	// ForIn allows for (var x in collection) loops. The 'in' is only special right after
+	// the loop variable, so it's still a valid name everywhere else.
+	ForIn bool
+
+	// ChainedComparisons makes a < b < c mean a < b and b < c, evaluating b once.
+	ChainedComparisons bool
+
+	// CommaOperator allows the C comma operator, a, b evaluates a, then b, to the value of b.
+	CommaOperator bool
+
+	// Exceptions allows throw statements and try statements with catch and finally. The
+	// keywords are only reserved when exceptions are enabled.
+	Exceptions bool
+
+	// Ranges allows the range expressions start..end and start..=end.
+	Ranges bool
+
+	// InOperator allows membership tests, x in collection. Like in for-in loops, 'in' is
+	// still a valid name.
+	InOperator bool
+
+	// PipeOperator allows value |> f as another way to write f(value).
+	PipeOperator bool
+
+	// Arrays allows array literals, [a, b], and indexing, a[i].
+	Arrays bool
+
+	// Maps allows map literals, {key: value}. A '{' that starts a statement is still a block.
+	Maps bool
+
+	// Tuples allows tuples, (a, b), and destructuring declarations, var (a, b) = tuple;. A
+	// comma in parentheses makes a tuple rather than a comma operator sequence.
+	Tuples bool
+
+	// BigLiterals allows number literals with an n suffix, like 123n, which are big numbers
+	// even when big number mode is off.
+	BigLiterals bool
+
+	// ExtendedNumbers allows scientific notation, 1.5e3, and underscores separating the
+	// digits of number literals, 1_000_000.
+	ExtendedNumbers bool
+
+	// StringOperators concatenates strings with numbers, "count: " + 3, and repeats strings
+	// multiplied by a number, "ab" * 3. Canonical Lox reports both as runtime errors.
+	StringOperators bool
+}
+
+// EditionOptions returns the language options of the named edition.
+func EditionOptions(edition string) (LanguageOptions, error) {
+	switch edition {
+	case EditionCanonical:
+		return LanguageOptions{Edition: EditionCanonical}, nil
+	case EditionGlox:
+		return LanguageOptions{Edition: EditionGlox, KeywordArguments: true, ForIn: true, ChainedComparisons: true, CommaOperator: true, Exceptions: true, Ranges: true, InOperator: true, PipeOperator: true, Arrays: true, Maps: true, Tuples: true, BigLiterals: true, ExtendedNumbers: true, StringOperators: true}, nil
+	}
+
+	return LanguageOptions{}, fmt.Errorf("unknown edition '%s', expected one of %s", edition, strings.Join(Editions(), ", "))
+}
+
+// Editions returns the names of the supported editions.
+func Editions() []string {
+	return []string{EditionCanonical, EditionGlox}
+}
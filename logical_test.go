@@ -0,0 +1,138 @@
+package glox
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// logicalOperand is an operand of the truth table: its source, how it's printed and if
+// it's truthy.
+type logicalOperand struct {
+	source  string
+	printed string
+	truthy  bool
+}
+
+var logicalOperands = []logicalOperand{
+	{source: "nil", printed: "nil", truthy: false},
+	{source: "false", printed: "false", truthy: false},
+	{source: "true", printed: "true", truthy: true},
+	{source: "0", printed: "0", truthy: true},
+	{source: "1", printed: "1", truthy: true},
+	{source: `""`, printed: "", truthy: true},
+	{source: `"s"`, printed: "s", truthy: true},
+	{source: "[]", printed: "[]", truthy: true},
+	{source: "{}", printed: "{}", truthy: true},
+	{source: "C()", printed: "C instance", truthy: true},
+	{source: "C", printed: "C", truthy: true},
+	{source: "f", printed: "<fn f>", truthy: true},
+}
+
+// runLogical evaluates left operator right(), where right() returns the right operand and
+// records that it was called. It returns what was printed, the runtime error and if the
+// right operand was evaluated.
+func runLogical(t *testing.T, operator string, left, right logicalOperand, strict bool) (string, error, bool) {
+	t.Helper()
+
+	source := fmt.Sprintf(`
+class C {}
+fun f() {}
+var evaluated = false;
+fun right() {
+  evaluated = true;
+  return %s;
+}
+print %s %s right();
+`, right.source, left.source, operator)
+
+	options := DefaultOptions()
+	options.StrictLogical = strict
+	program, diagnostics := Compile(source, options)
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	var out bytes.Buffer
+	interpreter := newProgramInterpreter(options)
+	interpreter.out = &out
+	interpreter.addLocals(program.locals)
+	err := program.run(interpreter)
+
+	evaluated, _ := interpreter.Global("evaluated")
+	return out.String(), err, evaluated == true
+}
+
+func TestLogicalTruthTable(t *testing.T) {
+	for _, operator := range []string{"and", "or"} {
+		for _, left := range logicalOperands {
+			for _, right := range logicalOperands {
+				name := fmt.Sprintf("%s %s %s", left.source, operator, right.source)
+
+				// The left operand decides the result when it's truthy for or, falsey for and.
+				decides := left.truthy == (operator == "or")
+
+				t.Run(name, func(t *testing.T) {
+					output, err, evaluated := runLogical(t, operator, left, right, false)
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err.Error())
+					}
+
+					expected := right.printed
+					if decides {
+						expected = left.printed
+					}
+
+					if output != expected+"\n" {
+						t.Errorf("printed %q, expected %q", output, expected+"\n")
+					}
+
+					if evaluated == decides {
+						t.Errorf("right operand evaluated: %t, expected %t", evaluated, !decides)
+					}
+				})
+
+				t.Run("strict "+name, func(t *testing.T) {
+					output, err, evaluated := runLogical(t, operator, left, right, true)
+					leftBool := left.source == "true" || left.source == "false"
+					rightBool := right.source == "true" || right.source == "false"
+
+					switch {
+					case !leftBool:
+						if err == nil {
+							t.Fatalf("expected an error for the left operand, printed %q", output)
+						}
+
+						if evaluated {
+							t.Errorf("right operand evaluated after the left one failed")
+						}
+					case decides:
+						if err != nil || output != left.printed+"\n" {
+							t.Errorf("printed %q, %v, expected %q", output, err, left.printed+"\n")
+						}
+
+						if evaluated {
+							t.Errorf("right operand evaluated although the left one decides")
+						}
+					case !rightBool:
+						if err == nil {
+							t.Fatalf("expected an error for the right operand, printed %q", output)
+						}
+
+						if !evaluated {
+							t.Errorf("right operand not evaluated")
+						}
+					default:
+						if err != nil || output != right.printed+"\n" {
+							t.Errorf("printed %q, %v, expected %q", output, err, right.printed+"\n")
+						}
+					}
+
+					if err != nil && err.Error() != "Operands of '"+operator+"' must be booleans" {
+						t.Errorf("unexpected error %q", err.Error())
+					}
+				})
+			}
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package glox
 
+import (
+	"fmt"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
 // LoxCallable interface should be implemented by any lox object that can be called like
 // a function.
 type LoxCallable interface {
@@ -12,4 +18,111 @@ type LoxCallable interface {
 	// number of arguments passed to the function matches the number of arguments the
 	// function expects.
 	Arity() int
+
+	// Name is the name the callable was declared with. It's used when rendering the
+	// callable and when reporting errors about calling it.
+	Name() string
+
+	// Doc is a short human readable description of the callable. For user defined
+	// functions and classes this is their signature, natives carry a hand written
+	// description.
+	Doc() string
+}
+
+// VariadicArity is the maximum arity of callables that accept any number of arguments
+// past their minimum.
+const VariadicArity = -1
+
+// ArityRange is implemented by callables that accept a range of argument counts rather
+// than an exact number, e.g. natives with optional parameters. For those callables Arity
+// reports the minimum number of arguments and MaxArity the maximum, or VariadicArity if
+// there is no upper bound.
+type ArityRange interface {
+	MaxArity() int
+}
+
+// arityRange returns the minimum and maximum number of arguments the callable accepts.
+func arityRange(callable LoxCallable) (int, int) {
+	if ranged, ok := callable.(ArityRange); ok {
+		return callable.Arity(), ranged.MaxArity()
+	}
+
+	return callable.Arity(), callable.Arity()
+}
+
+// checkArity verifies that the number of arguments passed to the callable is within its
+// accepted range and returns a description of the expectation if it's not.
+func checkArity(callable LoxCallable, count int) (string, bool) {
+	min, max := arityRange(callable)
+	if count >= min && (max == VariadicArity || count <= max) {
+		return "", true
+	}
+
+	var expected string
+	switch {
+	case min == max:
+		expected = fmt.Sprintf("Expected %d arguments", min)
+	case max == VariadicArity:
+		expected = fmt.Sprintf("Expected at least %d arguments", min)
+	default:
+		expected = fmt.Sprintf("Expected %d to %d arguments", min, max)
+	}
+
+	return fmt.Sprintf("%s but got %d calling '%s'", expected, count, callable.Name()), false
+}
+
+// ParameterNames is implemented by callables whose arguments can be passed by name at the
+// call site, e.g. draw(x: 10, y: 20).
+type ParameterNames interface {
+	Parameters() []string
+}
+
+// bindKeywordArguments places the keyword arguments of a call into the positional slots of
+// the callee's parameters, producing the argument list the callee expects.
+func bindKeywordArguments(callable LoxCallable, positional []interface{}, keywords []ast.KeywordArgument, values []interface{}) ([]interface{}, error) {
+	named, ok := callable.(ParameterNames)
+	if !ok {
+		return nil, NewRuntimeError(keywords[0].Name, "'"+callable.Name()+"' does not accept keyword arguments")
+	}
+
+	params := named.Parameters()
+	if len(positional) > len(params) {
+		message, _ := checkArity(callable, len(positional)+len(keywords))
+		return nil, NewRuntimeError(keywords[0].Name, message)
+	}
+
+	arguments := make([]interface{}, len(params))
+	bound := make([]bool, len(params))
+	for i, value := range positional {
+		arguments[i] = value
+		bound[i] = true
+	}
+
+	for k, keyword := range keywords {
+		index := -1
+		for i, param := range params {
+			if param == keyword.Name.Lexeme {
+				index = i
+			}
+		}
+
+		if index == -1 {
+			return nil, NewRuntimeError(keyword.Name, "'"+callable.Name()+"' has no parameter named '"+keyword.Name.Lexeme+"'")
+		}
+
+		if bound[index] {
+			return nil, NewRuntimeError(keyword.Name, "Argument '"+keyword.Name.Lexeme+"' is already passed positionally")
+		}
+
+		arguments[index] = values[k]
+		bound[index] = true
+	}
+
+	for i, param := range params {
+		if !bound[i] {
+			return nil, NewRuntimeError(keywords[0].Name, "Missing argument for parameter '"+param+"' calling '"+callable.Name()+"'")
+		}
+	}
+
+	return arguments, nil
 }
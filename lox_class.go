@@ -1,30 +1,36 @@
 package glox
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var ErrMethodNotFound = errors.New("method not found with the given name")
 
 type LoxClass struct {
-	Name       string
+	name       string
 	Superclass *LoxClass
 	methods    map[string]LoxFunction
 }
 
 func NewLoxClass(name string, superclass *LoxClass, methods map[string]LoxFunction) *LoxClass {
-	return &LoxClass{Name: name, Superclass: superclass, methods: methods}
+	return &LoxClass{name: name, Superclass: superclass, methods: methods}
 }
 
-
-
 func (lc *LoxClass) Call(ip *Interpreter, arguments []interface{}) (interface{}, error) {
 	instance := NewLoxInstance(lc)
+	if ip.profiler != nil {
+		ip.profiler.instantiated(instance)
+	}
 
 	// When a class is called, and the lox instance is created, we look for an "init" method,
 	// If we find it, we immediately bind and invoke it just like normal method call. The
 	// argument list is forwarded along.
 	initializer, err := lc.findMethod("init")
 	if err == nil {
-		initializer.Bind(instance).Call(ip, arguments)
+		if _, err := initializer.Bind(instance).Call(ip, arguments); err != nil {
+			return nil, err
+		}
 	}
 
 	return instance, nil
@@ -42,6 +48,40 @@ func (lc *LoxClass) Arity() int {
 	return 0
 }
 
+func (lc *LoxClass) Name() string {
+	return lc.name
+}
+
+// Parameters returns the parameters of the initializer, which are the parameters of
+// calling the class.
+func (lc *LoxClass) Parameters() []string {
+	initializer, err := lc.findMethod("init")
+	if err == nil {
+		return initializer.Parameters()
+	}
+
+	return nil
+}
+
+// Doc returns the class declaration header along with the signature of its initializer,
+// e.g. "class Brunch < Breakfast, init(meat, bread, drink)".
+func (lc *LoxClass) Doc() string {
+	doc := "class " + lc.name
+	if lc.Superclass != nil {
+		doc += " < " + lc.Superclass.name
+	}
+
+	if initializer, err := lc.findMethod("init"); err == nil {
+		doc += ", " + strings.TrimPrefix(initializer.Doc(), "fun ")
+	}
+
+	return doc
+}
+
+func (lc *LoxClass) String() string {
+	return lc.name
+}
+
 func (lc *LoxClass) findMethod(name string) (LoxFunction, error) {
 	if method, ok := lc.methods[name]; ok {
 		return method, nil
@@ -53,3 +93,17 @@ func (lc *LoxClass) findMethod(name string) (LoxFunction, error) {
 
 	return LoxFunction{}, ErrMethodNotFound
 }
+
+// methodNames returns the names of the methods of the class, including the inherited ones.
+func (lc *LoxClass) methodNames() []string {
+	names := make([]string, 0, len(lc.methods))
+	for name := range lc.methods {
+		names = append(names, name)
+	}
+
+	if lc.Superclass != nil {
+		names = append(names, lc.Superclass.methodNames()...)
+	}
+
+	return names
+}
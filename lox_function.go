@@ -1,16 +1,32 @@
 package glox
 
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
 // LoxFunction is the representation of the lox function in terms of the interpreter.
 // This struct also implements the LoxCallable interface so the runtime can call this
 // function.
 type LoxFunction struct {
-	declaration   *FunctionStmt
+	declaration   *ast.FunctionStmt
 	closure       *Environment
 	isInitializer bool
+
+	// created is when the function was created on the clock of its closure, only set when
+	// the closure records its definitions for --debug-resolver.
+	created uint64
 }
 
-func NewLoxFunction(declaration *FunctionStmt, closure *Environment, isInitializer bool) LoxCallable {
-	return LoxFunction{declaration: declaration, closure: closure, isInitializer: isInitializer}
+func NewLoxFunction(declaration *ast.FunctionStmt, closure *Environment, isInitializer bool) LoxCallable {
+	function := LoxFunction{declaration: declaration, closure: closure, isInitializer: isInitializer}
+	if closure != nil && closure.clock != nil {
+		function.created = atomic.AddUint64(closure.clock, 1)
+	}
+
+	return function
 }
 
 // Call will execute the function body with the arguments passed to it. The parameters are
@@ -20,9 +36,17 @@ func NewLoxFunction(declaration *FunctionStmt, closure *Environment, isInitializ
 // and argument lists and for each pair it creates a new variable with the parameter's name
 // and binds it to the argument's value.
 func (lf LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	// The body was resolved, so it uses the resolved distances even when it's called from an
+	// expression evaluated in a frame, which looks its own variables up dynamically.
+	if interpreter.dynamicLookup {
+		interpreter.dynamicLookup = false
+		defer func() { interpreter.dynamicLookup = true }()
+	}
+
 	env := NewEnvironment(lf.closure)
+	env.horizon = lf.created
 	for i, param := range lf.declaration.Params {
-		env.Define(param.Lexeme, arguments[i])
+		env.DefineSymbol(param.Symbol(), arguments[i])
 	}
 
 	err := interpreter.executeBlock(lf.declaration.Body, env)
@@ -31,7 +55,7 @@ func (lf LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (i
 			// if we are in an initializer and execute a return, we return "this" instead of
 			// returning the value.
 			if lf.isInitializer {
-				return lf.closure.GetAt(0, "this"), nil
+				return lf.closure.GetAt(0, ast.SymbolThis)
 			}
 
 			return runE.Value, nil
@@ -41,7 +65,7 @@ func (lf LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (i
 	}
 
 	if lf.isInitializer {
-		return lf.closure.GetAt(0, "this"), nil
+		return lf.closure.GetAt(0, ast.SymbolThis)
 	}
 
 	return nil, nil
@@ -51,12 +75,30 @@ func (lf LoxFunction) Arity() int {
 	return len(lf.declaration.Params)
 }
 
+func (lf LoxFunction) Name() string {
+	return lf.declaration.Name.Lexeme
+}
+
+func (lf LoxFunction) Parameters() []string {
+	params := make([]string, 0, len(lf.declaration.Params))
+	for _, param := range lf.declaration.Params {
+		params = append(params, param.Lexeme)
+	}
+
+	return params
+}
+
+// Doc returns the signature of the function as it was declared, e.g. "fun add(a, b)".
+func (lf LoxFunction) Doc() string {
+	return "fun " + lf.Name() + "(" + strings.Join(lf.Parameters(), ", ") + ")"
+}
+
 func (lf LoxFunction) String() string {
-	return "<fn " + lf.declaration.Name.Lexeme + ">"
+	return "<fn " + lf.Name() + ">"
 }
 
 func (lf LoxFunction) Bind(instance *LoxInstance) LoxFunction {
 	env := NewEnvironment(lf.closure)
-	env.Define("this", instance)
+	env.DefineSymbol(ast.SymbolThis, instance)
 	return NewLoxFunction(lf.declaration, env, lf.isInitializer).(LoxFunction)
 }
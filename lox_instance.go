@@ -1,5 +1,7 @@
 package glox
 
+import "github.com/iamsayantan/glox/ast"
+
 type LoxInstance struct {
 	klass  *LoxClass
 	fields map[string]interface{}
@@ -10,10 +12,10 @@ func NewLoxInstance(klass *LoxClass) *LoxInstance {
 }
 
 func (li *LoxInstance) String() string {
-	return li.klass.Name + " instance"
+	return li.klass.name + " instance"
 }
 
-func (li *LoxInstance) Get(name Token) (interface{}, error) {
+func (li *LoxInstance) Get(name ast.Token) (interface{}, error) {
 	if val, ok := li.fields[name.Lexeme]; ok {
 		return val, nil
 	}
@@ -22,9 +24,14 @@ func (li *LoxInstance) Get(name Token) (interface{}, error) {
 		return method.Bind(li), nil
 	}
 
-	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+	names := li.klass.methodNames()
+	for field := range li.fields {
+		names = append(names, field)
+	}
+
+	return nil, NewRuntimeError(name, undefinedMessage("property", name.Lexeme, names))
 }
 
-func (li *LoxInstance) Set(name Token, value interface{}) {
+func (li *LoxInstance) Set(name ast.Token, value interface{}) {
 	li.fields[name.Lexeme] = value
 }
\ No newline at end of file
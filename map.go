@@ -0,0 +1,196 @@
+package glox
+
+import (
+	"math"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// LoxMap is a hash map created with {key: value} literals. Numbers, strings, booleans and
+// nil are keys by their value and other values by their identity, see hashKey. Entries are
+// kept in insertion order, which is the order maps are iterated and printed in.
+type LoxMap struct {
+	keys   []interface{}
+	values []interface{}
+
+	// index maps the hash key of every key to its position in keys and values.
+	index map[interface{}]int
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{index: make(map[interface{}]int)}
+}
+
+// Len returns the number of entries of the map.
+func (m *LoxMap) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns the keys of the map in insertion order. The slice is shared with the map.
+func (m *LoxMap) Keys() []interface{} {
+	return m.keys
+}
+
+// Get returns the value of the key, and false if the key is not in the map.
+func (m *LoxMap) Get(key interface{}) (interface{}, bool) {
+	hash, ok := hashKey(key)
+	if !ok {
+		return nil, false
+	}
+
+	n, ok := m.index[hash]
+	if !ok {
+		return nil, false
+	}
+
+	return m.values[n], true
+}
+
+// Set sets the value of the key. It returns false if the value can't be a key.
+func (m *LoxMap) Set(key, value interface{}) bool {
+	hash, ok := hashKey(key)
+	if !ok {
+		return false
+	}
+
+	if n, ok := m.index[hash]; ok {
+		m.values[n] = value
+		return true
+	}
+
+	m.index[hash] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+	return true
+}
+
+// Remove removes the key from the map, returning its value and whether it was there.
+func (m *LoxMap) Remove(key interface{}) (interface{}, bool) {
+	hash, ok := hashKey(key)
+	if !ok {
+		return nil, false
+	}
+
+	n, ok := m.index[hash]
+	if !ok {
+		return nil, false
+	}
+
+	value := m.values[n]
+	m.keys = append(m.keys[:n], m.keys[n+1:]...)
+	m.values = append(m.values[:n], m.values[n+1:]...)
+	delete(m.index, hash)
+	for hash, position := range m.index {
+		if position > n {
+			m.index[hash] = position - 1
+		}
+	}
+
+	return value, true
+}
+
+// Iterate iterates over the keys of the map.
+func (m *LoxMap) Iterate() Iterator {
+	return &arrayIterator{array: NewLoxArray(append([]interface{}{}, m.keys...))}
+}
+
+// get returns the value of the key for an index expression, a missing key is an error.
+func (m *LoxMap) get(bracket ast.Token, key interface{}) (interface{}, error) {
+	value, ok := m.Get(key)
+	if !ok {
+		return nil, NewRuntimeError(bracket, "Key not found in map")
+	}
+
+	return value, nil
+}
+
+// set sets the value of the key for an index assignment.
+func (m *LoxMap) set(bracket ast.Token, key, value interface{}) error {
+	if !m.Set(key, value) {
+		if n, ok := key.(float64); ok && math.IsNaN(n) {
+			return NewRuntimeError(bracket, "Map keys must not be NaN, it isn't equal to itself")
+		}
+
+		return NewRuntimeError(bracket, "Map keys must be numbers, strings, booleans, nil or objects")
+	}
+
+	return nil
+}
+
+func (m *LoxMap) property(name ast.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "has":
+		doc := "has(key) returns true if the key is in the map."
+		return NewNativeFunction("has", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			_, ok := m.Get(arguments[0])
+			return ok, nil
+		}), nil
+	case "get":
+		doc := "get(key, default) returns the value of the key, or the default if the key is not in the map."
+		return NewNativeFunction("get", doc, 2, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if value, ok := m.Get(arguments[0]); ok {
+				return value, nil
+			}
+
+			return arguments[1], nil
+		}), nil
+	case "remove":
+		doc := "remove(key) removes the key from the map and returns its value, or nil if the key was not in the map."
+		return NewNativeFunction("remove", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			value, _ := m.Remove(arguments[0])
+			return value, nil
+		}), nil
+	case "keys":
+		doc := "keys() returns an array of the keys of the map, in insertion order."
+		return NewNativeFunction("keys", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return NewLoxArray(append([]interface{}{}, m.keys...)), nil
+		}), nil
+	case "values":
+		doc := "values() returns an array of the values of the map, in insertion order."
+		return NewNativeFunction("values", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return NewLoxArray(append([]interface{}{}, m.values...)), nil
+		}), nil
+	}
+
+	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+}
+
+// stringifyMap prints the map like its literal, with the keys and values printed like the
+// elements of an array.
+func (i *Interpreter) stringifyMap(m *LoxMap, seen map[interface{}]bool) string {
+	if seen[m] {
+		return "{...}"
+	}
+
+	seen[m] = true
+	defer delete(seen, m)
+
+	entries := make([]string, 0, len(m.keys))
+	for n, key := range m.keys {
+		entries = append(entries, i.stringifyElement(key, seen)+": "+i.stringifyElement(m.values[n], seen))
+	}
+
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func (i *Interpreter) VisitMapExpr(expr *ast.MapLiteral) (interface{}, error) {
+	m := NewLoxMap()
+	for n, keyExpr := range expr.Keys {
+		key, err := i.evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := i.evaluate(expr.Values[n])
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.set(expr.Brace, key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,112 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMapNumberKeysInBigNumberMode looks up keys written as literals, which are big numbers,
+// with numbers computed by natives, which are float64s.
+func TestMapNumberKeysInBigNumberMode(t *testing.T) {
+	options := DefaultOptions()
+	options.BigNumbers = true
+
+	output, err := runSource(t, `
+var m = {3: "x", 0.5: "half"};
+print m[len([1, 2, 3])];
+print m[1 / 2];
+m[len([1, 2, 3])] = "y";
+print m[3];
+print len(m.keys());
+`, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if expected := "x\nhalf\ny\n2\n"; output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
+
+// TestMapBigNumberLiteralKeys mixes number literals with and without the n suffix.
+func TestMapBigNumberLiteralKeys(t *testing.T) {
+	output, err := runSource(t, `
+print {1: "a"}[1n];
+print {1n: "b"}[1];
+var m = {2n: "c"};
+m[2] = "d";
+print m[2n];
+print len(m.keys());
+print {0.1n: "e"}.has(0.1);
+`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if expected := "a\nb\nd\n1\nfalse\n"; output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
+
+func TestMaps(t *testing.T) {
+	output, err := runSource(t, `
+var m = {"a": 1, 2: "two", true: nil};
+print m;
+print m["a"];
+m["b"] = 3;
+print m.has("b");
+print m.get("z", 0);
+print m.remove("a");
+print m.keys();
+print m.values();
+for (var k in m) print k;
+print "b" in m;
+print {};
+{ print "block"; }
+`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := `{"a": 1, 2: "two", true: nil}
+1
+true
+0
+1
+[2, true, "b"]
+["two", nil, 3]
+2
+true
+b
+true
+{}
+block
+`
+	if output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	tests := map[string]string{
+		`print {}["x"];`:              "Key not found in map",
+		`var m = {}; m[0 / 0] = 1;`:   "Map keys must not be NaN",
+		`var m = {}; print m.nope;`:   "Undefined property 'nope'",
+		`print {"a": 1}.remove("b");`: "",
+	}
+
+	for source, message := range tests {
+		_, err := runSource(t, source, DefaultOptions())
+		if message == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", source, err.Error())
+			}
+
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected an error containing %q, got %v", source, message, err)
+		}
+	}
+}
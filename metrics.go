@@ -0,0 +1,323 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// FunctionMetrics are the size and complexity measures of a single function or method.
+type FunctionMetrics struct {
+	// Name is the name of the function, methods are named Class.method.
+	Name   string
+	Line   int
+	Params int
+	// Statements counts the statements of the body, including the ones nested in blocks,
+	// ifs and loops but not the ones of nested functions. Blocks themselves don't count.
+	Statements int
+	// Complexity is the cyclomatic complexity, one plus the number of if statements, loops
+	// and 'and' and 'or' operators.
+	Complexity int
+	// Depth is how deeply if statements and loops are nested, 0 for a straight body.
+	Depth int
+}
+
+// Metrics measures every function and method declared in the statements, in the order they
+// are declared. Nested functions are measured on their own and don't add to the function
+// they are declared in, apart from their declaration counting as a statement.
+func Metrics(statements []ast.Stmt) []FunctionMetrics {
+	mc := &metricsCollector{}
+	mc.walk(statements...)
+
+	metrics := make([]FunctionMetrics, 0, len(mc.functions))
+	for _, function := range mc.functions {
+		metrics = append(metrics, *function)
+	}
+
+	return metrics
+}
+
+// WriteMetrics prints the metrics as a table.
+func WriteMetrics(w io.Writer, metrics []FunctionMetrics) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "function\tline\tparams\tstatements\tcomplexity\tdepth\t")
+	for _, m := range metrics {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t\n", m.Name, m.Line, m.Params, m.Statements, m.Complexity, m.Depth)
+	}
+
+	tw.Flush()
+}
+
+// metricsCollector walks the syntax tree, adding up the metrics of the function it's in.
+type metricsCollector struct {
+	functions []*FunctionMetrics
+	// current is the innermost function being walked, it's nil at the top level.
+	current *FunctionMetrics
+	depth   int
+}
+
+func (mc *metricsCollector) walk(statements ...ast.Stmt) {
+	for _, stmt := range statements {
+		_ = stmt.Accept(mc)
+	}
+}
+
+func (mc *metricsCollector) walkExpr(exprs ...ast.Expr) {
+	for _, expr := range exprs {
+		_, _ = expr.Accept(mc)
+	}
+}
+
+// statement counts a statement of the current function.
+func (mc *metricsCollector) statement() {
+	if mc.current != nil {
+		mc.current.Statements++
+	}
+}
+
+// branch counts a decision point of the current function.
+func (mc *metricsCollector) branch() {
+	if mc.current != nil {
+		mc.current.Complexity++
+	}
+}
+
+// nested walks the statements one level deeper.
+func (mc *metricsCollector) nested(statements ...ast.Stmt) {
+	mc.depth++
+	if mc.current != nil && mc.depth > mc.current.Depth {
+		mc.current.Depth = mc.depth
+	}
+
+	mc.walk(statements...)
+	mc.depth--
+}
+
+func (mc *metricsCollector) function(name string, stmt *ast.FunctionStmt) {
+	metrics := &FunctionMetrics{Name: name, Line: stmt.Name.Line, Params: len(stmt.Params), Complexity: 1}
+	mc.functions = append(mc.functions, metrics)
+
+	current, depth := mc.current, mc.depth
+	mc.current, mc.depth = metrics, 0
+	mc.walk(stmt.Body...)
+	mc.current, mc.depth = current, depth
+}
+
+func (mc *metricsCollector) VisitBlockStmt(stmt *ast.Block) error {
+	mc.walk(stmt.Statements...)
+	return nil
+}
+
+func (mc *metricsCollector) VisitExpressionExpr(expr *ast.Expression) error {
+	mc.statement()
+	mc.walkExpr(expr.Expression)
+	return nil
+}
+
+func (mc *metricsCollector) VisitPrintExpr(expr *ast.Print) error {
+	mc.statement()
+	mc.walkExpr(expr.Expression)
+	return nil
+}
+
+func (mc *metricsCollector) VisitVarStmt(stmt *ast.VarStmt) error {
+	mc.statement()
+	if stmt.Initializer != nil {
+		mc.walkExpr(stmt.Initializer)
+	}
+
+	return nil
+}
+
+func (mc *metricsCollector) VisitDestructureStmt(stmt *ast.DestructureStmt) error {
+	mc.statement()
+	mc.walkExpr(stmt.Initializer)
+	return nil
+}
+
+func (mc *metricsCollector) VisitIfStmt(stmt *ast.IfStmt) error {
+	mc.statement()
+	mc.branch()
+	mc.walkExpr(stmt.Condition)
+	mc.nested(stmt.ThenBranch)
+	if stmt.ElseBranch != nil {
+		mc.nested(stmt.ElseBranch)
+	}
+
+	return nil
+}
+
+func (mc *metricsCollector) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	mc.statement()
+	mc.branch()
+	mc.walkExpr(stmt.Condition)
+	mc.nested(stmt.Body)
+	return nil
+}
+
+func (mc *metricsCollector) VisitForInStmt(stmt *ast.ForInStmt) error {
+	mc.statement()
+	mc.branch()
+	mc.walkExpr(stmt.Collection)
+	mc.nested(stmt.Body)
+	return nil
+}
+
+func (mc *metricsCollector) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	mc.statement()
+	mc.function(stmt.Name.Lexeme, stmt)
+	return nil
+}
+
+func (mc *metricsCollector) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	mc.statement()
+	if stmt.Value != nil {
+		mc.walkExpr(stmt.Value)
+	}
+
+	return nil
+}
+
+func (mc *metricsCollector) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	mc.statement()
+	mc.walkExpr(stmt.Value)
+	return nil
+}
+
+func (mc *metricsCollector) VisitTryStmt(stmt *ast.TryStmt) error {
+	mc.statement()
+	mc.nested(stmt.Body...)
+	if stmt.Catch != nil {
+		mc.branch()
+		mc.nested(stmt.Catch.Body...)
+	}
+
+	if stmt.Finally != nil {
+		mc.nested(stmt.Finally...)
+	}
+
+	return nil
+}
+
+func (mc *metricsCollector) VisitClassStmt(stmt *ast.ClassStmt) error {
+	mc.statement()
+	for _, method := range stmt.Methods {
+		mc.function(stmt.Name.Lexeme+"."+method.Name.Lexeme, method)
+	}
+
+	return nil
+}
+
+func (mc *metricsCollector) VisitBadStmt(stmt *ast.BadStmt) error {
+	return nil
+}
+
+func (mc *metricsCollector) VisitAssignExpr(expr *ast.Assign) (interface{}, error) {
+	mc.walkExpr(expr.Value)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitLogicalExpr(expr *ast.Logical) (interface{}, error) {
+	mc.branch()
+	mc.walkExpr(expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitBinaryExpr(expr *ast.Binary) (interface{}, error) {
+	mc.walkExpr(expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitCallExpr(expr *ast.Call) (interface{}, error) {
+	mc.walkExpr(expr.Callee)
+	mc.walkExpr(expr.Arguments...)
+	for _, argument := range expr.KeywordArguments {
+		mc.walkExpr(argument.Value)
+	}
+
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitGroupingExpr(expr *ast.Grouping) (interface{}, error) {
+	mc.walkExpr(expr.Expression)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitLiteralExpr(expr *ast.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitUnaryExpr(expr *ast.Unary) (interface{}, error) {
+	mc.walkExpr(expr.Right)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	mc.walkExpr(expr.Object)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	mc.walkExpr(expr.Object, expr.Value)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitRangeExpr(expr *ast.Range) (interface{}, error) {
+	mc.walkExpr(expr.Start, expr.End)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitArrayExpr(expr *ast.ArrayLiteral) (interface{}, error) {
+	mc.walkExpr(expr.Elements...)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitMapExpr(expr *ast.MapLiteral) (interface{}, error) {
+	mc.walkExpr(expr.Keys...)
+	mc.walkExpr(expr.Values...)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitTupleExpr(expr *ast.Tuple) (interface{}, error) {
+	mc.walkExpr(expr.Elements...)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitIndexGetExpr(expr *ast.IndexGet) (interface{}, error) {
+	mc.walkExpr(expr.Object, expr.Index)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitIndexSetExpr(expr *ast.IndexSet) (interface{}, error) {
+	mc.walkExpr(expr.Object, expr.Index, expr.Value)
+	return nil, nil
+}
+
+func (mc *metricsCollector) VisitComparisonExpr(expr *ast.Comparison) (interface{}, error) {
+	// Every comparison after the first is an implicit 'and'.
+	for range expr.Operators[1:] {
+		mc.branch()
+	}
+
+	mc.walkExpr(expr.Operands...)
+	return nil, nil
+}
+
+var (
+	_ ast.Visitor     = &metricsCollector{}
+	_ ast.StmtVisitor = &metricsCollector{}
+)
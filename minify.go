@@ -0,0 +1,591 @@
+package glox
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Minify prints the source back without comments and without any whitespace that is not
+// needed to separate two tokens, for embedding lox programs where space is tight. The
+// program is printed from its syntax tree, so for loops come out desugared into the while
+// loops the interpreter runs, and redundant parentheses around single values are dropped.
+//
+// Nothing is printed if the source has errors.
+func Minify(source string, options Options, minify MinifyOptions) (string, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	scanner := NewScanner(bytes.NewBufferString(source), diagnostics)
+	tokens := scanner.ScanTokens()
+
+	parser := NewParser(tokens, diagnostics, options)
+	statements := parser.Parse()
+	if HasErrors(diagnostics.diagnostics) {
+		return "", diagnostics.diagnostics
+	}
+
+	_, resolveDiagnostics := Resolve(statements)
+	diagnostics.diagnostics = append(diagnostics.diagnostics, resolveDiagnostics...)
+	if HasErrors(diagnostics.diagnostics) {
+		return "", diagnostics.diagnostics
+	}
+
+	if minify.StripDeadCode {
+		statements = StripDeadCode(statements)
+	}
+
+	m := newMinifier(tokens, minify.RenameLocals)
+	for _, stmt := range statements {
+		stmt.Accept(m)
+	}
+
+	return m.out.String(), diagnostics.diagnostics
+}
+
+// MinifyOptions are the transformations Minify makes besides leaving out whitespace and
+// comments.
+type MinifyOptions struct {
+	// RenameLocals gives the variables declared with var inside blocks and functions the
+	// shortest names that don't clash with any identifier of the program. Globals,
+	// functions, classes and parameters keep their names, they can be seen from outside the
+	// program, by printing a function or by passing keyword arguments.
+	RenameLocals bool
+
+	// StripDeadCode leaves out the functions, classes and global variables the program
+	// never uses, see StripDeadCode.
+	StripDeadCode bool
+}
+
+// minifyScope maps the local variables declared in a scope to their new names. Names are
+// handed out by index, and a scope starts counting where its enclosing scope is at, so an
+// inner scope never reuses the name of a variable it can see.
+type minifyScope struct {
+	names map[string]string
+	next  int
+}
+
+// minifier prints the syntax tree as compact source.
+type minifier struct {
+	out          strings.Builder
+	renameLocals bool
+	scopes       []*minifyScope
+
+	// reserved are the keywords and every identifier of the program, short names are
+	// picked among the others.
+	reserved   map[string]bool
+	shortNames []string
+	candidates int
+}
+
+func newMinifier(tokens []ast.Token, renameLocals bool) *minifier {
+	reserved := make(map[string]bool)
+	for keyword := range keywords {
+		reserved[keyword] = true
+	}
+
+	for keyword := range exceptionKeywords {
+		reserved[keyword] = true
+	}
+
+	for _, token := range tokens {
+		if token.Type == ast.Identifiers {
+			reserved[token.Lexeme] = true
+		}
+	}
+
+	return &minifier{renameLocals: renameLocals, reserved: reserved}
+}
+
+// write appends a piece of source, with a space in front if it would otherwise run into
+// the previous identifier, keyword or number.
+func (m *minifier) write(piece string) {
+	if piece == "" {
+		return
+	}
+
+	last, _ := utf8.DecodeLastRuneInString(m.out.String())
+	first, _ := utf8.DecodeRuneInString(piece)
+	if isWordRune(last) && isWordRune(first) {
+		m.out.WriteByte(' ')
+	}
+
+	m.out.WriteString(piece)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func (m *minifier) beginScope() {
+	next := 0
+	if len(m.scopes) > 0 {
+		next = m.scopes[len(m.scopes)-1].next
+	}
+
+	m.scopes = append(m.scopes, &minifyScope{names: make(map[string]string), next: next})
+}
+
+func (m *minifier) endScope() {
+	m.scopes = m.scopes[:len(m.scopes)-1]
+}
+
+// declare adds the name to the innermost scope and returns the name to print. Only
+// variables are renamed, other declarations keep their name but still shadow the
+// variables of the enclosing scopes.
+func (m *minifier) declare(name string, rename bool) string {
+	renamed := m.newName(name, rename)
+	m.define(name, renamed)
+	return renamed
+}
+
+// newName picks the name a declaration is printed with, without declaring it yet.
+func (m *minifier) newName(name string, rename bool) string {
+	if len(m.scopes) == 0 || !rename || !m.renameLocals {
+		return name
+	}
+
+	scope := m.scopes[len(m.scopes)-1]
+	scope.next++
+	return m.shortName(scope.next - 1)
+}
+
+func (m *minifier) define(name, renamed string) {
+	if len(m.scopes) > 0 {
+		m.scopes[len(m.scopes)-1].names[name] = renamed
+	}
+}
+
+// lookup returns the new name of the variable, globals are never renamed.
+func (m *minifier) lookup(name string) string {
+	for i := len(m.scopes) - 1; i >= 0; i-- {
+		if renamed, ok := m.scopes[i].names[name]; ok {
+			return renamed
+		}
+	}
+
+	return name
+}
+
+// shortName returns the index-th shortest name that isn't reserved.
+func (m *minifier) shortName(index int) string {
+	for len(m.shortNames) <= index {
+		if name := nthIdentifier(m.candidates); !m.reserved[name] {
+			m.shortNames = append(m.shortNames, name)
+		}
+
+		m.candidates++
+	}
+
+	return m.shortNames[index]
+}
+
+// nthIdentifier enumerates the identifiers by length, the first character is a letter or
+// an underscore, the others can also be digits.
+func nthIdentifier(n int) string {
+	const first = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_"
+	const rest = first + "0123456789"
+
+	name := []byte{first[n%len(first)]}
+	n /= len(first)
+	for n > 0 {
+		n--
+		name = append(name, rest[n%len(rest)])
+		n /= len(rest)
+	}
+
+	return string(name)
+}
+
+func (m *minifier) expr(expr ast.Expr) {
+	expr.Accept(m)
+}
+
+func (m *minifier) VisitBlockStmt(stmt *ast.Block) error {
+	m.write("{")
+	m.beginScope()
+	for _, statement := range stmt.Statements {
+		statement.Accept(m)
+	}
+	m.endScope()
+	m.write("}")
+	return nil
+}
+
+func (m *minifier) VisitExpressionExpr(expr *ast.Expression) error {
+	m.expr(expr.Expression)
+	m.write(";")
+	return nil
+}
+
+func (m *minifier) VisitPrintExpr(expr *ast.Print) error {
+	m.write("print")
+	m.expr(expr.Expression)
+	m.write(";")
+	return nil
+}
+
+// VisitDestructureStmt picks the names first and declares them after the initializer,
+// like VisitVarStmt does.
+func (m *minifier) VisitDestructureStmt(stmt *ast.DestructureStmt) error {
+	names := make([]string, 0, len(stmt.Names))
+	for _, name := range stmt.Names {
+		names = append(names, m.newName(name.Lexeme, true))
+	}
+
+	m.write("var(" + strings.Join(names, ",") + ")=")
+	m.expr(stmt.Initializer)
+	for n, name := range stmt.Names {
+		m.define(name.Lexeme, names[n])
+	}
+
+	m.write(";")
+	return nil
+}
+
+// VisitVarStmt prints the initializer before declaring the variable, the initializer
+// still refers to the variables of the enclosing scopes.
+func (m *minifier) VisitVarStmt(stmt *ast.VarStmt) error {
+	m.write("var")
+	if stmt.Initializer == nil {
+		m.write(m.declare(stmt.Name.Lexeme, true))
+		m.write(";")
+		return nil
+	}
+
+	name := m.newName(stmt.Name.Lexeme, true)
+	m.write(name)
+	m.write("=")
+	m.expr(stmt.Initializer)
+	m.define(stmt.Name.Lexeme, name)
+	m.write(";")
+	return nil
+}
+
+func (m *minifier) VisitIfStmt(stmt *ast.IfStmt) error {
+	m.write("if(")
+	m.expr(stmt.Condition)
+	m.write(")")
+	stmt.ThenBranch.Accept(m)
+	if stmt.ElseBranch != nil {
+		m.write("else")
+		stmt.ElseBranch.Accept(m)
+	}
+
+	return nil
+}
+
+func (m *minifier) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	m.write("while(")
+	m.expr(stmt.Condition)
+	m.write(")")
+	stmt.Body.Accept(m)
+	return nil
+}
+
+func (m *minifier) VisitForInStmt(stmt *ast.ForInStmt) error {
+	m.beginScope()
+	name := m.newName(stmt.Name.Lexeme, true)
+	m.write("for(var")
+	m.write(name)
+	m.write("in")
+	m.expr(stmt.Collection)
+	m.write(")")
+
+	m.define(stmt.Name.Lexeme, name)
+	stmt.Body.Accept(m)
+	m.endScope()
+	return nil
+}
+
+func (m *minifier) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	m.write("fun")
+	m.function(m.declare(stmt.Name.Lexeme, false), stmt)
+	return nil
+}
+
+// function prints the name, parameters and body of a function or method.
+func (m *minifier) function(name string, stmt *ast.FunctionStmt) {
+	m.write(name)
+	m.write("(")
+	m.beginScope()
+	for i, param := range stmt.Params {
+		if i > 0 {
+			m.write(",")
+		}
+
+		m.write(m.declare(param.Lexeme, false))
+	}
+
+	m.write("){")
+	for _, statement := range stmt.Body {
+		statement.Accept(m)
+	}
+	m.endScope()
+	m.write("}")
+}
+
+func (m *minifier) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	m.write("return")
+	if stmt.Value != nil {
+		m.expr(stmt.Value)
+	}
+
+	m.write(";")
+	return nil
+}
+
+func (m *minifier) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	m.write("throw")
+	m.expr(stmt.Value)
+	m.write(";")
+	return nil
+}
+
+func (m *minifier) VisitTryStmt(stmt *ast.TryStmt) error {
+	m.write("try")
+	(&ast.Block{Statements: stmt.Body}).Accept(m)
+	if stmt.Catch != nil {
+		m.beginScope()
+		name := m.newName(stmt.Catch.Name.Lexeme, true)
+		m.write("catch(")
+		m.write(name)
+		m.write("){")
+		m.define(stmt.Catch.Name.Lexeme, name)
+		for _, statement := range stmt.Catch.Body {
+			statement.Accept(m)
+		}
+		m.endScope()
+		m.write("}")
+	}
+
+	if stmt.Finally != nil {
+		m.write("finally")
+		(&ast.Block{Statements: stmt.Finally}).Accept(m)
+	}
+
+	return nil
+}
+
+func (m *minifier) VisitClassStmt(stmt *ast.ClassStmt) error {
+	m.write("class")
+	m.write(m.declare(stmt.Name.Lexeme, false))
+	if stmt.Superclass != nil {
+		m.write("<")
+		m.expr(stmt.Superclass)
+	}
+
+	m.write("{")
+	for _, method := range stmt.Methods {
+		m.function(method.Name.Lexeme, method)
+	}
+
+	m.write("}")
+	return nil
+}
+
+// VisitBadStmt is never called, Minify doesn't print programs with parse errors.
+func (m *minifier) VisitBadStmt(stmt *ast.BadStmt) error {
+	return nil
+}
+
+func (m *minifier) VisitAssignExpr(expr *ast.Assign) (interface{}, error) {
+	m.write(m.lookup(expr.Name.Lexeme))
+	m.write("=")
+	m.expr(expr.Value)
+	return nil, nil
+}
+
+func (m *minifier) VisitLogicalExpr(expr *ast.Logical) (interface{}, error) {
+	m.expr(expr.Left)
+	m.write(expr.Operator.Lexeme)
+	m.expr(expr.Right)
+	return nil, nil
+}
+
+func (m *minifier) VisitBinaryExpr(expr *ast.Binary) (interface{}, error) {
+	m.expr(expr.Left)
+	m.write(expr.Operator.Lexeme)
+	m.expr(expr.Right)
+	return nil, nil
+}
+
+func (m *minifier) VisitCallExpr(expr *ast.Call) (interface{}, error) {
+	m.expr(expr.Callee)
+	m.write("(")
+	for i, argument := range expr.Arguments {
+		if i > 0 {
+			m.write(",")
+		}
+
+		m.expr(argument)
+	}
+
+	for i, argument := range expr.KeywordArguments {
+		if i > 0 || len(expr.Arguments) > 0 {
+			m.write(",")
+		}
+
+		m.write(argument.Name.Lexeme)
+		m.write(":")
+		m.expr(argument.Value)
+	}
+
+	m.write(")")
+	return nil, nil
+}
+
+// VisitGroupingExpr drops the parentheses around expressions that bind tighter than any
+// operator anyway.
+func (m *minifier) VisitGroupingExpr(expr *ast.Grouping) (interface{}, error) {
+	switch expr.Expression.(type) {
+	case *ast.Literal, *ast.VarExpr, *ast.ThisExpr, *ast.SuperExpr, *ast.Call, *ast.GetExpr, *ast.Grouping:
+		m.expr(expr.Expression)
+		return nil, nil
+	}
+
+	m.write("(")
+	m.expr(expr.Expression)
+	m.write(")")
+	return nil, nil
+}
+
+func (m *minifier) VisitLiteralExpr(expr *ast.Literal) (interface{}, error) {
+	switch value := expr.Value.(type) {
+	case nil:
+		m.write("nil")
+	case bool:
+		m.write(strconv.FormatBool(value))
+	case string:
+		m.write(`"` + value + `"`)
+	case float64:
+		if expr.Raw != "" {
+			m.write(expr.Raw)
+		} else {
+			m.write(strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *minifier) VisitUnaryExpr(expr *ast.Unary) (interface{}, error) {
+	m.write(expr.Operator.Lexeme)
+	m.expr(expr.Right)
+	return nil, nil
+}
+
+func (m *minifier) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
+	m.write(m.lookup(expr.Name.Lexeme))
+	return nil, nil
+}
+
+func (m *minifier) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	m.expr(expr.Object)
+	m.write(".")
+	m.write(expr.Name.Lexeme)
+	return nil, nil
+}
+
+func (m *minifier) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	m.expr(expr.Object)
+	m.write(".")
+	m.write(expr.Name.Lexeme)
+	m.write("=")
+	m.expr(expr.Value)
+	return nil, nil
+}
+
+func (m *minifier) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	m.write("this")
+	return nil, nil
+}
+
+func (m *minifier) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	m.write("super.")
+	m.write(expr.Method.Lexeme)
+	return nil, nil
+}
+
+func (m *minifier) VisitRangeExpr(expr *ast.Range) (interface{}, error) {
+	m.expr(expr.Start)
+	m.write(expr.Operator.Lexeme)
+	m.expr(expr.End)
+	return nil, nil
+}
+
+func (m *minifier) VisitArrayExpr(expr *ast.ArrayLiteral) (interface{}, error) {
+	m.write("[")
+	for i, element := range expr.Elements {
+		if i > 0 {
+			m.write(",")
+		}
+
+		m.expr(element)
+	}
+
+	m.write("]")
+	return nil, nil
+}
+
+func (m *minifier) VisitMapExpr(expr *ast.MapLiteral) (interface{}, error) {
+	m.write("{")
+	for n, key := range expr.Keys {
+		if n > 0 {
+			m.write(",")
+		}
+
+		m.expr(key)
+		m.write(":")
+		m.expr(expr.Values[n])
+	}
+
+	m.write("}")
+	return nil, nil
+}
+
+func (m *minifier) VisitTupleExpr(expr *ast.Tuple) (interface{}, error) {
+	m.write("(")
+	for i, element := range expr.Elements {
+		if i > 0 {
+			m.write(",")
+		}
+
+		m.expr(element)
+	}
+
+	m.write(")")
+	return nil, nil
+}
+
+func (m *minifier) VisitIndexGetExpr(expr *ast.IndexGet) (interface{}, error) {
+	m.expr(expr.Object)
+	m.write("[")
+	m.expr(expr.Index)
+	m.write("]")
+	return nil, nil
+}
+
+func (m *minifier) VisitIndexSetExpr(expr *ast.IndexSet) (interface{}, error) {
+	m.expr(expr.Object)
+	m.write("[")
+	m.expr(expr.Index)
+	m.write("]=")
+	m.expr(expr.Value)
+	return nil, nil
+}
+
+func (m *minifier) VisitComparisonExpr(expr *ast.Comparison) (interface{}, error) {
+	m.expr(expr.Operands[0])
+	for n, operator := range expr.Operators {
+		m.write(operator.Lexeme)
+		m.expr(expr.Operands[n+1])
+	}
+
+	return nil, nil
+}
+
+var _ ast.Visitor = &minifier{}
+var _ ast.StmtVisitor = &minifier{}
@@ -0,0 +1,96 @@
+package glox
+
+import "strings"
+
+// Minimize shrinks the source to a smaller one that is still interesting, for a bug report
+// that shows only what it takes to reproduce the bug. It's delta debugging over lines:
+// chunks of lines are removed while what's left stays interesting, with smaller and smaller
+// chunks down to single lines. interesting is called for every candidate, it must be
+// deterministic and return true for the source itself, otherwise the source is returned
+// as it is.
+func Minimize(source string, interesting func(candidate string) bool) string {
+	lines := strings.SplitAfter(source, "\n")
+	if !interesting(source) {
+		return source
+	}
+
+	chunks := 2
+	for len(lines) > 1 {
+		size := (len(lines) + chunks - 1) / chunks
+		reduced := false
+		for start := 0; start < len(lines); start += size {
+			end := start + size
+			if end > len(lines) {
+				end = len(lines)
+			}
+
+			candidate := append(append([]string{}, lines[:start]...), lines[end:]...)
+			if interesting(strings.Join(candidate, "")) {
+				lines = candidate
+				reduced = true
+				break
+			}
+		}
+
+		if reduced {
+			if chunks > 2 {
+				chunks--
+			}
+
+			continue
+		}
+
+		if chunks >= len(lines) {
+			break
+		}
+
+		chunks *= 2
+		if chunks > len(lines) {
+			chunks = len(lines)
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+// Outcome is how running a source ended, what tells the candidates of a minimized source
+// that still reproduce a bug from those that don't.
+type Outcome struct {
+	// Diagnostics are those of compiling the source, it didn't run if one is an error.
+	Diagnostics []Diagnostic
+	// Panicked is set if glox panicked, with the panic message in Panic.
+	Panicked bool
+	Panic    string
+	// Err is the runtime error the source stopped with.
+	Err error
+}
+
+// Failure describes how the source failed without saying where, so it stays the same as
+// lines are removed: the panic message, the message of the first compile error or of the
+// runtime error. It's empty if the source ran to the end.
+func (o Outcome) Failure() string {
+	if o.Panicked {
+		return "panic: " + o.Panic
+	}
+
+	for _, diagnostic := range o.Diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return "error: " + diagnostic.Message
+		}
+	}
+
+	if o.Err != nil {
+		return "runtime error: " + o.Err.Error()
+	}
+
+	return ""
+}
+
+// RunQuietly compiles and runs the source like Compile and Run do, without output or input,
+// and returns how it ended. Panics are recovered instead of crashing the caller, and the
+// run gives up with an interrupted error after a couple of seconds, as removing lines from a
+// source can leave a loop that never ends.
+func RunQuietly(source string, options Options) Outcome {
+	interpreter := newProgramInterpreter(options)
+	return runQuietly(source, options, interpreter.globals, interpreter)
+}
@@ -0,0 +1,20 @@
+package glox
+
+// RegisterModule defines a global instance named after the module with a field for each of
+// the functions, so scripts call them as db.query(...). The functions check their own
+// arguments, they accept any number of them. Registering a module again replaces it.
+func (r *Runtime) RegisterModule(name string, functions map[string]NativeFn) {
+	r.interpreter.defineModule(name, functions)
+}
+
+// defineModule defines the module in the globals.
+func (i *Interpreter) defineModule(name string, functions map[string]NativeFn) {
+	module := NewLoxInstance(NewLoxClass(name, nil, map[string]LoxFunction{}))
+
+	for function, fn := range functions {
+		qualified := name + "." + function
+		module.fields[function] = NewVariadicNativeFunction(qualified, qualified+"() is defined by the "+name+" module.", 0, VariadicArity, fn)
+	}
+
+	i.defineGlobal(name, module)
+}
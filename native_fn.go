@@ -1,17 +1,320 @@
 package glox
 
-import "time"
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
 
-type Clock struct{}
+// NativeFn is the signature of the go functions that back lox native functions.
+type NativeFn func(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
 
-func (c Clock) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+// nativeError is an error raised by a native function. Natives don't know where they are
+// called from, so the interpreter turns it into a runtime error at the call site.
+type nativeError struct {
+	message string
+}
+
+func (ne *nativeError) Error() string {
+	return ne.message
+}
+
+// NewNativeError creates an error that a native function returns to raise a runtime error
+// with the given message.
+func NewNativeError(message string) error {
+	return &nativeError{message: message}
+}
+
+// NativeFunction wraps a go function so it can be called from lox code. Along with the
+// function itself it carries the name and documentation that are shown to the user when
+// the function is printed or called incorrectly.
+type NativeFunction struct {
+	name     string
+	doc      string
+	arity    int
+	maxArity int
+	fn       NativeFn
+}
+
+func NewNativeFunction(name, doc string, arity int, fn NativeFn) *NativeFunction {
+	return &NativeFunction{name: name, doc: doc, arity: arity, maxArity: arity, fn: fn}
+}
+
+// NewVariadicNativeFunction creates a native function that accepts between minArity and
+// maxArity arguments. Passing VariadicArity as maxArity lifts the upper bound entirely.
+func NewVariadicNativeFunction(name, doc string, minArity, maxArity int, fn NativeFn) *NativeFunction {
+	return &NativeFunction{name: name, doc: doc, arity: minArity, maxArity: maxArity, fn: fn}
+}
+
+func (nf *NativeFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return nf.fn(interpreter, arguments)
+}
+
+func (nf *NativeFunction) Arity() int {
+	return nf.arity
+}
+
+func (nf *NativeFunction) MaxArity() int {
+	return nf.maxArity
+}
+
+func (nf *NativeFunction) Name() string {
+	return nf.name
+}
+
+func (nf *NativeFunction) Doc() string {
+	return nf.doc
+}
+
+func (nf *NativeFunction) String() string {
+	return "<native fn " + nf.name + ">"
+}
+
+// natives returns the native functions that are defined in the global environment of
+// every interpreter.
+func natives() []*NativeFunction {
+	return []*NativeFunction{
+		NewNativeFunction("clock", "clock() returns the number of seconds since the Unix epoch.", 0, recorded("clock", clock)),
+		NewNativeFunction("random", "random() returns a random number in [0, 1).", 0, recorded("random", random)),
+		NewNativeFunction("input", "input() reads a line from the standard input, without the line break, or returns nil at the end of the input.", 0, recorded("input", input)),
+		NewNativeFunction("callerName", "callerName() returns the name of the function that called the current function, or nil at the top level.", 0, callerName),
+		NewNativeFunction("error", "error(message) raises a runtime error with the message.", 1, raiseError),
+		NewNativeFunction("compose", "compose(f, g) returns a function that calls g and passes the result to f.", 2, compose),
+		NewNativeFunction("memoize", "memoize(f) returns a function that caches the results of f keyed on its arguments.", 1, memoize),
+		NewVariadicNativeFunction("partial", "partial(f, ...args) returns f with the given arguments bound before the arguments of each call.", 1, VariadicArity, partial),
+		NewNativeFunction("gcStats", "gcStats() returns the heap size, the number of garbage collections and, with --stats, the instances created and live per class.", 0, gcStats),
+		NewNativeFunction("stackTrace", "stackTrace() returns the current call stack as an array of {\"function\": name, \"line\": n} maps, innermost first.", 0, stackTrace),
+		NewNativeFunction("type", "type(value) returns the type of the value: \"number\", \"string\", \"bool\", \"nil\", \"function\", \"class\" or \"Name instance\".", 1, typeOf),
+		NewNativeFunction("className", "className(value) returns the name of the class of an instance, or of a class.", 1, className),
+		NewNativeFunction("superclassOf", "superclassOf(class) returns the superclass of the class, or nil if it has none.", 1, superclassOf),
+		NewNativeFunction("methodNames", "methodNames(class) returns the sorted names of the methods of a class or of the class of an instance, inherited ones included.", 1, methodNames),
+		NewNativeFunction("arity", "arity(f) returns the number of arguments the function or class takes, the least number if some are optional, or -1 if it takes any number.", 1, arity),
+		NewNativeFunction("len", "len(value) returns the number of elements of an array or a tuple, of entries of a map, or of characters of a string.", 1, length),
+		NewNativeFunction("ord", "ord(s) returns the Unicode code point of the single character string s.", 1, ord),
+		NewNativeFunction("chr", "chr(n) returns the string of the character with the Unicode code point n.", 1, chr),
+		NewNativeFunction("isNan", "isNan(n) returns true if the number is NaN, the result of 0 / 0.", 1, isNan),
+		NewNativeFunction("isInfinite", "isInfinite(n) returns true if the number is Infinity or -Infinity, the result of dividing by 0.", 1, isInfinite),
+		NewNativeFunction("seq", "seq(collection) returns a lazy sequence of the elements of a string or a collection.", 1, seq),
+		NewNativeFunction("countFrom", "countFrom(n) returns the infinite lazy sequence of the numbers from n in steps of one.", 1, countFrom),
+		NewNativeFunction("withCapturedOutput", "withCapturedOutput(f) calls f and returns everything it printed as a string instead of printing it.", 1, withCapturedOutput),
+	}
+}
+
+func clock(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
 	return float64(time.Now().Unix()), nil
 }
 
-func (c Clock) Arity() int {
-	return 0
+func random(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return rand.Float64(), nil
+}
+
+func input(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if interpreter.in == nil {
+		interpreter.in = bufio.NewReader(os.Stdin)
+	}
+
+	line, err := interpreter.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, nil
+	}
+
+	if err != nil && err != io.EOF {
+		return nil, NewNativeError("input() failed reading the standard input: " + err.Error())
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// gcStats describes the memory use as an instance with the fields heapBytes, collections
+// and classes. With --stats, classes has a field per class with the created and live counts
+// of its instances, otherwise it's nil.
+func gcStats(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := NewLoxInstance(NewLoxClass("gcStats", nil, map[string]LoxFunction{}))
+	stats.fields["heapBytes"] = float64(memStats.HeapAlloc)
+	stats.fields["collections"] = float64(memStats.NumGC)
+	stats.fields["classes"] = nil
+
+	if classStats := interpreter.ClassStats(); classStats != nil {
+		classes := NewLoxInstance(NewLoxClass("classStats", nil, map[string]LoxFunction{}))
+		for _, s := range classStats {
+			counts := NewLoxInstance(NewLoxClass("instanceCounts", nil, map[string]LoxFunction{}))
+			counts.fields["created"] = float64(s.Created)
+			counts.fields["live"] = float64(s.Live)
+			classes.fields[s.Name] = counts
+		}
+
+		stats.fields["classes"] = classes
+	}
+
+	return stats, nil
+}
+
+// raiseError fails the call, which turns into a runtime error at the call site.
+func raiseError(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return nil, NewNativeError(interpreter.stringify(arguments[0]))
+}
+
+// stackTrace returns the call stack of the caller as an array of maps with the function and
+// the line of every frame. The first frame is the call to stackTrace itself, so it's skipped.
+func stackTrace(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	frames := interpreter.CallStack()[1:]
+	trace := make([]interface{}, 0, len(frames))
+	for _, frame := range frames {
+		entry := NewLoxMap()
+		entry.Set("function", frame.Function)
+		entry.Set("line", float64(frame.Line))
+		trace = append(trace, entry)
+	}
+
+	return NewLoxArray(trace), nil
+}
+
+// callerName looks two frames up the stack, past the call to callerName and the call to
+// the current function.
+func callerName(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	frames := interpreter.CallStack()
+	if len(frames) < 3 {
+		return nil, nil
+	}
+
+	return frames[2].Function, nil
+}
+
+// length counts the elements of an array or a tuple, the entries of a map, or the characters
+// of a string like for-in loops iterate over them.
+func length(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case *LoxArray:
+		return float64(value.Len()), nil
+	case *LoxMap:
+		return float64(value.Len()), nil
+	case *LoxTuple:
+		return float64(value.Len()), nil
+	case string:
+		return float64(utf8.RuneCountInString(value)), nil
+	}
+
+	return nil, NewNativeError("len expects an array, a tuple, a map or a string")
+}
+
+// ord returns the code point of a string of a single character, counting characters like
+// len does.
+func ord(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	s, ok := arguments[0].(string)
+	if !ok || utf8.RuneCountInString(s) != 1 {
+		return nil, NewNativeError("ord expects a string of a single character")
+	}
+
+	r, _ := utf8.DecodeRuneInString(s)
+	return float64(r), nil
+}
+
+// chr returns the character with the code point, which must be a whole number that is a
+// valid code point.
+func chr(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	n, ok := toFloat64(arguments[0])
+	if !ok || n != float64(int64(n)) || n < 0 || n > unicode.MaxRune || (n >= 0xD800 && n <= 0xDFFF) {
+		return nil, NewNativeError("chr expects a whole number that is a Unicode code point")
+	}
+
+	return string(rune(n)), nil
 }
 
-func (c Clock) String() string {
-	return "<native fn>"
+// isNan reports if the number is NaN. It's the only way to tell, NaN isn't equal to
+// anything, itself included. Big numbers are never NaN.
+func isNan(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if _, ok := arguments[0].(*big.Float); ok {
+		return false, nil
+	}
+
+	n, ok := arguments[0].(float64)
+	if !ok {
+		return nil, NewNativeError("isNan expects a number")
+	}
+
+	return math.IsNaN(n), nil
+}
+
+// isInfinite reports if the number is positive or negative infinity.
+func isInfinite(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if n, ok := arguments[0].(*big.Float); ok {
+		return n.IsInf(), nil
+	}
+
+	n, ok := arguments[0].(float64)
+	if !ok {
+		return nil, NewNativeError("isInfinite expects a number")
+	}
+
+	return math.IsInf(n, 0), nil
+}
+
+// typeOf names the dynamic type of the value. Instances are named after their class, the
+// values wrapped for embedders after what they wrap.
+func typeOf(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case nil:
+		return "nil", nil
+	case bool:
+		return "bool", nil
+	case float64, *big.Float:
+		return "number", nil
+	case string:
+		return "string", nil
+	case *LoxInstance:
+		return value.klass.name + " instance", nil
+	case *LoxClass:
+		return "class", nil
+	case *LoxArray:
+		return "array", nil
+	case *LoxMap:
+		return "map", nil
+	case *LoxTuple:
+		return "tuple", nil
+	case LoxRange:
+		return "range", nil
+	case *LoxChannel:
+		return "channel", nil
+	case *LoxContext:
+		return "context", nil
+	case *LoxSequence:
+		return "sequence", nil
+	case LoxCallable:
+		return "function", nil
+	}
+
+	return fmt.Sprintf("%T", arguments[0]), nil
+}
+
+// withCapturedOutput calls the function with the interpreter's output redirected to a buffer
+// and returns what was printed. The output is restored even if the function fails.
+func withCapturedOutput(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	function, ok := arguments[0].(LoxCallable)
+	if !ok {
+		return nil, NewNativeError("withCapturedOutput expects a function as argument")
+	}
+
+	previous := interpreter.out
+	buffer := &bytes.Buffer{}
+	interpreter.out = buffer
+	defer func() { interpreter.out = previous }()
+
+	if _, err := callValue(interpreter, function, nil); err != nil {
+		return nil, err
+	}
+
+	return buffer.String(), nil
 }
@@ -0,0 +1,57 @@
+package glox
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStackTrace(t *testing.T) {
+	source := `
+fun inner() { return stackTrace(); }
+fun outer() {
+  return inner();
+}
+var trace = outer();
+print len(trace);
+for (var frame in trace) {
+  print frame["function"];
+  print frame["line"];
+}
+print stackTrace();
+`
+	program, diagnostics := Compile(source, DefaultOptions())
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	var out bytes.Buffer
+	if err := program.Run(&out); err != nil {
+		t.Fatalf("running the script: %s", err.Error())
+	}
+
+	expected := "2\ninner\n4\nouter\n6\n[]\n"
+	if out.String() != expected {
+		t.Errorf("printed %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestArity(t *testing.T) {
+	output, err := runSource(t, `
+fun add(a, b) { return a + b; }
+class Point { init(x, y) {} }
+print arity(add);
+print arity(Point);
+print arity(clock);
+print arity(partial(add, 1));
+print arity(partial);
+print arity(partial(partial, add));
+print arity(add.bindArgs);
+`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if expected := "2\n2\n0\n1\n-1\n-1\n-1\n"; output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
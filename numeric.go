@@ -0,0 +1,192 @@
+package glox
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// bigPrecision is the number of mantissa bits of the numbers in big number mode, a bit more
+// than 75 decimal digits.
+const bigPrecision = 256
+
+// bigDigits is the number of significant decimal digits numbers are printed with in big
+// number mode, fewer than the precision holds so rounding errors don't show.
+const bigDigits = 60
+
+// EnableBigNumbers makes every number literal evaluate to a *big.Float, so arithmetic is
+// exact for integers of any reasonable size and decimal fractions only round after many
+// more digits than a float64 has. Numbers from native functions are float64 and are
+// converted when they meet a big number.
+func (i *Interpreter) EnableBigNumbers() {
+	i.bigNumbers = true
+}
+
+// bigLiteral parses the source text of the number literal, once per literal.
+func (i *Interpreter) bigLiteral(expr *ast.Literal) (*big.Float, error) {
+	if value, ok := i.bigLiterals[expr]; ok {
+		return value, nil
+	}
+
+	value, _, err := big.ParseFloat(strings.NewReplacer("n", "", "_", "").Replace(expr.Raw), 10, bigPrecision, big.ToNearestEven)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.bigLiterals == nil {
+		i.bigLiterals = make(map[*ast.Literal]*big.Float)
+	}
+
+	i.bigLiterals[expr] = value
+	return value, nil
+}
+
+// newBigFloat returns a zero *big.Float with the precision of big number mode. The results
+// of arithmetic are always new values, a *big.Float is mutable and the operands may be
+// shared by variables or literals.
+func newBigFloat() *big.Float {
+	return new(big.Float).SetPrec(bigPrecision)
+}
+
+// bigOperands converts both operands to *big.Float if they are numbers and at least one of
+// them is a big number.
+func bigOperands(left, right interface{}) (*big.Float, *big.Float, bool) {
+	x, leftBig := left.(*big.Float)
+	y, rightBig := right.(*big.Float)
+	if !leftBig && !rightBig {
+		return nil, nil, false
+	}
+
+	if !leftBig {
+		f, ok := left.(float64)
+		if !ok {
+			return nil, nil, false
+		}
+
+		x = newBigFloat().SetFloat64(f)
+	}
+
+	if !rightBig {
+		f, ok := right.(float64)
+		if !ok {
+			return nil, nil, false
+		}
+
+		y = newBigFloat().SetFloat64(f)
+	}
+
+	return x, y, true
+}
+
+// bigBinary evaluates the binary operator on two big numbers. The operations that have no
+// number as their result, like 0 / 0 or the difference of two infinities, are runtime
+// errors because a *big.Float can't be NaN.
+func (i *Interpreter) bigBinary(operator ast.Token, x, y *big.Float) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(big.ErrNaN); !ok {
+				panic(r)
+			}
+
+			value, err = nil, NewRuntimeError(operator, "The result is not a number")
+		}
+	}()
+
+	switch operator.Type {
+	case ast.Greater:
+		return x.Cmp(y) > 0, nil
+	case ast.GreaterEqual:
+		return x.Cmp(y) >= 0, nil
+	case ast.Less:
+		return x.Cmp(y) < 0, nil
+	case ast.LessEqual:
+		return x.Cmp(y) <= 0, nil
+	case ast.BangEqual:
+		return x.Cmp(y) != 0, nil
+	case ast.EqualEqual:
+		return x.Cmp(y) == 0, nil
+	case ast.Minus:
+		return newBigFloat().Sub(x, y), nil
+	case ast.Plus:
+		return newBigFloat().Add(x, y), nil
+	case ast.Slash:
+		return newBigFloat().Quo(x, y), nil
+	case ast.Star:
+		return newBigFloat().Mul(x, y), nil
+	}
+
+	// unreachable
+	return nil, nil
+}
+
+// formatNumber prints a number the way lox does: whole numbers without a decimal point and
+// other numbers with as many digits as it takes to read them back exactly, 3.14 as 3.14 and
+// 0.1 + 0.2 as 0.30000000000000004. Numbers print in full from 1e-6 up to 1e21, and with an
+// exponent outside of that, like 1e+21 and 1e-7.
+func formatNumber(number float64) string {
+	switch {
+	case math.IsNaN(number):
+		return "NaN"
+	case math.IsInf(number, 1):
+		return "Infinity"
+	case math.IsInf(number, -1):
+		return "-Infinity"
+	case number == 0 || math.Abs(number) >= 1e-6 && math.Abs(number) < 1e21:
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	// Go pads the exponent to two digits, 1e-07, lox doesn't.
+	mantissa, exponent, found := strings.Cut(strconv.FormatFloat(number, 'e', -1, 64), "e")
+	if !found {
+		return mantissa
+	}
+
+	return mantissa + "e" + exponent[:1] + strings.TrimLeft(exponent[1:], "0")
+}
+
+// formatBigFloat prints integers with all their digits and other numbers with bigDigits
+// significant digits, without trailing zeros.
+func formatBigFloat(value *big.Float) string {
+	if value.IsInt() {
+		return value.Text('f', 0)
+	}
+
+	text := value.Text('g', bigDigits)
+	if strings.ContainsAny(text, "e") {
+		mantissa, exponent, _ := strings.Cut(text, "e")
+		return trimFraction(mantissa) + "e" + exponent
+	}
+
+	return trimFraction(text)
+}
+
+func trimFraction(text string) string {
+	if !strings.Contains(text, ".") {
+		return text
+	}
+
+	return strings.TrimSuffix(strings.TrimRight(text, "0"), ".")
+}
+
+// isNumber reports if the value is a number, a float64 or a big number.
+func isNumber(value interface{}) bool {
+	_, ok := toFloat64(value)
+	return ok
+}
+
+// toFloat64 returns the number as a float64, for native functions that need a Go number
+// whether or not big number mode is on.
+func toFloat64(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case *big.Float:
+		f, _ := value.Float64()
+		return f, true
+	}
+
+	return 0, false
+}
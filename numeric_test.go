@@ -0,0 +1,29 @@
+package glox
+
+import "testing"
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		number   float64
+		expected string
+	}{
+		{0, "0"},
+		{3.14, "3.14"},
+		{0.30000000000000004, "0.30000000000000004"},
+		{1e6, "1000000"},
+		{1234567.5, "1234567.5"},
+		{-1234567.5, "-1234567.5"},
+		{1e20, "100000000000000000000"},
+		{1e21, "1e+21"},
+		{1.5e300, "1.5e+300"},
+		{0.000001, "0.000001"},
+		{1e-7, "1e-7"},
+		{-2.5e-10, "-2.5e-10"},
+	}
+
+	for _, test := range tests {
+		if actual := formatNumber(test.number); actual != test.expected {
+			t.Errorf("formatNumber(%v) = %q, expected %q", test.number, actual, test.expected)
+		}
+	}
+}
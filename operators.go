@@ -0,0 +1,117 @@
+package glox
+
+import "github.com/iamsayantan/glox/ast"
+
+// precedence is how tightly a binary operator binds its operands. The higher levels bind
+// tighter, so a + b * c is a + (b * c).
+type precedence int
+
+// The precedence levels, from the loosest to the tightest:
+//
+//	pipe        |>                  left
+//	or          or                  left
+//	and         and                 left
+//	equality    == !=               left
+//	comparison  > >= < <= in        left
+//	range       .. ..=              none
+//	term        + -                 left
+//	factor      * /                 left
+//
+// Assignment binds looser than all of them, and the comma operator looser still. Unary
+// operators, calls and property accesses bind tighter. They are all parsed by their own
+// methods.
+const (
+	precedenceNone precedence = iota
+	precedencePipe
+	precedenceOr
+	precedenceAnd
+	precedenceEquality
+	precedenceComparison
+	precedenceRange
+	precedenceTerm
+	precedenceFactor
+)
+
+// binaryOperator is a row of the operator table the parser parses binary expressions with.
+// A new binary operator only needs a row here, and its semantics in the interpreter.
+type binaryOperator struct {
+	precedence precedence
+	// rightAssociative operators group to the right, a ^ b ^ c would be a ^ (b ^ c).
+	rightAssociative bool
+	// nonAssociative operators can't follow each other, a .. b .. c is an error.
+	nonAssociative bool
+	// node builds the syntax tree node of the operator, a Binary node if it's nil.
+	node func(left ast.Expr, operator ast.Token, right ast.Expr) ast.Expr
+}
+
+// binaryOperators returns the operator table for the language options. Extensions that add
+// operators add their rows here when they are enabled.
+func binaryOperators(options Options) map[ast.TokenType]binaryOperator {
+	operators := map[ast.TokenType]binaryOperator{
+		ast.Or:           {precedence: precedenceOr, node: logicalNode},
+		ast.And:          {precedence: precedenceAnd, node: logicalNode},
+		ast.EqualEqual:   {precedence: precedenceEquality},
+		ast.BangEqual:    {precedence: precedenceEquality},
+		ast.Greater:      {precedence: precedenceComparison},
+		ast.GreaterEqual: {precedence: precedenceComparison},
+		ast.Less:         {precedence: precedenceComparison},
+		ast.LessEqual:    {precedence: precedenceComparison},
+		ast.Plus:         {precedence: precedenceTerm},
+		ast.Minus:        {precedence: precedenceTerm},
+		ast.Slash:        {precedence: precedenceFactor},
+		ast.Star:         {precedence: precedenceFactor},
+	}
+
+	if options.ChainedComparisons {
+		for _, tokenType := range []ast.TokenType{ast.Greater, ast.GreaterEqual, ast.Less, ast.LessEqual} {
+			operators[tokenType] = binaryOperator{precedence: precedenceComparison, node: comparisonNode}
+		}
+	}
+
+	if options.Ranges {
+		operators[ast.DotDot] = binaryOperator{precedence: precedenceRange, nonAssociative: true, node: rangeNode}
+		operators[ast.DotDotEqual] = binaryOperator{precedence: precedenceRange, nonAssociative: true, node: rangeNode}
+	}
+
+	if options.PipeOperator {
+		operators[ast.Pipe] = binaryOperator{precedence: precedencePipe, node: pipeNode}
+	}
+
+	if options.InOperator {
+		operators[ast.In] = binaryOperator{precedence: precedenceComparison}
+	}
+
+	return operators
+}
+
+// logicalNode builds the node of the operators that short circuit.
+func logicalNode(left ast.Expr, operator ast.Token, right ast.Expr) ast.Expr {
+	return &ast.Logical{Left: left, Operator: operator, Right: right}
+}
+
+// comparisonNode builds a Binary node for a single comparison, and grows it into a
+// Comparison node when more comparisons follow. A grouped comparison, (a < b) < c, is not
+// part of the chain.
+func comparisonNode(left ast.Expr, operator ast.Token, right ast.Expr) ast.Expr {
+	switch left := left.(type) {
+	case *ast.Comparison:
+		return &ast.Comparison{Operands: append(left.Operands, right), Operators: append(left.Operators, operator)}
+	case *ast.Binary:
+		switch left.Operator.Type {
+		case ast.Greater, ast.GreaterEqual, ast.Less, ast.LessEqual:
+			return &ast.Comparison{Operands: []ast.Expr{left.Left, left.Right, right}, Operators: []ast.Token{left.Operator, operator}}
+		}
+	}
+
+	return &ast.Binary{Left: left, Operator: operator, Right: right}
+}
+
+// pipeNode desugars value |> f into the call f(value), so a |> f |> g is g(f(a)). The
+// right operand is only the callee, a |> f(b) calls what f(b) returns with a.
+func pipeNode(left ast.Expr, operator ast.Token, right ast.Expr) ast.Expr {
+	return &ast.Call{Callee: right, Paren: operator, Arguments: []ast.Expr{left}}
+}
+
+func rangeNode(left ast.Expr, operator ast.Token, right ast.Expr) ast.Expr {
+	return &ast.Range{Start: left, Operator: operator, End: right}
+}
@@ -1,16 +1,64 @@
 package glox
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
 type Parser struct {
 	// tokens is the list of tokens
-	tokens []Token
+	tokens []ast.Token
 	// current points to the next token to be consumed
 	current int
 
-	runtime *Runtime
+	reporter Reporter
+
+	// maxArguments is the limit on the number of parameters and arguments.
+	maxArguments int
+
+	// optionalSemicolons lets a line break terminate a statement in place of a ';'.
+	optionalSemicolons bool
+
+	// keywordArguments allows passing arguments by parameter name.
+	keywordArguments bool
+
+	// forIn allows for (var x in collection) loops.
+	forIn bool
+
+	// commaOperator allows sequences of expressions separated by commas.
+	commaOperator bool
+
+	// arrays allows array literals and indexing.
+	arrays bool
+
+	// maps allows map literals.
+	maps bool
+
+	// tuples allows tuples and destructuring declarations.
+	tuples bool
+
+	// bigLiterals allows number literals with an n suffix.
+	bigLiterals bool
+
+	// extendedNumbers allows scientific notation and digit separators in number literals.
+	extendedNumbers bool
+
+	// operators is the table of binary operators.
+	operators map[ast.TokenType]binaryOperator
+
+	// edition names the edition for errors about disabled extensions.
+	edition string
+
+	// sourceMap records the synthetic nodes created while desugaring.
+	sourceMap ast.SourceMap
 }
 
 type ParseError struct {
 	message string
+	// token is where the error was found.
+	token ast.Token
 }
 
 func NewParseError(message string) error {
@@ -21,99 +69,164 @@ func (pe ParseError) Error() string {
 	return pe.message
 }
 
-func NewParser(tokens []Token, runtime *Runtime) *Parser {
+// exceptionKeywords are the keywords of the exceptions extension. They are only keywords
+// when exceptions are enabled, so canonical programs can still use them as names.
+var exceptionKeywords = map[string]ast.TokenType{
+	"throw":   ast.Throw,
+	"try":     ast.Try,
+	"catch":   ast.Catch,
+	"finally": ast.Finally,
+}
+
+func NewParser(tokens []ast.Token, reporter Reporter, options Options) *Parser {
+	// The scanner already reported the invalid characters, the parser goes on as if they
+	// weren't there.
+	valid := make([]ast.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if token.Type == ast.Invalid {
+			continue
+		}
+
+		if keyword, ok := exceptionKeywords[token.Lexeme]; ok && options.Exceptions && token.Type == ast.Identifiers {
+			token.Type = keyword
+		}
+
+		valid = append(valid, token)
+	}
+
 	return &Parser{
-		tokens:  tokens,
-		current: 0,
-		runtime: runtime,
+		tokens:             valid,
+		current:            0,
+		reporter:           reporter,
+		maxArguments:       options.MaxArguments,
+		optionalSemicolons: options.OptionalSemicolons,
+		keywordArguments:   options.KeywordArguments,
+		forIn:              options.ForIn,
+		commaOperator:      options.CommaOperator,
+		arrays:             options.Arrays,
+		maps:               options.Maps,
+		tuples:             options.Tuples,
+		bigLiterals:        options.BigLiterals,
+		extendedNumbers:    options.ExtendedNumbers,
+		operators:          binaryOperators(options),
+		edition:            options.Edition,
+		sourceMap:          make(ast.SourceMap),
 	}
 }
 
-func (p *Parser) Parse() []Stmt {
-	statements := make([]Stmt, 0)
+// SourceMap returns the synthetic nodes created while parsing, mapped to the constructs
+// they were desugared from.
+func (p *Parser) SourceMap() ast.SourceMap {
+	return p.sourceMap
+}
+
+func (p *Parser) Parse() []ast.Stmt {
+	statements := make([]ast.Stmt, 0)
 	for !p.isAtEnd() {
-		expr, err := p.declaration()
+		start := p.current
+		stmt, err := p.declaration()
 		if err != nil {
-			return nil
+			stmt = p.badStatement(start, err)
 		}
 
-		statements = append(statements, expr)
+		statements = append(statements, stmt)
 	}
 
 	return statements
 }
 
+// ParseExpression parses a single expression that makes up all of the tokens. Unlike
+// statements, the expression doesn't need a terminating ';'.
+func (p *Parser) ParseExpression() (ast.Expr, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isAtEnd() {
+		return nil, p.error(p.peek(), "Expect end of expression")
+	}
+
+	return expr, nil
+}
+
 // declaration parses declaration statements. Any place where a declaration is allowed also
 // allowes non declaring statements, so the declaration rule falls through the statement.
 // declaration is called repeatedly when parsing a series of statements. If we get any error
-// while parsing, the parser tries to recover using synchronize and continue parsing the next
-// statements.
+// while parsing, Parse recovers with badStatement and continues parsing the next statements.
 // declaration --> classDecl
 // 				   | funcDeclaration
 //                 | varDecl
 // 				   | statement
-func (p *Parser) declaration() (Stmt, error) {
-	if p.match(Class) {
+func (p *Parser) declaration() (ast.Stmt, error) {
+	if p.match(ast.Class) {
 		return p.classDeclaration()
 	}
 
-	if p.match(Fun) {
+	if p.match(ast.Fun) {
 		return p.function("function")
 	}
 
-	if p.match(Var) {
-		stmt, err := p.varDeclaration()
-		if err != nil {
-			p.synchronize()
-			return nil, nil
-		}
-
-		return stmt, nil
+	if p.match(ast.Var) {
+		return p.varDeclaration()
 	}
 
 	return p.statement()
 }
 
+// badStatement recovers from an error in the top level statement starting at the start
+// token. It synchronizes the parser with the next statement and returns a BadStmt in place
+// of the statement, so the rest of the program can still be parsed and inspected.
+func (p *Parser) badStatement(start int, err error) ast.Stmt {
+	at := p.peek()
+	if parseErr, ok := err.(ParseError); ok {
+		at = parseErr.token
+	}
+
+	p.synchronize()
+	return &ast.BadStmt{From: p.tokens[start], At: at}
+}
+
 // classDeclaration parses a class syntax declaration.
 // classDecl --> "class" IDENTIFIER ( "<" IDENTIFIER)?
 //                "{" funcDeclaration "}"
-func (p *Parser) classDeclaration() (Stmt, error) {
-	name, err := p.consume(Identifiers, "Expect class name")
+func (p *Parser) classDeclaration() (ast.Stmt, error) {
+	name, err := p.consume(ast.Identifiers, "Expect class name")
 	if err != nil {
 		return nil, err
 	}
 
-	var superclass *VarExpr
-	if p.match(Less) {
-		_, err = p.consume(Identifiers, "Expect superclass name.")
+	var superclass *ast.VarExpr
+	if p.match(ast.Less) {
+		_, err = p.consume(ast.Identifiers, "Expect superclass name.")
 		if err != nil {
 			return nil, err
 		}
 
-		superclass = &VarExpr{Name: p.previous()}
+		superclass = &ast.VarExpr{Name: p.previous()}
 	}
 
-	_, err = p.consume(LeftBrace, "Expect '{' before class body.")
+	_, err = p.consume(ast.LeftBrace, "Expect '{' before class body.")
 	if err != nil {
 		return nil, err
 	}
 
-	var methods []*FunctionStmt
-	for !p.check(RightBrace) && !p.isAtEnd() {
+	var methods []*ast.FunctionStmt
+	for !p.check(ast.RightBrace) && !p.isAtEnd() {
 		method, err := p.function("method")
 		if err != nil {
 			return nil, err
 		}
 		
-		methods = append(methods, method.(*FunctionStmt))
+		methods = append(methods, method.(*ast.FunctionStmt))
 	}
 
-	_, err = p.consume(RightBrace, "Expect '}' after class body.")
+	_, err = p.consume(ast.RightBrace, "Expect '}' after class body.")
 	if err != nil {
 		return nil, err
 	}
 
-	return &ClassStmt{Name: name, Superclass: superclass, Methods: methods}, nil
+	return &ast.ClassStmt{Name: name, Superclass: superclass, Methods: methods}, nil
 }
 
 // function parses grammar for function declaration. Since we already matched and consumed
@@ -123,42 +236,44 @@ func (p *Parser) classDeclaration() (Stmt, error) {
 // We consume the { at the  beginning of the body before calling block, as block() assumes
 // brace token has already been consumed. And this way we cal provide a more precise error
 // message if the brace is not provided.
-func (p *Parser) function(kind string) (Stmt, error) {
-	name, err := p.consume(Identifiers, "Expect " + kind + " name")
+func (p *Parser) function(kind string) (ast.Stmt, error) {
+	name, err := p.consume(ast.Identifiers, "Expect " + kind + " name")
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.consume(LeftParen, "Expect '(' after " + kind + " name")
+	_, err = p.consume(ast.LeftParen, "Expect '(' after " + kind + " name")
 	if err != nil {
 		return nil, err
 	}
 
-	parameters := make([]Token, 0)
-	if !p.check(RightParen) {
+	parameters := make([]ast.Token, 0)
+	if !p.check(ast.RightParen) {
 		for {
-			if len(parameters) > 255 {
-				p.error(p.peek(), "Can't have more than 255 parameters")
-			}
-
-			param, err := p.consume(Identifiers, "Expect parameter name")
+			param, err := p.consume(ast.Identifiers, "Expect parameter name")
 			if err != nil {
 				return nil, err
 			}
 
 			parameters = append(parameters, param)
-			if !p.match(Comma) {
+			if !p.match(ast.Comma) {
 				break
 			}
 		}
 	}
 
-	_, err = p.consume(RightParen, "Expect ')' after parameters")
+	// We report too many parameters only once, after the whole list is parsed, and keep
+	// going. The parser is not in a confused state, so there is no need to synchronize.
+	if len(parameters) > p.maxArguments {
+		p.error(parameters[p.maxArguments], fmt.Sprintf("Can't have more than %d parameters in '%s'", p.maxArguments, name.Lexeme))
+	}
+
+	_, err = p.consume(ast.RightParen, "Expect ')' after parameters")
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.consume(LeftBrace, "Expect '{' before " + kind + " body")
+	_, err = p.consume(ast.LeftBrace, "Expect '{' before " + kind + " body")
 	if err != nil {
 		return nil, err
 	}
@@ -168,66 +283,118 @@ func (p *Parser) function(kind string) (Stmt, error) {
 		return nil, err
 	}
 
-	return &FunctionStmt{Name: name, Body: body, Params: parameters}, nil
+	return &ast.FunctionStmt{Name: name, Body: body, Params: parameters}, nil
 }
 
 // varDeclaration parses variable declaration syntax. When the parser matches a var
-// keyword, this method is used to parse that statement.
-// varDecl        → "var" IDENTIFIER ( "=" expression )? ";" ;
-func (p *Parser) varDeclaration() (Stmt, error) {
-	name, err := p.consume(Identifiers, "Expect a variable name")
+// keyword, this method is used to parse that statement. The initializer is an assignment
+// rather than a whole expression, so var a = 1, b = 2; is an error instead of quietly
+// being a sequence, as it declares two variables in C.
+// varDecl        → "var" IDENTIFIER ( "=" assignment )? ";"
+//                | "var" "(" IDENTIFIER ( "," IDENTIFIER )* ")" "=" assignment ";" ;
+func (p *Parser) varDeclaration() (ast.Stmt, error) {
+	if p.tuples && p.match(ast.LeftParen) {
+		return p.destructureDeclaration()
+	}
+
+	name, err := p.consume(ast.Identifiers, "Expect a variable name")
 	if err != nil {
 		return nil, err
 	}
 
-	var expr Expr
-	if p.match(Equal) {
-		expr, err = p.expression()
+	var expr ast.Expr
+	if p.match(ast.Equal) {
+		expr, err = p.assignment()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	_, err = p.consume(Semicolon, "Expect a ';' after variable declaration")
+	_, err = p.consumeTerminator("Expect a ';' after variable declaration")
 	if err != nil {
 		return nil, err
 	}
 
-	return &VarStmt{Name: name, Initializer: expr}, nil
+	return &ast.VarStmt{Name: name, Initializer: expr}, nil
+}
+
+// destructureDeclaration parses the rest of a destructuring declaration, after the '('.
+// The initializer is required, there is nothing to destructure otherwise.
+func (p *Parser) destructureDeclaration() (ast.Stmt, error) {
+	paren := p.previous()
+	names := make([]ast.Token, 0)
+	for {
+		name, err := p.consume(ast.Identifiers, "Expect a variable name")
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+		if !p.match(ast.Comma) {
+			break
+		}
+	}
+
+	if _, err := p.consume(ast.RightParen, "Expect ')' after variable names"); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.consume(ast.Equal, "Expect '=' after variable names, a destructuring declaration needs a value"); err != nil {
+		return nil, err
+	}
+
+	initializer, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.consumeTerminator("Expect a ';' after variable declaration"); err != nil {
+		return nil, err
+	}
+
+	return &ast.DestructureStmt{Paren: paren, Names: names, Initializer: initializer}, nil
 }
 
 // statement parses statements, a program can have multiple statements. Statements are
 // of two types, print statement and expression statement.
 // statement --> exprStmt
 //				| printStmt
-func (p *Parser) statement() (Stmt, error) {
-	if p.match(If) {
+func (p *Parser) statement() (ast.Stmt, error) {
+	if p.match(ast.If) {
 		return p.ifStatement()
 	}
 
-	if p.match(PRINT) {
+	if p.match(ast.PRINT) {
 		return p.printStatement()
 	}
 
-	if p.match(While) {
+	if p.match(ast.While) {
 		return p.whileStatement()
 	}
 
-	if p.match(For) {
+	if p.match(ast.For) {
 		return p.forStatement()
 	}
 
-	if p.match(Return) {
+	if p.match(ast.Return) {
 		return p.returnStatement()
 	}
 
-	if p.match(LeftBrace) {
+	if p.match(ast.Throw) {
+		return p.throwStatement()
+	}
+
+	if p.match(ast.Try) {
+		return p.tryStatement()
+	}
+
+	if p.match(ast.LeftBrace) {
 		stmt, err := p.block()
 		if err != nil {
 			return nil, err
 		}
 
-		return &Block{Statements: stmt}, nil
+		return &ast.Block{Statements: stmt}, nil
 	}
 
 	return p.expressionStatement()
@@ -237,39 +404,133 @@ func (p *Parser) statement() (Stmt, error) {
 // keyword, we look for a value expression. As many different tokens can start an expression, it's
 // hard to tell if return value is present. So instead, we look for it's absence. Since semicolon
 // can't begin an expression, if the next token is that, we know there must not be a value.
-func (p *Parser) returnStatement() (Stmt, error) {
+func (p *Parser) returnStatement() (ast.Stmt, error) {
 	keyword := p.previous()
-	var value Expr
+	var value ast.Expr
 	var err error
 	
-	if !p.check(Semicolon) {
+	if !p.check(ast.Semicolon) && !p.atImplicitTerminator() {
 		value, err = p.expression()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	_, err = p.consume(Semicolon, "Expect ';' after return value")
-	return &ReturnStmt{Keyword: keyword, Value: value}, nil
+	_, err = p.consumeTerminator("Expect ';' after return value")
+	return &ast.ReturnStmt{Keyword: keyword, Value: value}, nil
 }
 
-func (p *Parser) forStatement() (Stmt, error) {
-	_, err := p.consume(LeftParen, "Expect '(' after 'for'")
+// throwStatement parses a throw statement, after the throw keyword.
+// throwStmt --> "throw" expression ";"
+func (p *Parser) throwStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	value, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
-	var initializer Stmt = nil
-	var condition Expr = nil
-	var increment Expr = nil
+	_, err = p.consumeTerminator("Expect ';' after thrown value")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ThrowStmt{Keyword: keyword, Value: value}, nil
+}
+
+// tryStatement parses a try statement, after the try keyword. At least one of the catch
+// clause and the finally block must follow the try block.
+// tryStmt --> "try" block ( "catch" "(" IDENTIFIER ")" block )? ( "finally" block )?
+func (p *Parser) tryStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(ast.LeftBrace, "Expect '{' after 'try'")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &ast.TryStmt{Keyword: keyword, Body: body}
+	if p.match(ast.Catch) {
+		_, err = p.consume(ast.LeftParen, "Expect '(' after 'catch'")
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := p.consume(ast.Identifiers, "Expect exception variable name")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consume(ast.RightParen, "Expect ')' after exception variable name")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consume(ast.LeftBrace, "Expect '{' before catch body")
+		if err != nil {
+			return nil, err
+		}
+
+		catchBody, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.Catch = &ast.CatchClause{Name: name, Body: catchBody}
+	}
+
+	if p.match(ast.Finally) {
+		_, err = p.consume(ast.LeftBrace, "Expect '{' after 'finally'")
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.Finally, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		return nil, p.error(p.peek(), "Expect 'catch' or 'finally' after try block")
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) forStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	// Clauses without parentheses, as in Go, are reported and then parsed as if they were
+	// there, so the rest of the loop doesn't produce more errors.
+	parenthesized := p.match(ast.LeftParen)
+	if !parenthesized {
+		p.error(p.peek(), "Expect '(' after 'for', the clauses go in parentheses: for (var i = 0; i < 10; i = i + 1)")
+	}
+
+	var err error
+	if p.check(ast.RightParen) {
+		return nil, p.error(p.peek(), "Expect for clauses, did you mean 'for (;;)' to loop forever?")
+	}
+
+	if parenthesized && p.forIn && p.check(ast.Var) && p.peekAt(1).Type == ast.Identifiers && p.peekAt(2).Type == ast.Identifiers && p.peekAt(2).Lexeme == "in" {
+		return p.forInStatement(keyword)
+	}
+
+	var initializer ast.Stmt = nil
+	var condition ast.Expr = nil
+	var increment ast.Expr = nil
 
 	// If the token following the '(' is a semicolon, then the initializer
 	// has been omitted. Otherwise we check for the var keyword to see if
 	// it's a variable declaration. If none of those is matched, it must be
 	// an expression.
-	if p.match(Semicolon) {
+	if p.match(ast.Semicolon) {
 		// no need to do anything, initializer already is nil
-	} else if p.match(Var) {
+	} else if p.match(ast.Var) {
 		initializer, err = p.varDeclaration()
 		if err != nil {
 			return nil, err
@@ -281,28 +542,32 @@ func (p *Parser) forStatement() (Stmt, error) {
 		}
 	}
 
-	if !p.check(Semicolon) {
+	if !p.check(ast.Semicolon) {
 		condition, err = p.expression()
 		if err != nil {
 			return nil, err
 		}
+
+		p.checkCondition(condition)
 	}
 
-	_, err = p.consume(Semicolon, "Expect ';' after loop condition")
+	_, err = p.consume(ast.Semicolon, "Expect ';' after loop condition")
 	if err != nil {
 		return nil, err
 	}
 
-	if !p.check(RightParen) {
+	if !p.check(ast.RightParen) && (parenthesized || !p.check(ast.LeftBrace)) {
 		increment, err = p.expression()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	_, err = p.consume(RightParen, "Expect ')' after for clause")
-	if err != nil {
-		return nil, err
+	if parenthesized {
+		_, err = p.consume(ast.RightParen, "Expect ')' after for clause")
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	body, err := p.statement()
@@ -310,34 +575,68 @@ func (p *Parser) forStatement() (Stmt, error) {
 		return nil, err
 	}
 
+	// None of the nodes we create below exist in the source, we record them in the source
+	// map so they can be traced back to the for loop.
+	desugaring := ast.Desugaring{Construct: "for", Span: ast.Span{Start: keyword, End: p.previous()}}
+
 	// if increment is not nil, it executes after body in each iteration of the loop.
 	// And as the increment expression in the for loop does not produce any value, we
 	// convert it to an expression statement.
 	if increment != nil {
-		body = &Block{
-			Statements: []Stmt{body, &Expression{Expression: increment}},
+		incrementStmt := &ast.Expression{Expression: increment}
+		body = &ast.Block{
+			Statements: []ast.Stmt{body, incrementStmt},
 		}
+		p.sourceMap.Add(desugaring, body, incrementStmt)
 	}
 
 	// If the condition is omitted, we put in true to make it an infinite loop.
 	if condition == nil {
-		condition = &Literal{Value: True}
+		condition = ast.NewBoolLiteral(true)
+		p.sourceMap.Add(desugaring, condition)
 	}
 
 	// Now we take the condition and body and make it a primitive while loop.
-	body = &WhileStmt{Condition: condition, Body: body}
+	body = &ast.WhileStmt{Keyword: keyword, Condition: condition, Body: body}
+	p.sourceMap.Add(desugaring, body)
 
 	// Now if we have an initializer, it runs once before the body of the loop. We do that
 	// by creating a block that runs the initializer and then executes the loop.
 	if initializer != nil {
-		body = &Block{Statements: []Stmt{initializer, body}}
+		body = &ast.Block{Statements: []ast.Stmt{initializer, body}}
+		p.sourceMap.Add(desugaring, body)
 	}
 
 	return body, nil
 }
 
-func (p *Parser) whileStatement() (Stmt, error) {
-	_, err := p.consume(LeftParen, "Expect '(' after 'while'")
+// forInStatement parses the rest of a for (var name in collection) loop, after the '('.
+func (p *Parser) forInStatement(keyword ast.Token) (ast.Stmt, error) {
+	p.advance()
+	name := p.advance()
+	p.advance()
+
+	collection, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(ast.RightParen, "Expect ')' after for clause")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ForInStmt{Keyword: keyword, Name: name, Collection: collection, Body: body}, nil
+}
+
+func (p *Parser) whileStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(ast.LeftParen, "Expect '(' after 'while'")
 	if err != nil {
 		return nil, err
 	}
@@ -347,7 +646,9 @@ func (p *Parser) whileStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	_, err = p.consume(RightParen, "Expect ')' after condition")
+	p.checkCondition(condition)
+
+	_, err = p.consume(ast.RightParen, "Expect ')' after condition")
 	if err != nil {
 		return nil, err
 	}
@@ -357,16 +658,18 @@ func (p *Parser) whileStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	return &WhileStmt{Condition: condition, Body: body}, nil
+	return &ast.WhileStmt{Keyword: keyword, Condition: condition, Body: body}, nil
 }
 
-func (p *Parser) ifStatement() (Stmt, error) {
+func (p *Parser) ifStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
 	// The parenthesis around the if statement is only half useful. We need some kind of delimiter between
 	// the condition and the then statement, otherwise the parser can't tell when it has reached the end
 	// of the condition. But the opening parenthesis in the if condition doesn't do anything useful, it's
 	// only there because otherwise we'd end up with unbalanced parenthesis. Go requires the statement to
 	// be braced block, so the '{' acts as the end of the condition.
-	_, err := p.consume(LeftParen, "Expected '(' after 'if'")
+	_, err := p.consume(ast.LeftParen, "Expected '(' after 'if'")
 	if err != nil {
 		return nil, err
 	}
@@ -376,7 +679,9 @@ func (p *Parser) ifStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	_, err = p.consume(RightParen, "Expect ')' after if condition.")
+	p.checkCondition(condition)
+
+	_, err = p.consume(ast.RightParen, "Expect ')' after if condition.")
 	if err != nil {
 		return nil, err
 	}
@@ -386,22 +691,56 @@ func (p *Parser) ifStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	var elseBranch Stmt = nil
-	if p.match(Else) {
+	var elseBranch ast.Stmt = nil
+	if p.match(ast.Else) {
 		elseBranch, err = p.statement()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return &IfStmt{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}, nil
+	return &ast.IfStmt{Keyword: keyword, Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}, nil
+}
+
+// checkCondition warns about an assignment used as the condition of an if statement or a
+// loop, which is usually a comparison missing an '='. Wrapping the assignment in
+// parentheses says it's intended and silences the warning.
+func (p *Parser) checkCondition(condition ast.Expr) {
+	var name ast.Token
+	switch condition := condition.(type) {
+	case *ast.Assign:
+		name = condition.Name
+	case *ast.SetExpr:
+		name = condition.Name
+	default:
+		return
+	}
+
+	diagnostic := warningAt(phaseParser, name, "Assignment used as a condition, did you mean '=='?")
+	if equals, ok := p.tokenAfter(name, ast.Equal); ok {
+		diagnostic.Fix = replaceToken(equals, "==")
+	}
+
+	p.reporter.Report(diagnostic)
+}
+
+// tokenAfter finds the first token of the type after the token.
+func (p *Parser) tokenAfter(token ast.Token, tokenType ast.TokenType) (ast.Token, bool) {
+	for _, next := range p.tokens {
+		after := next.Line > token.Line || next.Line == token.Line && next.Column > token.Column
+		if after && next.Type == tokenType {
+			return next, true
+		}
+	}
+
+	return ast.Token{}, false
 }
 
 // block parses a block of statements when it encounters a '{'.
-func (p *Parser) block() ([]Stmt, error) {
-	statements := make([]Stmt, 0)
+func (p *Parser) block() ([]ast.Stmt, error) {
+	statements := make([]ast.Stmt, 0)
 
-	for !p.check(RightBrace) && !p.isAtEnd() {
+	for !p.check(ast.RightBrace) && !p.isAtEnd() {
 		stmt, err := p.declaration()
 		if err != nil {
 			return nil, err
@@ -410,7 +749,7 @@ func (p *Parser) block() ([]Stmt, error) {
 		statements = append(statements, stmt)
 	}
 
-	_, err := p.consume(RightBrace, "Expect '}' after block")
+	_, err := p.consume(ast.RightBrace, "Expect '}' after block")
 	if err != nil {
 		return nil, err
 	}
@@ -423,236 +762,260 @@ func (p *Parser) block() ([]Stmt, error) {
 // subsequent expression, consume the terminating semicolon and emit the
 // syntax tree.
 // printStmt --> "print" expression ";"
-func (p *Parser) printStatement() (Stmt, error) {
+func (p *Parser) printStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	// print is often called like a function, which only goes wrong when there is no value
+	// or more than one of them. With tuples, print (a, b) prints a tuple.
+	if p.check(ast.LeftParen) {
+		if p.checkNext(ast.RightParen) {
+			return nil, p.error(p.peekAt(1), "Expect a value to print, 'print' is a statement and not a function")
+		}
+
+		if comma, ok := p.groupingComma(); ok && !p.tuples {
+			return nil, p.error(comma, "'print' prints a single value, did you mean a print statement for each value?")
+		}
+	}
+
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.consume(Semicolon, "Expect ; after value.")
+	_, err = p.consumeTerminator("Expect ; after value.")
 	if err != nil {
 		return nil, err
 	}
 
-	return &Print{Expression: expr}, nil
+	return &ast.Print{Keyword: keyword, Expression: expr}, nil
+}
+
+// groupingComma returns the first comma directly inside the parentheses starting at the
+// current token.
+func (p *Parser) groupingComma() (ast.Token, bool) {
+	depth := 0
+	for offset := 0; ; offset++ {
+		token := p.peekAt(offset)
+		switch token.Type {
+		case ast.LeftParen:
+			depth++
+		case ast.RightParen:
+			depth--
+			if depth == 0 {
+				return ast.Token{}, false
+			}
+		case ast.Comma:
+			if depth == 1 {
+				return token, true
+			}
+		case ast.Semicolon, ast.Eof:
+			return ast.Token{}, false
+		}
+	}
 }
 
 // expressionStatement parses expression statements. It kind of acts like a
 // fallthrough condition. If we can't match with any known statements, we
 // assume it's a expression statement.
 // exprStmt --> expression ";";
-func (p *Parser) expressionStatement() (Stmt, error) {
+func (p *Parser) expressionStatement() (ast.Stmt, error) {
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.consume(Semicolon, "Expect ; after value.")
-	if err != nil {
-		return nil, err
+	if call, ok := expr.(*ast.Call); ok && p.check(ast.LeftBrace) && !p.atImplicitTerminator() {
+		if params, ok := callParams(call); ok {
+			return p.functionWithoutFun(call, params)
+		}
 	}
 
-	return &Expression{Expression: expr}, nil
-}
-
-// expression parses the grammar
-// expression --> assignment
-func (p *Parser) expression() (Expr, error) {
-	return p.assignment()
-}
-
-// assignment parses an assignment expression. First we parse the left hand side, which can be
-// any expression of higher precedence. If we find an '=',  we parse the right hand side and
-// then wrap it all up in an assignment tree node. The difference from other binary expressions
-// is that we don't loop to build up a sequence of same operator. Since assignment is right associative
-// we call assignment() recursively to parse the right hand side. Unlike getters, setters don't chain.
-// However the reference to call allows any high-precedence expression before the last dot, including
-// any number of getters.
-// breakfast.omelette.filling.meat = ham
-// assignment --> ( call ".")? IDENTIFIER "=" assignment
-// 				  | logic_or
-func (p *Parser) assignment() (Expr, error) {
-	expr, err := p.or()
+	_, err = p.consumeTerminator("Expect ; after value.")
 	if err != nil {
 		return nil, err
 	}
 
-	if p.match(Equal) {
-		equals := p.previous()
-		value, err := p.assignment()
-
-		if err != nil {
-			return nil, err
-		}
+	return &ast.Expression{Expression: expr}, nil
+}
 
-		// Before we create a assignment node, we look at the left hand side expression and figure out
-		// what kind of assignment target it is. If the left hand side is not a valid assignment target
-		// we report a syntax error. This makes sure that we report an error on code like a + b = c.
-		if variable, ok := expr.(*VarExpr); ok {
-			name := variable.Name
-			return &Assign{Name: name, Value: value}, nil
-		} else if getExpr, ok := expr.(*GetExpr); ok {
-			return &SetExpr{Object: getExpr.Object, Name: getExpr.Name, Value: value}, nil
-		} else {
-			p.error(equals, "Invalid assignment target")
-			return nil, nil
-		}
+// functionWithoutFun recovers from a function declared without the fun keyword, which is
+// parsed as a call followed by a block, e.g. add(a, b) { return a + b; }. The error is
+// reported and the rest is parsed as the body of the function, so the parser is not thrown
+// off by the body.
+func (p *Parser) functionWithoutFun(call *ast.Call, params []ast.Token) (ast.Stmt, error) {
+	name := call.Callee.(*ast.VarExpr).Name
+	lexemes := make([]string, 0, len(params))
+	for _, param := range params {
+		lexemes = append(lexemes, param.Lexeme)
 	}
 
-	return expr, nil
-}
+	p.error(name, fmt.Sprintf("Expect 'fun' before function declaration, did you mean 'fun %s(%s)'?", name.Lexeme, strings.Join(lexemes, ", ")))
 
-func (p *Parser) or() (Expr, error) {
-	expr, err := p.and()
+	p.advance()
+	body, err := p.block()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(Or) {
-		operator := p.previous()
-		right, err := p.and()
-		if err != nil {
-			return nil, err
-		}
-
-		expr = &Logical{Left: expr, Operator: operator, Right: right}
-	}
-
-	return expr, nil
+	return &ast.FunctionStmt{Name: name, Params: params, Body: body}, nil
 }
 
-func (p *Parser) and() (Expr, error) {
-	expr, err := p.equality()
-	if err != nil {
-		return nil, err
+// callParams returns the arguments of a call as parameters, if the call looks like a
+// function declaration, calling a name with only names for arguments.
+func callParams(call *ast.Call) ([]ast.Token, bool) {
+	if _, ok := call.Callee.(*ast.VarExpr); !ok || len(call.KeywordArguments) > 0 {
+		return nil, false
 	}
 
-	for p.match(And) {
-		operator := p.previous()
-		right, err := p.equality()
-		if err != nil {
-			return nil, err
+	params := make([]ast.Token, 0, len(call.Arguments))
+	for _, argument := range call.Arguments {
+		param, ok := argument.(*ast.VarExpr)
+		if !ok {
+			return nil, false
 		}
 
-		expr = &Logical{Left: expr, Operator: operator, Right: right}
+		params = append(params, param.Name)
 	}
 
-	return expr, nil
+	return params, true
 }
 
-// equality parses the grammar. It matches an equality and anything of higher precedence.
-// equality --> comparison ( ("==" | "!=") comparison )*
-func (p *Parser) equality() (Expr, error) {
-	expr, err := p.comparison()
+// expression parses the grammar. With the comma operator enabled, an expression is a
+// sequence of assignments separated by commas, evaluated left to right to the value of the
+// last one. Argument lists are separated by commas too, so arguments are parsed as
+// assignments and a sequence has to be wrapped in parentheses to be passed as one.
+// expression --> assignment ( "," assignment )*
+func (p *Parser) expression() (ast.Expr, error) {
+	expr, err := p.assignment()
 	if err != nil {
 		return nil, err
 	}
 
-	// if the control goes into this for loop, it means we have found
-	// a == or != operator and we are parsing an equality expression.
-	// Note that if equality does not match any equality operator, it
-	// essentially calls and returns comparison().
-	for p.match(Bang, BangEqual) {
-		// we grab the operator that has been consumed by match
-		operator := p.previous()
-
-		// calling comparison again to grab the right side of the operator
-		right, err := p.comparison()
+	for p.commaOperator && p.match(ast.Comma) {
+		comma := p.previous()
+		right, err := p.assignment()
 		if err != nil {
 			return nil, err
 		}
 
-		// then we combine the operator and the two operands to a new Binary
-		// syntax tree node.
-		expr = &Binary{expr, operator, right}
-
-		// Now we loop around to parse expression like this a == b == c == d == e.
-		// With each new iteration we create a new Binary expression with the previous
-		// expression as the left operand.
+		expr = &ast.Binary{Left: expr, Operator: comma, Right: right}
 	}
 
 	return expr, nil
 }
 
-// comparison matches a comparison expression or anything of higher precedence.
-// comparison --> term ( (">" | ">=" | "<" | "<=") term )*
-func (p *Parser) comparison() (Expr, error) {
-	expr, err := p.term()
+// assignment parses an assignment expression. First we parse the left hand side, which can be
+// any expression of higher precedence. If we find an '=',  we parse the right hand side and
+// then wrap it all up in an assignment tree node. The difference from other binary expressions
+// is that we don't loop to build up a sequence of same operator. Since assignment is right associative
+// we call assignment() recursively to parse the right hand side. Unlike getters, setters don't chain.
+// However the reference to call allows any high-precedence expression before the last dot, including
+// any number of getters.
+// breakfast.omelette.filling.meat = ham
+// assignment --> ( call ".")? IDENTIFIER "=" assignment
+// 				  | logic_or
+func (p *Parser) assignment() (ast.Expr, error) {
+	expr, err := p.binary(precedencePipe)
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(Greater, GreaterEqual, Less, LessEqual) {
-		operator := p.previous()
-		right, err := p.term()
+	if p.match(ast.Equal) {
+		equals := p.previous()
+		value, err := p.assignment()
 
 		if err != nil {
 			return nil, err
 		}
 
-		expr = &Binary{Left: expr, Operator: operator, Right: right}
+		// Before we create a assignment node, we look at the left hand side expression and figure out
+		// what kind of assignment target it is. If the left hand side is not a valid assignment target
+		// we report a syntax error. This makes sure that we report an error on code like a + b = c.
+		if variable, ok := expr.(*ast.VarExpr); ok {
+			name := variable.Name
+			return &ast.Assign{Name: name, Value: value}, nil
+		} else if getExpr, ok := expr.(*ast.GetExpr); ok {
+			return &ast.SetExpr{Object: getExpr.Object, Name: getExpr.Name, Value: value}, nil
+		} else if indexGet, ok := expr.(*ast.IndexGet); ok {
+			return &ast.IndexSet{Object: indexGet.Object, Bracket: indexGet.Bracket, Index: indexGet.Index, Value: value}, nil
+		} else {
+			p.error(equals, "Invalid assignment target")
+			return nil, nil
+		}
 	}
 
 	return expr, nil
 }
 
-// term matches a term expression or anything of higher precedence.
-// term --> factor ( ( "-" | "+" ) factor )*
-func (p *Parser) term() (Expr, error) {
-	expr, err := p.factor()
+// binary parses the binary operators, and anything of higher precedence, with precedence
+// climbing over the operator table. It parses an operand, then keeps folding in operators
+// as long as they bind at least as tightly as minimum. The right operand of an operator is
+// parsed with a minimum one level above the operator's own, so operators of the same level
+// group to the left, a - b - c is (a - b) - c, unless the operator is right associative.
+// binary --> unary ( operator unary )*
+func (p *Parser) binary(minimum precedence) (ast.Expr, error) {
+	expr, err := p.unary()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(Plus, Minus) {
-		operator := p.previous()
-		right, err := p.factor()
+	for {
+		operator, ok := p.operator(p.peek())
+		if !ok || operator.precedence < minimum {
+			return expr, nil
+		}
+
+		token := p.advance()
+		if token.Type == ast.Identifiers {
+			token.Type = ast.In
+		}
+		next := operator.precedence + 1
+		if operator.rightAssociative {
+			next = operator.precedence
+		}
 
+		right, err := p.binary(next)
 		if err != nil {
 			return nil, err
 		}
 
-		expr = &Binary{Left: expr, Operator: operator, Right: right}
-	}
-
-	return expr, nil
-}
-
-// factor parses a factor expression or anything of higher precedence.
-// factor --> unary ( ( "/" | "*" ) unary )*
-func (p *Parser) factor() (Expr, error) {
-	expr, err := p.unary()
-
-	if err != nil {
-		return nil, err
-	}
-
-	for p.match(Slash, Star) {
-		operator := p.previous()
-		right, err := p.unary()
+		if operator.node != nil {
+			expr = operator.node(expr, token, right)
+		} else {
+			expr = &ast.Binary{Left: expr, Operator: token, Right: right}
+		}
 
-		if err != nil {
-			return nil, err
+		if next, ok := p.operator(p.peek()); ok && operator.nonAssociative && next.precedence == operator.precedence {
+			return nil, p.error(p.peek(), "Expect parentheses around '"+token.Lexeme+"' operands, it can't be chained")
 		}
+	}
+}
 
-		expr = &Binary{Left: expr, Operator: operator, Right: right}
+// operator returns the row of the operator table for the token. 'in' is scanned as an
+// identifier, it's only the membership operator here, where an operand was just parsed, so
+// it's still a valid name everywhere else.
+func (p *Parser) operator(token ast.Token) (binaryOperator, bool) {
+	if token.Type == ast.Identifiers && token.Lexeme == "in" {
+		operator, ok := p.operators[ast.In]
+		return operator, ok
 	}
 
-	return expr, nil
+	operator, ok := p.operators[token.Type]
+	return operator, ok
 }
 
 // unary parses an unary expression and primary expression.
 // unary --> ( "!" | "-" ) unary
 //			 | call
-func (p *Parser) unary() (Expr, error) {
-	if p.match(Bang, Minus) {
+func (p *Parser) unary() (ast.Expr, error) {
+	if p.match(ast.Bang, ast.Minus) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
 			return nil, err
 		}
 
-		return &Unary{Operator: operator, Right: right}, nil
+		return &ast.Unary{Operator: operator, Right: right}, nil
 	}
 
 	return p.call()
@@ -663,25 +1026,30 @@ func (p *Parser) unary() (Expr, error) {
 // The * in the grammar allows calls like fn(1)(2)(3) function calls. The for loop corresponds with
 // the * in the grammer rule. We zip along the tokens building up a chain of calls and gets as we
 // find parentheses and dots: egg.scramble(3).with(cheddar)
-// call --> primary ( "(" arguments? ")" | "." IDENTIFIER )*;
-func (p *Parser) call() (Expr, error) {
+// call --> primary ( "(" arguments? ")" | "." IDENTIFIER | "[" expression "]" )*;
+func (p *Parser) call() (ast.Expr, error) {
 	expr, err := p.primary()
 	if err != nil {
 		return nil, err
 	}
 
 	for {
-		if p.match(LeftParen) {
+		if p.match(ast.LeftParen) {
 			expr, err = p.finishCall(expr)
 			if err != nil {
 				return nil, err
 			}
-		} else if p.match(Dot) {
-			name, err := p.consume(Identifiers, "Expect property name after '.'")
+		} else if p.match(ast.Dot) {
+			name, err := p.consume(ast.Identifiers, "Expect property name after '.'")
+			if err != nil {
+				return nil, err
+			}
+			expr = &ast.GetExpr{Name: name, Object: expr}
+		} else if p.match(ast.LeftBracket) {
+			expr, err = p.finishIndex(expr)
 			if err != nil {
 				return nil, err
 			}
-			expr = &GetExpr{Name: name, Object: expr}
 		} else {
 			break
 		}
@@ -692,95 +1060,197 @@ func (p *Parser) call() (Expr, error) {
 
 // finishCall is a helper that parses the function arguments. This is more or less
 // the grammar for arguments. Except we also check the zero argument condition. If
-// we find the ')' as the next token, we don't parse any expression.
-// arguments --> expression ( "," expression )*;
-func (p *Parser) finishCall(callee Expr) (Expr, error) {
-	arguments := make([]Expr, 0)
-	if !p.check(RightParen) {
+// we find the ')' as the next token, we don't parse any expression. An argument that
+// starts with an identifier followed by ':' is passed by parameter name, once we have
+// seen one of those every following argument must be passed by name too.
+// arguments --> argument ( "," argument )*;
+// argument  --> ( IDENTIFIER ":" )? expression;
+func (p *Parser) finishCall(callee ast.Expr) (ast.Expr, error) {
+	arguments := make([]ast.Expr, 0)
+	keywordArguments := make([]ast.KeywordArgument, 0)
+	if !p.check(ast.RightParen) {
 		for {
-			expr, err := p.expression()
-			if err != nil {
-				return nil, err
+			if p.check(ast.Identifiers) && p.checkNext(ast.Colon) {
+				name := p.advance()
+				p.advance()
+
+				if !p.keywordArguments {
+					p.error(name, "Keyword arguments are not enabled in the "+p.edition+" edition")
+				}
+
+				for _, argument := range keywordArguments {
+					if argument.Name.Lexeme == name.Lexeme {
+						p.error(name, "Duplicate keyword argument '"+name.Lexeme+"'")
+					}
+				}
+
+				value, err := p.assignment()
+				if err != nil {
+					return nil, err
+				}
+
+				keywordArguments = append(keywordArguments, ast.KeywordArgument{Name: name, Value: value})
+			} else {
+				if len(keywordArguments) > 0 {
+					p.error(p.peek(), "Positional argument can't follow keyword arguments")
+				}
+
+				expr, err := p.assignment()
+				if err != nil {
+					return nil, err
+				}
+
+				arguments = append(arguments, expr)
 			}
 
-			if len(arguments) >= 255 {
-				p.error(p.peek(), "Can't have more than 255 arguments.")
-			}
-
-			arguments = append(arguments, expr)
-			if !p.match(Comma) {
+			if !p.match(ast.Comma) {
 				break
 			}
 		}
 	}
 
-	paren, err := p.consume(RightParen, "Expect ')' after arguments")
+	paren, err := p.consume(ast.RightParen, "Expect ')' after arguments")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(arguments)+len(keywordArguments) > p.maxArguments {
+		p.error(paren, fmt.Sprintf("Can't have more than %d arguments calling '%s'", p.maxArguments, calleeName(callee)))
+	}
+
+	return &ast.Call{Callee: callee, Paren: paren, Arguments: arguments, KeywordArguments: keywordArguments}, nil
+}
+
+// finishIndex parses the index of an indexing expression, after the '['. The closing
+// bracket is kept as the token of the node, for errors about the index.
+func (p *Parser) finishIndex(object ast.Expr) (ast.Expr, error) {
+	if !p.arrays {
+		p.error(p.previous(), "Arrays are not enabled in the "+p.edition+" edition")
+	}
+
+	index, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Call{Callee: callee, Paren: paren, Arguments: arguments}, nil
+	bracket, err := p.consume(ast.RightBracket, "Expect ']' after index")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.IndexGet{Object: object, Bracket: bracket, Index: index}, nil
+}
+
+// calleeName returns a name for the callee of a call to use in error messages.
+func calleeName(callee ast.Expr) string {
+	switch callee := callee.(type) {
+	case *ast.VarExpr:
+		return callee.Name.Lexeme
+	case *ast.GetExpr:
+		return callee.Name.Lexeme
+	case *ast.SuperExpr:
+		return callee.Method.Lexeme
+	}
+
+	return "function"
 }
 
 // primary parses the primary expressions, these are of highest level of precedence.
 // primary --> NUMBER | STRING | "true" | "false" | "nil" | "this"
 //            | "(" expression ")" | IDENTIFIER
-//            | "super" "." IDENTIFIER;
-func (p *Parser) primary() (Expr, error) {
-	if p.match(False) {
-		return &Literal{Value: false}, nil
+//            | "super" "." IDENTIFIER
+//            | "[" ( assignment ( "," assignment )* )? "]"
+//            | "{" ( assignment ":" assignment ( "," assignment ":" assignment )* )? "}";
+func (p *Parser) primary() (ast.Expr, error) {
+	if p.match(ast.False) {
+		return ast.NewBoolLiteral(false), nil
 	}
 
-	if p.match(True) {
-		return &Literal{Value: true}, nil
+	if p.match(ast.True) {
+		return ast.NewBoolLiteral(true), nil
 	}
 
-	if p.match(Nil) {
-		return &Literal{Value: nil}, nil
+	if p.match(ast.Nil) {
+		return ast.NewNilLiteral(), nil
 	}
 
-	if p.match(String, Number) {
-		return &Literal{Value: p.previous().Literal}, nil
+	if p.match(ast.String, ast.Number) {
+		literal := ast.NewTokenLiteral(p.previous())
+		if literal.Big && !p.bigLiterals {
+			p.error(p.previous(), "Big number literals are not enabled in the "+p.edition+" edition")
+		}
+
+		if strings.ContainsAny(literal.Raw, "eE_") && !p.extendedNumbers {
+			p.error(p.previous(), "Scientific notation and digit separators are not enabled in the "+p.edition+" edition")
+		}
+
+		return literal, nil
 	}
 
-	if p.match(Super) {
+	if p.match(ast.Super) {
 		keword := p.previous()
 		
-		_, err := p.consume(Dot, "Expect '.' after 'super'")
+		_, err := p.consume(ast.Dot, "Expect '.' after 'super'")
 		if err != nil {
 			return nil, err
 		}
 
-		method, err := p.consume(Identifiers, "Expect superclass method name")
+		method, err := p.consume(ast.Identifiers, "Expect superclass method name")
 		if err != nil {
 			return nil, err
 		}
 
-		return &SuperExpr{Method: method, Keyword: keword}, nil
+		return &ast.SuperExpr{Method: method, Keyword: keword}, nil
 	}
 
-	if p.match(This) {
-		return &ThisExpr{Keyword: p.previous()}, nil
+	if p.match(ast.This) {
+		return &ast.ThisExpr{Keyword: p.previous()}, nil
 	}
 
-	if p.match(Identifiers) {
-		return &VarExpr{Name: p.previous()}, nil
+	if p.match(ast.Identifiers) {
+		return &ast.VarExpr{Name: p.previous()}, nil
 	}
 
 	// if we find a '(' token during parsing, we must find a ')' too
 	// after the expression, otherwise its an error.
-	if p.match(LeftParen) {
+	if p.tuples && p.match(ast.LeftParen) {
+		return p.tupleOrGrouping()
+	}
+
+	if p.match(ast.LeftParen) {
 		expression, err := p.expression()
 		if err != nil {
 			return nil, err
 		}
 
-		_, err = p.consume(RightParen, "Expect ')' after expression.")
+		_, err = p.consume(ast.RightParen, "Expect ')' after expression.")
 		if err != nil {
 			return nil, err
 		}
 
-		return &Grouping{Expression: expression}, nil
+		return &ast.Grouping{Expression: expression}, nil
+	}
+
+	if p.match(ast.LeftBracket) {
+		return p.arrayLiteral()
+	}
+
+	// A '{' that starts a statement is parsed as a block before an expression statement is
+	// tried, so one here, where a value is expected, can only start a map.
+	if p.match(ast.LeftBrace) {
+		return p.mapLiteral()
+	}
+
+	if p.check(ast.PRINT) {
+		return nil, p.error(p.peek(), "'print' is a statement and can't be used as a value, did you mean 'print value;'?")
+	}
+
+	// A binary operator without a left operand is reported, then its right operand is parsed
+	// and stands in for the whole expression. The parser isn't confused, so it goes on
+	// without synchronizing. '-' is left out as it's also a unary operator.
+	if operator, ok := p.operators[p.peek().Type]; ok && !p.check(ast.Minus) {
+		p.error(p.advance(), "Missing left-hand operand")
+		return p.binary(operator.precedence + 1)
 	}
 
 	// The parser has descent down from the initial expression grammer to
@@ -791,11 +1261,112 @@ func (p *Parser) primary() (Expr, error) {
 	return nil, p.error(p.peek(), "Expect Expression")
 }
 
+// tupleOrGrouping parses a parenthesized expression when tuples are enabled, after the
+// '('. A comma after the first element makes it a tuple, with the elements parsed as
+// assignments like array elements, otherwise it's a grouping.
+func (p *Parser) tupleOrGrouping() (ast.Expr, error) {
+	paren := p.previous()
+	first, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []ast.Expr{first}
+	for p.match(ast.Comma) {
+		element, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, element)
+	}
+
+	if _, err := p.consume(ast.RightParen, "Expect ')' after expression."); err != nil {
+		return nil, err
+	}
+
+	if len(elements) == 1 {
+		return &ast.Grouping{Expression: first}, nil
+	}
+
+	return &ast.Tuple{Paren: paren, Elements: elements}, nil
+}
+
+// arrayLiteral parses the elements of an array literal, after the '['. The elements are
+// assignments, as a comma separates the elements rather than being the comma operator.
+func (p *Parser) arrayLiteral() (ast.Expr, error) {
+	bracket := p.previous()
+	if !p.arrays {
+		p.error(bracket, "Arrays are not enabled in the "+p.edition+" edition")
+	}
+
+	elements := make([]ast.Expr, 0)
+	if !p.check(ast.RightBracket) {
+		for {
+			element, err := p.assignment()
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, element)
+			if !p.match(ast.Comma) {
+				break
+			}
+		}
+	}
+
+	if _, err := p.consume(ast.RightBracket, "Expect ']' after array elements"); err != nil {
+		return nil, err
+	}
+
+	return &ast.ArrayLiteral{Bracket: bracket, Elements: elements}, nil
+}
+
+// mapLiteral parses the entries of a map literal, after the '{'.
+func (p *Parser) mapLiteral() (ast.Expr, error) {
+	brace := p.previous()
+	if !p.maps {
+		p.error(brace, "Maps are not enabled in the "+p.edition+" edition")
+	}
+
+	keys := make([]ast.Expr, 0)
+	values := make([]ast.Expr, 0)
+	if !p.check(ast.RightBrace) {
+		for {
+			key, err := p.assignment()
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := p.consume(ast.Colon, "Expect ':' after map key"); err != nil {
+				return nil, err
+			}
+
+			value, err := p.assignment()
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+			values = append(values, value)
+			if !p.match(ast.Comma) {
+				break
+			}
+		}
+	}
+
+	if _, err := p.consume(ast.RightBrace, "Expect '}' after map entries"); err != nil {
+		return nil, err
+	}
+
+	return &ast.MapLiteral{Brace: brace, Keys: keys, Values: values}, nil
+}
+
 // match checks to see if the current token has any of the given
 // types provided as parameter, if it matches it consumes the token
 // and returns true. Otherwise it leaves the current token alone
 // and return false.
-func (p *Parser) match(tokenTypes ...TokenType) bool {
+func (p *Parser) match(tokenTypes ...ast.TokenType) bool {
 	for _, tokenType := range tokenTypes {
 		if p.check(tokenType) {
 			p.advance()
@@ -808,7 +1379,7 @@ func (p *Parser) match(tokenTypes ...TokenType) bool {
 
 // check method returns if the current token matches the given type.
 // It does not consume the token though, just looks at it.
-func (p *Parser) check(tokenType TokenType) bool {
+func (p *Parser) check(tokenType ast.TokenType) bool {
 	if p.isAtEnd() {
 		return false
 	}
@@ -816,8 +1387,17 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().Type == tokenType
 }
 
+// checkNext returns if the token after the current one matches the given type.
+func (p *Parser) checkNext(tokenType ast.TokenType) bool {
+	if p.isAtEnd() || p.tokens[p.current+1].Type == ast.Eof {
+		return false
+	}
+
+	return p.tokens[p.current+1].Type == tokenType
+}
+
 // advance consumes the current token and returns it.
-func (p *Parser) advance() Token {
+func (p *Parser) advance() ast.Token {
 	if !p.isAtEnd() {
 		p.current++
 	}
@@ -825,32 +1405,110 @@ func (p *Parser) advance() Token {
 	return p.previous()
 }
 
-func (p *Parser) consume(tokenType TokenType, message string) (Token, error) {
+func (p *Parser) consume(tokenType ast.TokenType, message string) (ast.Token, error) {
 	if p.check(tokenType) {
 		return p.advance(), nil
 	}
 
-	return Token{}, p.error(p.peek(), message)
+	return ast.Token{}, p.errorWithFix(p.peek(), message, p.missingFix(tokenType))
+}
+
+// consumeTerminator consumes the ';' that terminates a statement. When optional semicolons
+// are enabled, the statement may instead be terminated by a line break, a '}' closing the
+// enclosing block or the end of the input. Since the statement has already been parsed,
+// the expression before the terminator is always complete.
+func (p *Parser) consumeTerminator(message string) (ast.Token, error) {
+	if p.check(ast.Semicolon) {
+		return p.advance(), nil
+	}
+
+	if p.atImplicitTerminator() {
+		return p.previous(), nil
+	}
+
+	return ast.Token{}, p.errorWithFix(p.peek(), message, p.missingFix(ast.Semicolon))
+}
+
+// missingFix returns the fix inserting the missing ';', ')' or '}' after the previous
+// token, when the current token makes it clear that's where it belongs: a ';' or ')'
+// missing at the end of a line, or a '}' missing at the end of the input. It's nil when
+// the token is missing somewhere less obvious.
+func (p *Parser) missingFix(tokenType ast.TokenType) *Fix {
+	if p.current == 0 {
+		return nil
+	}
+
+	previous, next := p.previous(), p.peek()
+	endOfLine := p.isAtEnd() || next.Line > previous.Line
+
+	switch tokenType {
+	case ast.Semicolon:
+		if endOfLine || next.Type == ast.RightBrace {
+			return insertAfter(previous, ";")
+		}
+	case ast.RightParen:
+		if endOfLine || next.Type == ast.Semicolon || next.Type == ast.LeftBrace {
+			return insertAfter(previous, ")")
+		}
+	case ast.RightBrace:
+		if p.isAtEnd() {
+			return insertAfter(previous, "\n}")
+		}
+	}
+
+	return nil
+}
+
+// atImplicitTerminator reports if, with optional semicolons enabled, the statement ends
+// right before the current token.
+func (p *Parser) atImplicitTerminator() bool {
+	if !p.optionalSemicolons {
+		return false
+	}
+
+	return p.isAtEnd() || p.check(ast.RightBrace) || p.peek().Line > p.previous().Line
 }
 
 // isAtEnd checks if we have run out of tokens to parse.
 func (p *Parser) isAtEnd() bool {
-	return p.peek().Type == Eof
+	return p.peek().Type == ast.Eof
 }
 
 // peek returns the current token we are yet to consume.
-func (p *Parser) peek() Token {
+func (p *Parser) peek() ast.Token {
 	return p.tokens[p.current]
 }
 
+// peekAt returns the token offset tokens after the current one, or the Eof token if the
+// tokens end before that.
+func (p *Parser) peekAt(offset int) ast.Token {
+	if p.current+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.current+offset]
+}
+
 // previous returns the most recent token that has been consumed.
-func (p *Parser) previous() Token {
+func (p *Parser) previous() ast.Token {
 	return p.tokens[p.current-1]
 }
 
-func (p *Parser) error(token Token, message string) error {
-	p.runtime.tokenError(token, message)
-	return NewParseError(message)
+// warning reports a warning at the token. Unlike errors, warnings don't stop the parser.
+func (p *Parser) warning(token ast.Token, message string) {
+	p.reporter.Report(warningAt(phaseParser, token, message))
+}
+
+func (p *Parser) error(token ast.Token, message string) error {
+	return p.errorWithFix(token, message, nil)
+}
+
+// errorWithFix reports an error that the fix resolves, fix may be nil.
+func (p *Parser) errorWithFix(token ast.Token, message string, fix *Fix) error {
+	diagnostic := errorAt(phaseParser, token, message)
+	diagnostic.Fix = fix
+	p.reporter.Report(diagnostic)
+	return ParseError{message: message, token: token}
 }
 
 // synchronize synchronizes the parser state in case of encountering an error.
@@ -862,12 +1520,12 @@ func (p *Parser) synchronize() {
 	p.advance()
 
 	for !p.isAtEnd() {
-		if p.previous().Type == Semicolon {
+		if p.previous().Type == ast.Semicolon {
 			return
 		}
 
 		switch p.peek().Type {
-		case Class, Fun, Var, For, If, While, PRINT, Return:
+		case ast.Class, ast.Fun, ast.Var, ast.For, ast.If, ast.While, ast.PRINT, ast.Return, ast.Throw, ast.Try:
 			return
 		}
 
@@ -0,0 +1,99 @@
+package glox
+
+import (
+	"io"
+	"os"
+)
+
+// Pool hands out interpreters that are ready to run a compiled program, for servers that
+// run the same script for every request. The native functions, the build info and the
+// prelude are set up once into base globals that every interpreter of the pool starts
+// from, and an interpreter returned to the pool is reset to them, so nothing a run defines
+// leaks into the next one.
+//
+//	interpreter := pool.Get()
+//	defer pool.Put(interpreter)
+//	err := pool.Run(interpreter, w)
+//
+// A pool can be used from many goroutines at the same time, but an interpreter must only
+// be used by one goroutine until it's put back.
+type Pool struct {
+	program *Program
+
+	// base are the globals every interpreter starts from, they are never modified.
+	base *Environment
+
+	// baseLocals are the resolved locals of the prelude the base globals were set up with.
+	baseLocals Locals
+
+	idle chan *Interpreter
+}
+
+// NewPool creates a pool for the program that keeps up to size idle interpreters, all of
+// them created up front.
+func NewPool(program *Program, size int) *Pool {
+	base := newProgramInterpreter(program.options)
+	pool := &Pool{program: program, base: base.globals, baseLocals: base.locals, idle: make(chan *Interpreter, size)}
+	for n := 0; n < size; n++ {
+		pool.idle <- pool.newInterpreter()
+	}
+
+	return pool
+}
+
+// newInterpreter creates an interpreter with a copy of the base globals and the resolved
+// locals of the prelude and the program.
+func (p *Pool) newInterpreter() *Interpreter {
+	interpreter := newInterpreter(nil, p.base.Snapshot())
+	interpreter.base = p.base
+	interpreter.applyOptions(p.program.options)
+	interpreter.addLocals(p.baseLocals)
+	interpreter.addLocals(p.program.locals)
+	return interpreter
+}
+
+// Get returns an idle interpreter, or a new one if all of them are in use.
+func (p *Pool) Get() *Interpreter {
+	select {
+	case interpreter := <-p.idle:
+		return interpreter
+	default:
+		return p.newInterpreter()
+	}
+}
+
+// Put resets the interpreter and returns it to the pool. It's dropped if the pool already
+// keeps as many idle interpreters as it can.
+func (p *Pool) Put(interpreter *Interpreter) {
+	interpreter.Reset()
+	select {
+	case p.idle <- interpreter:
+	default:
+	}
+}
+
+// Run runs the program of the pool on an interpreter from Get, writing what it prints to
+// out. It returns the runtime error that stopped the program, if any. The resources used
+// by the run are kept in the RunStats of the interpreter until it's put back.
+func (p *Pool) Run(interpreter *Interpreter, out io.Writer) error {
+	interpreter.out = out
+	return p.program.run(interpreter)
+}
+
+// Reset returns an interpreter from a pool to the state it was handed out in: the globals
+// are set back to the base globals of the pool and the output to os.Stdout. The RunStats,
+// and the statistics if they are enabled, are cleared. Interpreters that don't come from a
+// pool keep their globals.
+func (i *Interpreter) Reset() {
+	if i.base != nil {
+		i.globals.restore(i.base)
+	}
+
+	i.environment = i.globals
+	i.frames = nil
+	i.out = os.Stdout
+	i.usage = RunStats{}
+	if i.profiler != nil {
+		i.profiler = newProfiler()
+	}
+}
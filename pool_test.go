@@ -0,0 +1,85 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+// poolScript changes a global of its own and one of the prelude, which the next run from
+// the pool must not see.
+const poolScript = `
+var runs;
+if (runs == nil) runs = 0;
+runs = runs + 1;
+print runs;
+print clock == nil;
+clock = nil;
+`
+
+func newTestPool(t *testing.T, source string, options Options) *Pool {
+	t.Helper()
+
+	program, diagnostics := Compile(source, options)
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	return NewPool(program, 1)
+}
+
+func TestPoolReusesInterpreters(t *testing.T) {
+	pool := newTestPool(t, poolScript, DefaultOptions())
+
+	first := pool.Get()
+	pool.Put(first)
+	if second := pool.Get(); second != first {
+		t.Errorf("expected the interpreter that was put back")
+	}
+}
+
+func TestPoolIsolatesRuns(t *testing.T) {
+	pool := newTestPool(t, poolScript, DefaultOptions())
+
+	for n := 0; n < 3; n++ {
+		interpreter := pool.Get()
+		var out strings.Builder
+		if err := pool.Run(interpreter, &out); err != nil {
+			t.Fatalf("run %d: %s", n, err.Error())
+		}
+
+		pool.Put(interpreter)
+		if expected := "1\nfalse\n"; out.String() != expected {
+			t.Errorf("run %d printed %q, expected %q", n, out.String(), expected)
+		}
+	}
+}
+
+// TestPoolAppliesOptions checks the interpreters of a pool are set up like the ones of
+// Program.Run, including when they are created after the pool ran out of idle ones.
+func TestPoolAppliesOptions(t *testing.T) {
+	options := DefaultOptions()
+	options.BigNumbers = true
+	options.DebugResolver = true
+	options.MaxCallDepth = 50
+	pool := newTestPool(t, `
+print 0.1 + 0.2;
+fun deep(n) { return deep(n + 1); }
+deep(0);
+`, options)
+
+	for _, interpreter := range []*Interpreter{pool.Get(), pool.Get()} {
+		if !interpreter.debugResolver {
+			t.Errorf("resolver debugging isn't enabled")
+		}
+
+		var out strings.Builder
+		err := pool.Run(interpreter, &out)
+		if out.String() != "0.3\n" {
+			t.Errorf("printed %q, expected %q", out.String(), "0.3\n")
+		}
+
+		if err == nil || !strings.Contains(err.Error(), "Stack overflow") {
+			t.Errorf("expected a stack overflow at a depth of 50, got %v", err)
+		}
+	}
+}
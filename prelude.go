@@ -0,0 +1,53 @@
+package glox
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// preludeFiles is the standard library written in lox. The files are loaded in the order of
+// their names, with core.lox first since the others may use it.
+//
+//go:embed prelude/*.lox
+var preludeFiles embed.FS
+
+// loadPrelude defines the functions and classes of the prelude in the global environment.
+// The prelude ships with the binary, so any diagnostic in it is a bug in glox rather than in
+// the user's script and is returned as an error.
+func (i *Interpreter) loadPrelude() error {
+	paths, err := fs.Glob(preludeFiles, "prelude/*.lox")
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(paths, func(a, b int) bool {
+		return paths[a] == "prelude/core.lox" && paths[b] != "prelude/core.lox"
+	})
+
+	for _, path := range paths {
+		source, err := preludeFiles.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		tokens, diagnostics := ScanSource(string(source))
+		statements, parseDiagnostics := ParseTokens(tokens)
+		locals, resolveDiagnostics := Resolve(statements)
+
+		diagnostics = append(append(diagnostics, parseDiagnostics...), resolveDiagnostics...)
+		if len(diagnostics) > 0 {
+			return fmt.Errorf("%s: %s", path, diagnostics[0])
+		}
+
+		i.addLocals(locals)
+		for _, stmt := range statements {
+			if err := i.execute(stmt); err != nil {
+				return fmt.Errorf("%s: %s", path, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package glox
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Locals maps each expression referring to a local variable to the number of scopes
+// between the expression and the scope where the variable is declared. It's produced by
+// the resolver and consumed by the interpreter.
+type Locals map[ast.Expr]int
+
+// ScanSource scans the source code into tokens. The returned tokens always end with an
+// Eof token, even when there are diagnostics.
+func ScanSource(source string) ([]ast.Token, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	scanner := NewScanner(bytes.NewBufferString(source), diagnostics)
+	tokens := scanner.ScanTokens()
+
+	return tokens, diagnostics.diagnostics
+}
+
+// ParseTokens parses the tokens into a list of statements using the default options. The
+// statements should not be used if any of the diagnostics is an error.
+func ParseTokens(tokens []ast.Token) ([]ast.Stmt, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	parser := NewParser(tokens, diagnostics, DefaultOptions())
+	statements := parser.Parse()
+
+	return statements, diagnostics.diagnostics
+}
+
+// Parse scans and parses the source into a list of statements. The statements should not
+// be used if any of the diagnostics is an error.
+func Parse(source string, options Options) ([]ast.Stmt, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	scanner := NewScanner(bytes.NewBufferString(source), diagnostics)
+	tokens := scanner.ScanTokens()
+	statements := NewParser(tokens, diagnostics, options).Parse()
+
+	return statements, diagnostics.diagnostics
+}
+
+// ParseExpr parses a single expression, like "price * (1 + tax)", using the default options.
+// No trailing semicolon is needed, but the whole source must be one expression. The
+// expression should not be used if any of the diagnostics is an error.
+func ParseExpr(source string) (ast.Expr, []Diagnostic) {
+	return parseExpr(source, DefaultOptions())
+}
+
+func parseExpr(source string, options Options) (ast.Expr, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	scanner := NewScanner(bytes.NewBufferString(source), diagnostics)
+	tokens := scanner.ScanTokens()
+	if HasErrors(diagnostics.diagnostics) {
+		return nil, diagnostics.diagnostics
+	}
+
+	expr, _ := NewParser(tokens, diagnostics, options).ParseExpression()
+	return expr, diagnostics.diagnostics
+}
+
+// Resolve runs the static analysis pass over the statements, returning the resolved local
+// variables along with any diagnostics.
+func Resolve(statements []ast.Stmt) (Locals, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	resolver := NewResolver(diagnostics)
+	resolver.resolveStatements(statements)
+
+	return resolver.Locals(), diagnostics.diagnostics
+}
+
+// Check scans, parses and resolves the source without running it, returning every
+// diagnostic found along the way. Directive comments in the source are honored. The source
+// is checked as a whole script, so references to undeclared globals are errors.
+func Check(source string, options Options) []Diagnostic {
+	_, diagnostics := Compile(source, options)
+	return diagnostics
+}
+
+// Program is a script that has been scanned, parsed and resolved, ready to run. A program
+// is never modified once it's compiled, and every run gets an interpreter and environments
+// of its own, so a program can be run by many goroutines at the same time.
+type Program struct {
+	statements []ast.Stmt
+	locals     Locals
+	options    Options
+}
+
+// Compile scans, parses and resolves the source as a whole script, like Check does. The
+// program is nil if any of the diagnostics is an error.
+func Compile(source string, options Options) (*Program, []Diagnostic) {
+	diagnostics := &diagnosticList{}
+	for name, value := range options.Globals {
+		if _, err := sandboxValue(value); err != nil {
+			message := "Global '" + name + "': " + err.Error()
+			return nil, []Diagnostic{{Severity: SeverityError, Message: message, Code: diagnosticCode(phaseResolver, message)}}
+		}
+	}
+
+	scanner := NewScanner(bytes.NewBufferString(source), diagnostics)
+	tokens := scanner.ScanTokens()
+
+	parser := NewParser(tokens, diagnostics, options)
+	statements := parser.Parse()
+	if HasErrors(diagnostics.diagnostics) {
+		return nil, diagnostics.diagnostics
+	}
+
+	resolver := NewResolver(diagnostics)
+	resolver.SetDirectives(scanner.Directives())
+	resolver.CheckGlobals(predefinedGlobals(options))
+	resolver.ResolveProgram(statements)
+	if HasErrors(diagnostics.diagnostics) {
+		return nil, diagnostics.diagnostics
+	}
+
+	return &Program{statements: statements, locals: resolver.Locals(), options: options}, diagnostics.diagnostics
+}
+
+// Run runs the program with a new interpreter, writing what it prints to out. A writer
+// that implements http.Flusher, like an http.ResponseWriter, is flushed after every print,
+// so the output streams while the program runs. It returns the runtime error that stopped the
+// program, if any.
+func (p *Program) Run(out io.Writer) error {
+	_, err := p.RunWithStats(out)
+	return err
+}
+
+// RunWithStats runs the program like Run, and also returns the resources the run used,
+// even if it failed.
+func (p *Program) RunWithStats(out io.Writer) (RunStats, error) {
+	interpreter := newProgramInterpreter(p.options)
+	interpreter.out = out
+	interpreter.addLocals(p.locals)
+
+	err := p.run(interpreter)
+	return interpreter.RunStats(), err
+}
+
+// run executes the statements of the program on an interpreter that knows its locals,
+// counting the resources used.
+func (p *Program) run(interpreter *Interpreter) error {
+	interpreter.usage.start()
+	defer interpreter.usage.stop()
+
+	for _, stmt := range p.statements {
+		if err := interpreter.execute(stmt); err != nil {
+			return interpreter.uncaught(err)
+		}
+	}
+
+	return nil
+}
+
+// newProgramInterpreter creates an interpreter without a runtime, with the globals a
+// script starts with: the native functions, the build info and the prelude, unless it's
+// disabled.
+func newProgramInterpreter(options Options) *Interpreter {
+	interpreter := NewInterpreter(nil)
+	interpreter.applyOptions(options)
+	interpreter.defineBuildInfo(options)
+	if !options.NoPrelude {
+		if err := interpreter.loadPrelude(); err != nil {
+			panic("glox: loading the prelude: " + err.Error())
+		}
+	}
+
+	// Compile already rejected the globals that can't be converted.
+	for name, value := range options.Globals {
+		if value, err := sandboxValue(value); err == nil {
+			interpreter.defineGlobal(name, value)
+		}
+	}
+
+	return interpreter
+}
+
+// predefinedGlobals returns the names of the globals a script can use without declaring
+// them.
+func predefinedGlobals(options Options) []string {
+	return newProgramInterpreter(options).globals.Names()
+}
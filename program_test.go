@@ -0,0 +1,102 @@
+package glox
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+const concurrentScript = `
+class Counter {
+  init(start) { this.count = start; }
+  next() { this.count = this.count + 1; return this.count; }
+}
+
+fun makeAdder(n) {
+  fun add(x) { return x + n; }
+  return add;
+}
+
+var counter = Counter(10);
+var add3 = makeAdder(3);
+var totals = {"a": 1};
+for (var i = 0; i < 50; i = i + 1) {
+  totals["a"] = totals["a"] + add3(counter.next());
+}
+
+var words = ["lox", "is", "fun"];
+words.push("!");
+print totals["a"];
+print words;
+print counter.count;
+`
+
+// runSource compiles and runs the source with the options, returning what it printed and
+// the runtime error that stopped it. Compile errors fail the test.
+func runSource(t *testing.T, source string, options Options) (string, error) {
+	t.Helper()
+
+	program, diagnostics := Compile(source, options)
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	var out bytes.Buffer
+	err := program.Run(&out)
+	return out.String(), err
+}
+
+// TestProgramRunsConcurrently runs one compiled program from many goroutines at once. Every
+// run must print what a run on its own prints, and go test -race must find no data race.
+func TestProgramRunsConcurrently(t *testing.T) {
+	program, diagnostics := Compile(concurrentScript, DefaultOptions())
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	var expected bytes.Buffer
+	if err := program.Run(&expected); err != nil {
+		t.Fatalf("running the program: %s", err.Error())
+	}
+
+	const goroutines, runs = 16, 20
+	outputs := make(chan string, goroutines*runs)
+	errs := make(chan error, goroutines*runs)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < runs; n++ {
+				var out bytes.Buffer
+				if err := program.Run(&out); err != nil {
+					errs <- err
+					return
+				}
+
+				outputs <- out.String()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(outputs)
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("concurrent run failed: %s", err.Error())
+	}
+
+	count := 0
+	for output := range outputs {
+		count++
+		if output != expected.String() {
+			t.Fatalf("concurrent run printed %q, expected %q", output, expected.String())
+		}
+	}
+
+	if count != goroutines*runs {
+		t.Fatalf("expected %d runs, got %d", goroutines*runs, count)
+	}
+}
@@ -0,0 +1,70 @@
+package glox
+
+import (
+	"math"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// LoxRange is the value of a range expression, the numbers from Start up to End in steps
+// of one. End is only part of the range if it's inclusive and a whole number of steps from
+// Start. Ranges are values, two ranges with the same bounds are equal.
+type LoxRange struct {
+	Start     float64
+	End       float64
+	Inclusive bool
+}
+
+// Contains reports if the value is one of the numbers of the range.
+func (r LoxRange) Contains(value interface{}) bool {
+	number, ok := toFloat64(value)
+	if !ok || number < r.Start || number > r.End || (number == r.End && !r.Inclusive) {
+		return false
+	}
+
+	return math.Trunc(number-r.Start) == number-r.Start
+}
+
+func (r LoxRange) Iterate() Iterator {
+	return &rangeIterator{r: r, next: r.Start}
+}
+
+func (r LoxRange) String() string {
+	operator := ".."
+	if r.Inclusive {
+		operator = "..="
+	}
+
+	return formatNumber(r.Start) + operator + formatNumber(r.End)
+}
+
+// property returns the property of the range with the given name.
+func (r LoxRange) property(name ast.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "start":
+		return r.Start, nil
+	case "end":
+		return r.End, nil
+	case "contains":
+		doc := "contains(value) returns true if the value is one of the numbers of " + r.String() + "."
+		return NewNativeFunction("contains", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return r.Contains(arguments[0]), nil
+		}), nil
+	}
+
+	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+}
+
+type rangeIterator struct {
+	r    LoxRange
+	next float64
+}
+
+func (ri *rangeIterator) Next() (interface{}, bool) {
+	if ri.next > ri.r.End || (ri.next == ri.r.End && !ri.r.Inclusive) {
+		return nil, false
+	}
+
+	ri.next++
+	return ri.next - 1, true
+}
@@ -0,0 +1,122 @@
+package glox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// recordEntry is the result of a call to a nondeterministic native function, like clock or
+// random, in a recording of a run.
+type recordEntry struct {
+	Native string      `json:"native"`
+	Value  interface{} `json:"value"`
+}
+
+// recorder logs the results of the nondeterministic native functions while recording, or
+// plays them back while replaying, so a run can be reproduced exactly.
+type recorder struct {
+	entries []recordEntry
+	// replaying is set when the entries were loaded from a recording, next is the entry the
+	// next call gets.
+	replaying bool
+	next      int
+}
+
+// StartRecording makes the interpreter log the results of the nondeterministic native
+// functions. They are written out by WriteRecording.
+func (i *Interpreter) StartRecording() {
+	i.recorder = &recorder{}
+}
+
+// WriteRecording writes the log of the recorded calls as JSON lines.
+func (i *Interpreter) WriteRecording(w io.Writer) error {
+	if i.recorder == nil {
+		return nil
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, entry := range i.recorder.entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Replay reads a log written by WriteRecording. From then on the nondeterministic native
+// functions return the logged results, in order, instead of being called.
+func (i *Interpreter) Replay(r io.Reader) error {
+	entries := make([]recordEntry, 0)
+	decoder := json.NewDecoder(r)
+	for decoder.More() {
+		var entry recordEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	i.recorder = &recorder{entries: entries, replaying: true}
+	return nil
+}
+
+// recorded wraps a nondeterministic native function so its results are recorded or
+// replayed.
+func recorded(name string, fn NativeFn) NativeFn {
+	return func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+		rec := interpreter.recorder
+		if rec == nil {
+			return fn(interpreter, arguments)
+		}
+
+		if rec.replaying {
+			if rec.next >= len(rec.entries) {
+				return nil, NewNativeError(fmt.Sprintf("The replay diverged, the recording has no more results for '%s'", name))
+			}
+
+			entry := rec.entries[rec.next]
+			if entry.Native != name {
+				return nil, NewNativeError(fmt.Sprintf("The replay diverged, expected a call to '%s' but got '%s'", entry.Native, name))
+			}
+
+			rec.next++
+			return entry.Value, nil
+		}
+
+		value, err := fn(interpreter, arguments)
+		if err == nil {
+			rec.entries = append(rec.entries, recordEntry{Native: name, Value: value})
+		}
+
+		return value, err
+	}
+}
+
+// loadReplay reads the recording at the path for the interpreter to replay.
+func (r *Runtime) loadReplay(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	return r.interpreter.Replay(f)
+}
+
+// writeRecording writes the recorded results to the record out path.
+func (r *Runtime) writeRecording() {
+	f, err := os.Create(r.options.RecordOut)
+	if err != nil {
+		fmt.Printf("error writing recording: %s\n", err.Error())
+		return
+	}
+
+	defer f.Close()
+	if err := r.interpreter.WriteRecording(f); err != nil {
+		fmt.Printf("error writing recording: %s\n", err.Error())
+	}
+}
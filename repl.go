@@ -0,0 +1,227 @@
+package glox
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// runCommand runs a prompt command, a line starting with ':'.
+//
+//	:reload <file>  re-defines the functions and classes declared in the file
+//	:history [n]    lists the executed statements, or the variables after statement n
+//	:history defs   lists the variables, functions and classes declared at the prompt
+//	:back <n>       goes back to the globals as they were after statement n
+//	:watch <name>   prints the value of the variable every time it's assigned
+//	:unwatch <name> stops watching the variable
+//	:doc <expr>     prints the documentation of the value of the expression
+func (r *Runtime) runCommand(line string) {
+	fields := strings.Fields(strings.TrimPrefix(line, ":"))
+	if len(fields) == 0 {
+		fmt.Println("Expect a command after ':'")
+		return
+	}
+
+	switch fields[0] {
+	case "reload":
+		if len(fields) != 2 {
+			fmt.Println("Usage: :reload <file>")
+			return
+		}
+
+		r.reload(fields[1])
+	case "history", "back":
+		if fields[0] == "history" && len(fields) == 2 && fields[1] == "defs" {
+			r.listDefinitions()
+			return
+		}
+
+		history := r.interpreter.History()
+		if history == nil {
+			fmt.Println("History is not enabled, start glox with --history")
+			return
+		}
+
+		if fields[0] == "history" && len(fields) == 1 {
+			r.listHistory()
+			return
+		}
+
+		index := -1
+		if len(fields) == 2 {
+			index, _ = strconv.Atoi(fields[1])
+		}
+
+		if index < 1 || index > len(history) {
+			fmt.Printf("Usage: :%s <n>, n between 1 and %d\n", fields[0], len(history))
+			return
+		}
+
+		if fields[0] == "history" {
+			r.showHistory(index - 1)
+		} else {
+			r.interpreter.RestoreHistory(index - 1)
+			fmt.Printf("back to statement %d\n", index)
+		}
+	case "watch", "unwatch":
+		if len(fields) != 2 {
+			fmt.Printf("Usage: :%s <name>\n", fields[0])
+			return
+		}
+
+		if fields[0] == "unwatch" {
+			r.interpreter.Unwatch(fields[1])
+			return
+		}
+
+		r.interpreter.SetWatchHandler(r.interpreter.printWatchHit(os.Stdout))
+		r.interpreter.Watch(fields[1])
+	case "doc":
+		source := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, ":"), "doc"))
+		if source == "" {
+			fmt.Println("Usage: :doc <expression>")
+			return
+		}
+
+		value, err := r.interpreter.EvaluateInFrame(0, source)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		fmt.Print(r.interpreter.describe(value))
+	default:
+		fmt.Printf("Unknown command ':%s'\n", fields[0])
+	}
+}
+
+// listHistory prints the executed statements, numbered from 1.
+func (r *Runtime) listHistory() {
+	for n, entry := range r.interpreter.History() {
+		printed := strings.TrimSpace(NewAstPrinter().Print([]ast.Stmt{entry.Statement}))
+		if newline := strings.Index(printed, "\n"); newline >= 0 {
+			printed = printed[:newline] + " ..."
+		}
+
+		fmt.Printf("%4d  %s\n", n+1, printed)
+	}
+}
+
+// showHistory prints the variables as they were right after the statement of the history
+// entry ran, innermost scope first. The globals that were defined before the history
+// started, like the native functions, are left out unless they were changed.
+func (r *Runtime) showHistory(index int) {
+	r.interpreter.writeEnvironment(os.Stdout, r.interpreter.History()[index].Environment, r.interpreter.historyBaseline)
+}
+
+// definition is a global declared at the prompt. Params is nil for variables.
+type definition struct {
+	kind      string
+	name      ast.Token
+	params    []ast.Token
+	redefines bool
+}
+
+// trackDefinitions records the global declarations among the statements typed at the
+// prompt. Declaring a global again is allowed and replaces it, but a function or class
+// that is declared again with a different number of parameters is warned about, as the
+// calls written for the old one won't work anymore.
+func (r *Runtime) trackDefinitions(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		var def definition
+		switch stmt := stmt.(type) {
+		case *ast.VarStmt:
+			def = definition{kind: "var", name: stmt.Name}
+		case *ast.DestructureStmt:
+			for _, name := range stmt.Names {
+				r.trackDefinitions([]ast.Stmt{&ast.VarStmt{Name: name}})
+			}
+
+			continue
+		case *ast.FunctionStmt:
+			def = definition{kind: "fun", name: stmt.Name, params: append([]ast.Token{}, stmt.Params...)}
+		case *ast.ClassStmt:
+			def = definition{kind: "class", name: stmt.Name, params: []ast.Token{}}
+			for _, method := range stmt.Methods {
+				if method.Name.Lexeme == "init" {
+					def.params = append(def.params, method.Params...)
+				}
+			}
+		default:
+			continue
+		}
+
+		if previous, ok := r.definition(def.name.Lexeme); ok {
+			def.redefines = true
+			if previous.params != nil && def.params != nil && len(previous.params) != len(def.params) {
+				r.Report(warningAt(phaseResolver, def.name, fmt.Sprintf("Redefining '%s' changes its arity from %d to %d", def.name.Lexeme, len(previous.params), len(def.params))))
+			}
+		}
+
+		r.definitions = append(r.definitions, def)
+	}
+}
+
+// definition returns the latest declaration of the global at the prompt.
+func (r *Runtime) definition(name string) (definition, bool) {
+	for n := len(r.definitions) - 1; n >= 0; n-- {
+		if r.definitions[n].name.Lexeme == name {
+			return r.definitions[n], true
+		}
+	}
+
+	return definition{}, false
+}
+
+// listDefinitions prints the declarations made at the prompt, oldest first, with the
+// parameters of functions and classes.
+func (r *Runtime) listDefinitions() {
+	for n, def := range r.definitions {
+		signature := def.kind + " " + def.name.Lexeme
+		if def.params != nil {
+			params := make([]string, 0, len(def.params))
+			for _, param := range def.params {
+				params = append(params, param.Lexeme)
+			}
+
+			signature += "(" + strings.Join(params, ", ") + ")"
+		}
+
+		if def.redefines {
+			signature += "  (redefined)"
+		}
+
+		fmt.Printf("%4d  %s\n", n+1, signature)
+	}
+}
+
+// reload parses the file and re-defines the global functions and classes declared in it,
+// replacing the existing bindings. The rest of the file is not run and other globals keep
+// their values, so a long lived session can pick up edits to the file.
+func (r *Runtime) reload(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("error reading file: %s\n", err.Error())
+		return
+	}
+
+	statements, ok := r.compile(string(data))
+	if !ok {
+		return
+	}
+
+	declarations := make([]ast.Stmt, 0)
+	for _, stmt := range statements {
+		switch stmt.(type) {
+		case *ast.FunctionStmt, *ast.ClassStmt:
+			declarations = append(declarations, stmt)
+		}
+	}
+
+	r.trackDefinitions(declarations)
+	r.interpreter.Interpret(declarations)
+	fmt.Printf("reloaded %d declarations from %s\n", len(declarations), path)
+}
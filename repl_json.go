@@ -0,0 +1,93 @@
+package glox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// replRequest is a line of input of the JSON prompt. ID is optional and sent back with the
+// frame of the evaluation, so a front-end can match them.
+type replRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Source string          `json:"source"`
+}
+
+// replFrame is the result of evaluating a request of the JSON prompt. Result is the value
+// of an expression, printed like the prompt prints it, and null for statements. Stdout is
+// what the code printed.
+type replFrame struct {
+	ID          json.RawMessage  `json:"id,omitempty"`
+	Result      *string          `json:"result"`
+	Stdout      string           `json:"stdout"`
+	Diagnostics []JSONDiagnostic `json:"diagnostics"`
+	Error       *JSONError       `json:"error"`
+}
+
+// RunJSONPrompt runs the prompt for programs rather than people, like GUI front-ends and
+// notebooks. Every line read from in is a request, a JSON object with the source to
+// evaluate and an optional id, like {"id": 1, "source": "1 + 2"}. For every request one
+// line is written to out, a JSON frame with the id, the value of the source if it's an
+// expression, what it printed, its diagnostics and the runtime error it stopped with.
+// Nothing else is written to out, and the globals are kept from one request to the next.
+func (r *Runtime) RunJSONPrompt(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var request replRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			encoder.Encode(&replFrame{Diagnostics: []JSONDiagnostic{}, Error: &JSONError{Message: "Invalid request: " + err.Error()}})
+			continue
+		}
+
+		encoder.Encode(r.evaluateFrame(request))
+	}
+
+	r.finish()
+}
+
+// evaluateFrame evaluates the source of a request like the prompt evaluates a line, and
+// collects what the prompt would print in a frame.
+func (r *Runtime) evaluateFrame(request replRequest) *replFrame {
+	r.frame = &replFrame{ID: request.ID, Diagnostics: []JSONDiagnostic{}}
+	frame := r.frame
+	defer func() {
+		r.frame, r.hadError = nil, false
+	}()
+
+	if strings.HasPrefix(strings.TrimSpace(request.Source), ":") {
+		frame.Error = &JSONError{Message: "Prompt commands aren't supported with --json-io"}
+		return frame
+	}
+
+	previous := r.interpreter.out
+	stdout := &bytes.Buffer{}
+	r.interpreter.out = stdout
+	defer func() {
+		r.interpreter.out = previous
+		frame.Stdout = stdout.String()
+	}()
+
+	r.source = request.Source
+	if expr, diagnostics := parseExpr(request.Source, r.options); !HasErrors(diagnostics) {
+		r.printExpression(expr, request.Source)
+	} else {
+		r.run(request.Source)
+	}
+
+	r.source = ""
+	return frame
+}
+
+// addDiagnostic adds a diagnostic to the frame being evaluated.
+func (f *replFrame) addDiagnostic(diagnostic Diagnostic) {
+	f.Diagnostics = append(f.Diagnostics, diagnostic.JSON())
+}
@@ -1,6 +1,10 @@
 package glox
 
 import (
+	"fmt"
+	"sort"
+
+	"github.com/iamsayantan/glox/ast"
 	"github.com/iamsayantan/glox/util"
 )
 
@@ -15,48 +19,116 @@ const (
 	FunctionTypeInitializer
 )
 
+// Names of the warnings reported by the resolver, used to disable them with directives.
+const (
+	WarningUnusedVariable = "unused-variable"
+	WarningLateBinding    = "late-binding"
+)
+
 const (
 	ClassTypeNone ClassType = iota
 	ClassTypeClass
 	ClassTypeSubclass
 )
 
+// variable is what the resolver knows about a declared name.
+type variable struct {
+	// name is the token the variable was declared with.
+	name ast.Token
+
+	// defined tracks if we have finished resolving the variable's initializer.
+	defined bool
+
+	// used tracks if the variable is ever read. Only local variables declared with var
+	// are reported when they are not.
+	used         bool
+	reportUnused bool
+
+	// function is the declaration bound to the name when it was declared by a function
+	// declaration and has not been assigned to since. It lets us validate calls whose
+	// callee is statically known.
+	function *ast.FunctionStmt
+
+	// lateUses are the globals the body of a top level function uses that are declared
+	// below the use, and calls the global functions it calls. A top level call of the
+	// function before one of those declarations fails, see checkLateUses.
+	lateUses []ast.Token
+	calls    []ast.Symbol
+}
+
 type Resolver struct {
-	interpreter *Interpreter
+	// locals records the resolved distance of every expression that refers to a local
+	// variable.
+	locals Locals
+
 	// scopes keeps track of the stack of scopes currently in scope. Each element
 	// in the stack is a map representing a new block scope. Keys, like in
-	// environment is the variable name, the value is boolean used to track if we
-	// have finished resolving the variable's initializer. The scope stack only keep
-	// tracks of the block scopes, variables declared in the top level are not tracked
-	// by the resolver since they are more dynamic in Lox. While resolving a variable if
-	// we don't find it in the stack of global scopes, we assume it must be global.
-	scopes util.Stack[map[string]bool]
+	// environment is the variable name, the value tracks if we have finished resolving
+	// the variable's initializer. The scope stack only keep tracks of the block scopes,
+	// variables declared in the top level are not resolved by the resolver since they
+	// are more dynamic in Lox. While resolving a variable if we don't find it in the
+	// stack of global scopes, we assume it must be global.
+	scopes util.Stack[map[ast.Symbol]*variable]
+
+	// globals keeps the variables declared at the top level. They are never resolved to
+	// a distance, but knowing them lets us check uses of globals against their declarations.
+	globals map[ast.Symbol]*variable
+
+	// checkGlobals reports references to globals that are never declared. It's only
+	// possible when the whole program is known up front, like when running a script.
+	checkGlobals bool
+
+	// directives are the directive comments of the source being resolved. They can
+	// disable warnings for the whole file or the next line.
+	directives []Directive
 
 	currentFunction FunctionType
 	currentClass    ClassType
 
-	runtime *Runtime
+	// currentGlobal is the variable of the top level function whose body is being
+	// resolved, nil outside of one.
+	currentGlobal *variable
+
+	// bindings records the declaration every name in the source refers to, for editor
+	// features like highlighting. It's nil unless bindings are recorded.
+	bindings map[tokenPosition]binding
+
+	reporter Reporter
 }
 
-func NewResolver(i *Interpreter, runtime *Runtime) *Resolver {
-	stack := util.NewStack[map[string]bool]()
-	return &Resolver{interpreter: i, scopes: *stack, runtime: runtime, currentFunction: FunctionTypeNone, currentClass: ClassTypeNone}
+func NewResolver(reporter Reporter) *Resolver {
+	stack := util.NewStack[map[ast.Symbol]*variable]()
+	return &Resolver{
+		locals:          make(Locals),
+		scopes:          *stack,
+		globals:         make(map[ast.Symbol]*variable),
+		reporter:        reporter,
+		currentFunction: FunctionTypeNone,
+		currentClass:    ClassTypeNone,
+	}
 }
 
 // VisitAssignExpr resolves an assignment expression, first we resolve the expression for
 // the assigned value in case it also contains references to other variables. Then we use
 // our existing resolveLocal() method to resolve the variable that's being assigned to.
-func (r *Resolver) VisitAssignExpr(expr *Assign) (interface{}, error) {
+func (r *Resolver) VisitAssignExpr(expr *ast.Assign) (interface{}, error) {
 	_, err := r.resolveExpr(expr.Value)
 	if err != nil {
 		return nil, err
 	}
 
+	// After an assignment we can no longer be sure which function the name refers to.
+	if v := r.lookup(expr.Name); v != nil {
+		v.function = nil
+		r.bind(expr.Name, v)
+	}
+
 	r.resolveLocal(expr, expr.Name)
+	r.checkDeclared(expr.Name)
 	return nil, nil
 }
 
-func (r *Resolver) VisitLogicalExpr(expr *Logical) (interface{}, error) {
+func (r *Resolver) VisitLogicalExpr(expr *ast.Logical) (interface{}, error) {
 	// Since static analysis does no control flow or short circuiting, logical expression is
 	// exactly same as other binary operators.
 	r.resolveExpr(expr.Left)
@@ -65,36 +137,150 @@ func (r *Resolver) VisitLogicalExpr(expr *Logical) (interface{}, error) {
 	return nil, nil
 }
 
-func (r *Resolver) VisitBinaryExpr(expr *Binary) (interface{}, error) {
+func (r *Resolver) VisitBinaryExpr(expr *ast.Binary) (interface{}, error) {
 	r.resolveExpr(expr.Left)
 	r.resolveExpr(expr.Right)
 
 	return nil, nil
 }
 
-func (r *Resolver) VisitCallExpr(expr *Call) (interface{}, error) {
+func (r *Resolver) VisitRangeExpr(expr *ast.Range) (interface{}, error) {
+	r.resolveExpr(expr.Start)
+	r.resolveExpr(expr.End)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitComparisonExpr(expr *ast.Comparison) (interface{}, error) {
+	for _, operand := range expr.Operands {
+		r.resolveExpr(operand)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitArrayExpr(expr *ast.ArrayLiteral) (interface{}, error) {
+	for _, element := range expr.Elements {
+		r.resolveExpr(element)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpr(expr *ast.MapLiteral) (interface{}, error) {
+	for n, key := range expr.Keys {
+		r.resolveExpr(key)
+		r.resolveExpr(expr.Values[n])
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitTupleExpr(expr *ast.Tuple) (interface{}, error) {
+	for _, element := range expr.Elements {
+		r.resolveExpr(element)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexGetExpr(expr *ast.IndexGet) (interface{}, error) {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexSetExpr(expr *ast.IndexSet) (interface{}, error) {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+	r.resolveExpr(expr.Value)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitCallExpr(expr *ast.Call) (interface{}, error) {
 	r.resolveExpr(expr.Callee)
 
 	for _, argument := range expr.Arguments {
 		r.resolveExpr(argument)
 	}
 
+	for _, argument := range expr.KeywordArguments {
+		r.resolveExpr(argument.Value)
+	}
+
+	// A global can be assigned by code that isn't resolved yet, like a later line at the
+	// prompt, so only the calls of local functions are checked here. The interpreter checks
+	// the others against the function that is actually called.
+	if callee, ok := expr.Callee.(*ast.VarExpr); ok && len(expr.KeywordArguments) > 0 {
+		if v := r.lookup(callee.Name); v != nil && v.function != nil && v != r.globals[callee.Name.Symbol()] {
+			r.checkKeywordArguments(expr, v.function)
+		}
+	}
+
+	if callee, ok := expr.Callee.(*ast.VarExpr); ok && r.checkGlobals && r.lookup(callee.Name) == r.globals[callee.Name.Symbol()] {
+		if r.currentGlobal != nil {
+			r.currentGlobal.calls = append(r.currentGlobal.calls, callee.Name.Symbol())
+		} else if r.currentFunction == FunctionTypeNone {
+			r.checkLateUses(callee.Name)
+		}
+	}
+
 	return nil, nil
 }
 
-func (r *Resolver) VisitGroupingExpr(expr *Grouping) (interface{}, error) {
+// checkKeywordArguments validates the keyword arguments of a call whose callee is known
+// to be the given local function declaration. Every keyword must name a parameter that was
+// not already passed positionally, and all parameters must be covered.
+func (r *Resolver) checkKeywordArguments(expr *ast.Call, function *ast.FunctionStmt) {
+	passed := make(map[string]bool)
+	for i, param := range function.Params {
+		if i < len(expr.Arguments) {
+			passed[param.Lexeme] = true
+		}
+	}
+
+	for _, argument := range expr.KeywordArguments {
+		known := false
+		for _, param := range function.Params {
+			if param.Lexeme == argument.Name.Lexeme {
+				known = true
+			}
+		}
+
+		if !known {
+			r.error(argument.Name, "'"+function.Name.Lexeme+"' has no parameter named '"+argument.Name.Lexeme+"'")
+			continue
+		}
+
+		if passed[argument.Name.Lexeme] {
+			r.error(argument.Name, "Argument '"+argument.Name.Lexeme+"' is already passed positionally")
+		}
+
+		passed[argument.Name.Lexeme] = true
+	}
+
+	for _, param := range function.Params {
+		if !passed[param.Lexeme] {
+			r.error(expr.Paren, "Missing argument for parameter '"+param.Lexeme+"' calling '"+function.Name.Lexeme+"'")
+		}
+	}
+}
+
+func (r *Resolver) VisitGroupingExpr(expr *ast.Grouping) (interface{}, error) {
 	r.resolveExpr(expr.Expression)
 
 	return nil, nil
 }
 
-func (r *Resolver) VisitLiteralExpr(expr *Literal) (interface{}, error) {
+func (r *Resolver) VisitLiteralExpr(expr *ast.Literal) (interface{}, error) {
 	// A literal does not mention any variables and does not contain any subexpression.
 	// So there is no work to do here.
 	return nil, nil
 }
 
-func (r *Resolver) VisitUnaryExpr(expr *Unary) (interface{}, error) {
+func (r *Resolver) VisitUnaryExpr(expr *ast.Unary) (interface{}, error) {
 	r.resolveExpr(expr.Right)
 
 	return nil, nil
@@ -105,21 +291,32 @@ func (r *Resolver) VisitUnaryExpr(expr *Unary) (interface{}, error) {
 // to  see if the variable is being accessed inside it's own initializer. If the variable
 // exists in the current scope but its value is false, that means we have declared it but
 // not yet defined it. We report that error.
-func (r *Resolver) VisitVarExpr(expr *VarExpr) (interface{}, error) {
+func (r *Resolver) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
 	if !r.scopes.IsEmpty() {
 		scope, err := r.scopes.Peek()
 		if err == nil {
-			if val, ok := scope[expr.Name.Lexeme]; ok && !val {
-				r.runtime.tokenError(expr.Name, "Can't read local variable in its own initializer.")
+			if val, ok := scope[expr.Name.Symbol()]; ok && !val.defined {
+				r.error(expr.Name, "Can't read local variable in its own initializer.")
 			}
 		}
 	}
 
+	if v := r.lookup(expr.Name); v != nil {
+		v.used = true
+		r.bind(expr.Name, v)
+	}
+
 	r.resolveLocal(expr, expr.Name)
+	r.checkDeclared(expr.Name)
 	return nil, nil
 }
 
-func (r *Resolver) VisitClassStmt(stmt *ClassStmt) error {
+// VisitBadStmt has nothing to resolve, the parser already reported the error.
+func (r *Resolver) VisitBadStmt(stmt *ast.BadStmt) error {
+	return nil
+}
+
+func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) error {
 	enclosingClass := r.currentClass
 	r.currentClass = ClassTypeClass
 
@@ -127,7 +324,7 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) error {
 	r.define(stmt.Name)
 
 	if stmt.Superclass != nil && stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
-		r.runtime.tokenError(stmt.Superclass.Name, "A class can't inherit from itself.")
+		r.error(stmt.Superclass.Name, "A class can't inherit from itself.")
 	}
 
 	if stmt.Superclass != nil {
@@ -137,11 +334,11 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) error {
 
 	if stmt.Superclass != nil {
 		// If the class declaration has a superclass, then we create a new scope surrounding
-		// all of its methods. In that scope we define the name "super". Once we are done 
+		// all of its methods. In that scope we define the name "super". Once we are done
 		// resolving the methods, we discard the scope.
 		r.beginScope()
 		superScope, _ := r.scopes.Peek()
-		superScope["super"] = true
+		superScope[ast.SymbolSuper] = &variable{defined: true}
 	}
 
 	// we resolve "this" exactly like any other local variable, using "this" as the name.
@@ -154,7 +351,7 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) error {
 		return err
 	}
 
-	scope["this"] = true
+	scope[ast.SymbolThis] = &variable{defined: true}
 
 	for _, method := range stmt.Methods {
 		declaration := FunctionTypeMethod
@@ -175,9 +372,9 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) error {
 	return nil
 }
 
-func (r *Resolver) VisitThisExpr(expr *ThisExpr) (interface{}, error) {
+func (r *Resolver) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
 	if r.currentClass == ClassTypeNone {
-		r.runtime.tokenError(expr.Keyword, "Can't use 'this' outside of a class.")
+		r.error(expr.Keyword, "Can't use 'this' outside of a class.")
 		return nil, nil
 	}
 
@@ -185,11 +382,11 @@ func (r *Resolver) VisitThisExpr(expr *ThisExpr) (interface{}, error) {
 	return nil, nil
 }
 
-func (r *Resolver) VisitGetExpr(expr *GetExpr) (interface{}, error) {
+func (r *Resolver) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
 	return r.resolveExpr(expr.Object)
 }
 
-func (r *Resolver) VisitSetExpr(expr *SetExpr) (interface{}, error) {
+func (r *Resolver) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
 	_, err := r.resolveExpr(expr.Value)
 	if err != nil {
 		return nil, err
@@ -203,23 +400,23 @@ func (r *Resolver) VisitSetExpr(expr *SetExpr) (interface{}, error) {
 	return nil, nil
 }
 
-// VisitSuperExpr resolves a "super" expression. The super expression is resolved just like a 
+// VisitSuperExpr resolves a "super" expression. The super expression is resolved just like a
 // variable. The resolution stores the number of hops along the environment chain that the interpreter
 // needs to walk to find the environment where super is stored.
-func (r *Resolver) VisitSuperExpr(expr *SuperExpr) (interface{}, error) {
+func (r *Resolver) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
 	if r.currentClass == ClassTypeNone {
-		r.runtime.tokenError(expr.Keyword, "Can't use 'super' outside of a class.")
+		r.error(expr.Keyword, "Can't use 'super' outside of a class.")
 	} else if r.currentClass != ClassTypeSubclass {
-		r.runtime.tokenError(expr.Keyword, "Can't use 'super' in class with no superclass.")
+		r.error(expr.Keyword, "Can't use 'super' in class with no superclass.")
 	}
-	
+
 	r.resolveLocal(expr, expr.Keyword)
 	return nil, nil
 }
 
 // VisitBlockStmt will visit a block statement which will create a new lexical scope,
 // traverse the statements inside the block and then discard the scope.
-func (r *Resolver) VisitBlockStmt(stmt *Block) error {
+func (r *Resolver) VisitBlockStmt(stmt *ast.Block) error {
 	r.beginScope()
 	err := r.resolveStatements(stmt.Statements)
 	if err != nil {
@@ -230,12 +427,12 @@ func (r *Resolver) VisitBlockStmt(stmt *Block) error {
 	return nil
 }
 
-func (r *Resolver) VisitExpressionExpr(expr *Expression) error {
+func (r *Resolver) VisitExpressionExpr(expr *ast.Expression) error {
 	r.resolveExpr(expr.Expression)
 	return nil
 }
 
-func (r *Resolver) VisitPrintExpr(expr *Print) error {
+func (r *Resolver) VisitPrintExpr(expr *ast.Print) error {
 	r.resolveExpr(expr.Expression)
 	return nil
 }
@@ -244,8 +441,11 @@ func (r *Resolver) VisitPrintExpr(expr *Print) error {
 // will add a new entry to the current innermost scopes map. As we visit expression we need
 // to know if we are inside the initializer for some variable. We do that by splitting binding
 // in two steps, the first is declaring it.
-func (r *Resolver) VisitVarStmt(stmt *VarStmt) error {
+func (r *Resolver) VisitVarStmt(stmt *ast.VarStmt) error {
 	r.declare(stmt.Name)
+	if !r.scopes.IsEmpty() {
+		r.lookup(stmt.Name).reportUnused = true
+	}
 	if stmt.Initializer != nil {
 		_, err := r.resolveExpr(stmt.Initializer)
 		if err != nil {
@@ -257,11 +457,29 @@ func (r *Resolver) VisitVarStmt(stmt *VarStmt) error {
 	return nil
 }
 
+// VisitDestructureStmt resolves a destructuring declaration like a var statement for every
+// name, the names are declared before the initializer is resolved and defined after it.
+func (r *Resolver) VisitDestructureStmt(stmt *ast.DestructureStmt) error {
+	for _, name := range stmt.Names {
+		r.declare(name)
+		if !r.scopes.IsEmpty() {
+			r.lookup(name).reportUnused = true
+		}
+	}
+
+	r.resolveExpr(stmt.Initializer)
+	for _, name := range stmt.Names {
+		r.define(name)
+	}
+
+	return nil
+}
+
 // VisitIfStmt resolves an if statement. It has one expression for its condition and one or two
 // statements for the branches. The resolution is different from interpretetion here, when we
 // resolve an if statement, there is no control flow. We resolve the condition and both the
 // branches.
-func (r *Resolver) VisitIfStmt(stmt *IfStmt) error {
+func (r *Resolver) VisitIfStmt(stmt *ast.IfStmt) error {
 	r.resolveExpr(stmt.Condition)
 	r.resolveStmt(stmt.ThenBranch)
 	if stmt.ElseBranch != nil {
@@ -273,36 +491,88 @@ func (r *Resolver) VisitIfStmt(stmt *IfStmt) error {
 
 // VisitWhileStmt will resolve a while statement. It resolves both the condition and the body
 // exactly once.
-func (r *Resolver) VisitWhileStmt(stmt *WhileStmt) error {
+func (r *Resolver) VisitWhileStmt(stmt *ast.WhileStmt) error {
 	r.resolveExpr(stmt.Condition)
 	r.resolveStmt(stmt.Body)
 
 	return nil
 }
 
+// VisitForInStmt resolves the collection in the enclosing scope, and the body in a scope of
+// its own with the loop variable, like the environment of each iteration.
+func (r *Resolver) VisitForInStmt(stmt *ast.ForInStmt) error {
+	r.resolveExpr(stmt.Collection)
+
+	r.beginScope()
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+	r.resolveStmt(stmt.Body)
+	r.endScope()
+
+	return nil
+}
+
+// VisitThrowStmt resolves the thrown value.
+func (r *Resolver) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	r.resolveExpr(stmt.Value)
+	return nil
+}
+
+// VisitTryStmt resolves the try block, the catch clause and the finally block in scopes of
+// their own. The exception variable shares its scope with the catch body, like parameters
+// do with a function body.
+func (r *Resolver) VisitTryStmt(stmt *ast.TryStmt) error {
+	r.beginScope()
+	r.resolveStatements(stmt.Body)
+	r.endScope()
+
+	if stmt.Catch != nil {
+		r.beginScope()
+		r.declare(stmt.Catch.Name)
+		r.define(stmt.Catch.Name)
+		r.resolveStatements(stmt.Catch.Body)
+		r.endScope()
+	}
+
+	if stmt.Finally != nil {
+		r.beginScope()
+		r.resolveStatements(stmt.Finally)
+		r.endScope()
+	}
+
+	return nil
+}
+
 // VisitFunctionStmt resolves a function declaration. Functions both bind names and introduce
 // a scope. The name of the function itself is bound in the surrounding scope where the function
 // is declared. When we step into the function's body, we also bind its parameters into the inner
 // function scope.
-func (r *Resolver) VisitFunctionStmt(stmt *FunctionStmt) error {
+func (r *Resolver) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
 	// We declare and define the name of the function in the current scope. Unlike variables, though
 	// we define the name eagerly, before resolving the function's body. This lets a function recursively
 	// refer to itself inside its own body.
 	r.declare(stmt.Name)
 	r.define(stmt.Name)
+	r.lookup(stmt.Name).function = stmt
+
+	enclosingGlobal := r.currentGlobal
+	if r.scopes.IsEmpty() {
+		r.currentGlobal = r.globals[stmt.Name.Symbol()]
+	}
 
 	r.resolveFunction(stmt, FunctionTypeFunction)
+	r.currentGlobal = enclosingGlobal
 	return nil
 }
 
-func (r *Resolver) VisitReturnStmt(stmt *ReturnStmt) error {
+func (r *Resolver) VisitReturnStmt(stmt *ast.ReturnStmt) error {
 	if r.currentFunction == FunctionTypeNone {
-		r.runtime.tokenError(stmt.Keyword, "Can't return from top-level code")
+		r.error(stmt.Keyword, "Can't return from top-level code")
 	}
 
 	if stmt.Value != nil {
 		if r.currentFunction == FunctionTypeInitializer {
-			r.runtime.tokenError(stmt.Keyword, "Can't return a value from initializer.")
+			r.error(stmt.Keyword, "Can't return a value from initializer.")
 			return nil
 		}
 
@@ -312,7 +582,103 @@ func (r *Resolver) VisitReturnStmt(stmt *ReturnStmt) error {
 	return nil
 }
 
-func (r *Resolver) resolveStatements(statements []Stmt) error {
+// CheckGlobals enables the whole program analysis of globals. The predefined names are the
+// globals that exist before the program runs, like the native functions. Every other global
+// must be declared at the top level of the program passed to ResolveProgram.
+func (r *Resolver) CheckGlobals(predefined []string) {
+	r.checkGlobals = true
+	for _, name := range predefined {
+		r.globals[ast.Intern(name)] = &variable{name: ast.Token{Type: ast.Identifiers, Lexeme: name}, defined: true}
+	}
+}
+
+// ResolveProgram resolves the statements of a whole program. When globals are checked, the
+// top level declarations are collected first, so functions can refer to globals declared
+// further down the program.
+func (r *Resolver) ResolveProgram(statements []ast.Stmt) error {
+	if r.checkGlobals {
+		for _, stmt := range statements {
+			var names []ast.Token
+			switch stmt := stmt.(type) {
+			case *ast.VarStmt:
+				names = []ast.Token{stmt.Name}
+			case *ast.DestructureStmt:
+				names = stmt.Names
+			case *ast.FunctionStmt:
+				names = []ast.Token{stmt.Name}
+			case *ast.ClassStmt:
+				names = []ast.Token{stmt.Name}
+			default:
+				continue
+			}
+
+			for _, name := range names {
+				if _, ok := r.globals[name.Symbol()]; !ok {
+					r.globals[name.Symbol()] = &variable{name: name}
+				}
+			}
+		}
+	}
+
+	return r.resolveStatements(statements)
+}
+
+// checkDeclared reports a name that is neither a local nor a known global, when globals are
+// checked. Globals are bound late, so a global used above its declaration is only defined
+// if the declaration runs first. We warn about the uses in top level code. The uses in a
+// top level function are fine as long as it's called after the declaration, which
+// checkLateUses checks at its calls.
+func (r *Resolver) checkDeclared(name ast.Token) {
+	if !r.checkGlobals {
+		return
+	}
+
+	v := r.lookup(name)
+	if v == nil {
+		r.error(name, undefinedMessage("variable", name.Lexeme, r.visibleNames()))
+		return
+	}
+
+	if v != r.globals[name.Symbol()] || v.name.Line <= name.Line {
+		return
+	}
+
+	switch {
+	case r.currentGlobal != nil:
+		r.currentGlobal.lateUses = append(r.currentGlobal.lateUses, name)
+	case r.currentFunction == FunctionTypeNone:
+		r.warn(WarningLateBinding, name, fmt.Sprintf("'%s' is used before its declaration on line %d", name.Lexeme, v.name.Line))
+	}
+}
+
+// checkLateUses warns about a top level call of a global function that uses a global, in
+// its body or in the functions it calls, which is declared below the call and so isn't
+// defined yet when the call runs.
+func (r *Resolver) checkLateUses(callee ast.Token) {
+	visited, reported := make(map[*variable]bool), make(map[ast.Symbol]bool)
+	pending := []*variable{r.globals[callee.Symbol()]}
+	for len(pending) > 0 {
+		function := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if function == nil || function.function == nil || visited[function] {
+			continue
+		}
+
+		visited[function] = true
+		for _, use := range function.lateUses {
+			if declaration := r.globals[use.Symbol()]; declaration != nil && declaration.name.Line > callee.Line && !reported[use.Symbol()] {
+				reported[use.Symbol()] = true
+				r.warn(WarningLateBinding, callee, fmt.Sprintf("'%s' is declared below, on line %d, and '%s' uses it before that", use.Lexeme, declaration.name.Line, callee.Lexeme))
+			}
+		}
+
+		for _, symbol := range function.calls {
+			pending = append(pending, r.globals[symbol])
+		}
+	}
+}
+
+func (r *Resolver) resolveStatements(statements []ast.Stmt) error {
 	for _, stmt := range statements {
 		err := r.resolveStmt(stmt)
 		if err != nil {
@@ -323,28 +689,79 @@ func (r *Resolver) resolveStatements(statements []Stmt) error {
 	return nil
 }
 
-func (r *Resolver) resolveStmt(statement Stmt) error {
+func (r *Resolver) resolveStmt(statement ast.Stmt) error {
 	return statement.Accept(r)
 }
 
-func (r *Resolver) resolveExpr(expr Expr) (interface{}, error) {
+func (r *Resolver) resolveExpr(expr ast.Expr) (interface{}, error) {
 	return expr.Accept(r)
 }
 
 // beginScope creates a new scope and pushes it into the stack.
 func (r *Resolver) beginScope() {
-	r.scopes.Push(make(map[string]bool))
+	r.scopes.Push(make(map[ast.Symbol]*variable))
 }
 
+// endScope pops the innermost scope, warning about the variables in it that were never read.
 func (r *Resolver) endScope() {
-	r.scopes.Pop()
+	scope, _ := r.scopes.Pop()
+
+	unused := make([]ast.Token, 0)
+	for _, v := range scope {
+		if v.reportUnused && !v.used {
+			unused = append(unused, v.name)
+		}
+	}
+
+	// Scopes are maps, so we sort the variables to report them in the order they appear.
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].Line < unused[j].Line || (unused[i].Line == unused[j].Line && unused[i].Lexeme < unused[j].Lexeme)
+	})
+
+	for _, name := range unused {
+		r.warn(WarningUnusedVariable, name, "Local variable '"+name.Lexeme+"' is never used")
+	}
+}
+
+// Locals returns the local variables resolved so far.
+func (r *Resolver) Locals() Locals {
+	return r.locals
+}
+
+func (r *Resolver) error(token ast.Token, message string) {
+	r.reporter.Report(errorAt(phaseResolver, token, message))
+}
+
+// SetDirectives sets the directive comments of the source being resolved.
+func (r *Resolver) SetDirectives(directives []Directive) {
+	r.directives = directives
+}
+
+// warn reports a warning unless it's disabled by a directive, either for the whole file or
+// for the line of the token.
+func (r *Resolver) warn(warning string, token ast.Token, message string) {
+	for _, directive := range r.directives {
+		if directive.Name != "disable" && !(directive.Name == "disable-next-line" && directive.Line+1 == token.Line) {
+			continue
+		}
+
+		for _, argument := range directive.Arguments {
+			if argument == warning {
+				return
+			}
+		}
+	}
+
+	r.reporter.Report(warningAt(phaseResolver, token, message))
 }
 
 // declare adds a variable to the innermost scope so that it shadows any outer
 // one and so we know that the variable exists. We mark it as "not ready yet"
 // by binding the name as false in the scope map.
-func (r *Resolver) declare(name Token) {
+func (r *Resolver) declare(name ast.Token) {
 	if r.scopes.IsEmpty() {
+		r.globals[name.Symbol()] = &variable{name: name}
+		r.bind(name, r.globals[name.Symbol()])
 		return
 	}
 
@@ -353,22 +770,67 @@ func (r *Resolver) declare(name Token) {
 	// when we declare a variable in a local scope, we already know the names of
 	// every previously declared variables in that same scope. If we see collision
 	// we report an error.
-	if _, ok := scope[name.Lexeme]; ok {
-		r.runtime.tokenError(name, "Already a variable with this name in this scope")
+	if _, ok := scope[name.Symbol()]; ok {
+		r.error(name, "Already a variable with this name in this scope")
+	}
+
+	scope[name.Symbol()] = &variable{name: name}
+	r.bind(name, scope[name.Symbol()])
+}
+
+// bind records the variable as the one the name refers to, when bindings are recorded.
+func (r *Resolver) bind(name ast.Token, v *variable) {
+	if r.bindings == nil || name.Column == 0 {
+		return
 	}
 
-	scope[name.Lexeme] = false
+	r.bindings[positionOf(name)] = binding{declaration: v.name, global: r.globals[name.Symbol()] == v}
 }
 
 // define marks a variable as ready for use. This essentially means that the
 // variable is fully initialized.
-func (r *Resolver) define(name Token) {
-	if r.scopes.IsEmpty() {
+func (r *Resolver) define(name ast.Token) {
+	scope := r.globals
+	if !r.scopes.IsEmpty() {
+		scope, _ = r.scopes.Peek()
+	}
+
+	if v, ok := scope[name.Symbol()]; ok {
+		v.defined = true
 		return
 	}
 
-	scope, _ := r.scopes.Peek()
-	scope[name.Lexeme] = true
+	scope[name.Symbol()] = &variable{name: name, defined: true}
+}
+
+// lookup finds the variable a name refers to, starting at the innermost scope and falling
+// back to the globals. It returns nil if the name has not been declared.
+func (r *Resolver) lookup(name ast.Token) *variable {
+	for i := r.scopes.Size() - 1; i >= 0; i-- {
+		scope, _ := r.scopes.Get(i)
+		if v, ok := scope[name.Symbol()]; ok {
+			return v
+		}
+	}
+
+	return r.globals[name.Symbol()]
+}
+
+// visibleNames returns the names of the variables in scope, local and global.
+func (r *Resolver) visibleNames() []string {
+	names := make([]string, 0, len(r.globals))
+	for i := 0; i < r.scopes.Size(); i++ {
+		scope, _ := r.scopes.Get(i)
+		for symbol := range scope {
+			names = append(names, symbol.String())
+		}
+	}
+
+	for symbol := range r.globals {
+		names = append(names, symbol.String())
+	}
+
+	return names
 }
 
 // resolveLocal resolves a variable in the stack of local scopes. We start at the innermost
@@ -376,11 +838,11 @@ func (r *Resolver) define(name Token) {
 // we resolve it, passing in the number of scopes between the current innermost scope and the
 // scope where the variable was found. If we walk thorough all the scopes and never find the
 // variable, we assume its global.
-func (r *Resolver) resolveLocal(expr Expr, name Token) {
+func (r *Resolver) resolveLocal(expr ast.Expr, name ast.Token) {
 	for i := r.scopes.Size() - 1; i >= 0; i-- {
 		val, _ := r.scopes.Get(i)
-		if _, ok := val[name.Lexeme]; ok {
-			r.interpreter.resolve(expr, r.scopes.Size()-1-i)
+		if _, ok := val[name.Symbol()]; ok {
+			r.locals[expr] = r.scopes.Size() - 1 - i
 			return
 		}
 	}
@@ -391,7 +853,7 @@ func (r *Resolver) resolveLocal(expr Expr, name Token) {
 // body in the scope. The difference from how interpreter handles is that, at runtime, declaring
 // a function doesn't do anything to the function's body, the body doesn't get touched until the
 // function is called. But in static analysis we immediately traverse into the body.
-func (r *Resolver) resolveFunction(function *FunctionStmt, funcType FunctionType) {
+func (r *Resolver) resolveFunction(function *ast.FunctionStmt, funcType FunctionType) {
 	// We stash the previous value of the field in a local variable first. As Lox has local functions,
 	// we can nest function declaration arbitrarily deeply. We need to track not just we are in a
 	// function, but how many we're in.
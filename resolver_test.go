@@ -0,0 +1,215 @@
+package glox
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const resolutionScript = `
+var a = "global";
+{
+  var a = "outer";
+  {
+    fun show() { return a; }
+    print show();
+    var a = "inner";
+    print show();
+    print a;
+  }
+}
+
+fun counter() {
+  var n = 0;
+  fun increment() {
+    n = n + 1;
+    return n;
+  }
+  return increment;
+}
+
+var next = counter();
+next();
+print next();
+
+class Base {
+  init(x) { this.x = x; }
+  get() { return this.x; }
+}
+
+class Derived < Base {
+  get() { return super.get() + 1; }
+}
+
+print Derived(1).get();
+for (var i = 0; i < 2; i = i + 1) {
+  fun later() { return i; }
+  print later();
+}
+`
+
+// runResolution runs the resolution script with resolver debugging, with the distance of
+// every local changed by shift.
+func runResolution(t *testing.T, shift int) (string, error) {
+	t.Helper()
+
+	options := DefaultOptions()
+	options.DebugResolver = true
+	program, diagnostics := Compile(resolutionScript, options)
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	var out strings.Builder
+	interpreter := newProgramInterpreter(options)
+	interpreter.out = &out
+	for expr, distance := range program.locals {
+		interpreter.locals[expr] = distance + shift
+	}
+
+	err := program.run(interpreter)
+	return out.String(), err
+}
+
+// TestCheckResolution checks the resolved distances of a script with closures, shadowing
+// declared after a closure, methods and loops match the dynamic lookups.
+func TestCheckResolution(t *testing.T) {
+	output, err := runResolution(t, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := "outer\nouter\ninner\n2\n2\n0\n1\n"
+	if output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
+
+// TestCheckResolutionReportsMismatches moves every local one environment away from where
+// the resolver placed it, which the check must report as an internal error.
+func TestCheckResolutionReportsMismatches(t *testing.T) {
+	for _, shift := range []int{-1, 1} {
+		_, err := runResolution(t, shift)
+		if err == nil || !strings.Contains(err.Error(), "internal error: resolver placed local") {
+			t.Errorf("shift %d: expected an internal error, got %v", shift, err)
+		}
+	}
+}
+
+// TestCheckResolutionConcurrently runs the resolution script with resolver debugging from
+// many goroutines. Every interpreter keeps its own definition clock, so a run must neither
+// see the definitions of another run nor race with it under go test -race.
+func TestCheckResolutionConcurrently(t *testing.T) {
+	options := DefaultOptions()
+	options.DebugResolver = true
+	program, diagnostics := Compile(resolutionScript, options)
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	const goroutines = 8
+	outputs := make(chan string, goroutines)
+	errs := make(chan error, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out strings.Builder
+			if err := program.Run(&out); err != nil {
+				errs <- err
+				return
+			}
+
+			outputs <- out.String()
+		}()
+	}
+
+	wg.Wait()
+	close(outputs)
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("concurrent run failed: %s", err.Error())
+	}
+
+	for output := range outputs {
+		if expected := "outer\nouter\ninner\n2\n2\n0\n1\n"; output != expected {
+			t.Errorf("printed %q, expected %q", output, expected)
+		}
+	}
+}
+
+// lateBindingWarnings compiles the source and returns the lines and messages of its
+// late-binding warnings.
+func lateBindingWarnings(t *testing.T, source string) []string {
+	t.Helper()
+
+	program, diagnostics := Compile(source, DefaultOptions())
+	if program == nil {
+		t.Fatalf("compile errors: %v", diagnostics)
+	}
+
+	var warnings []string
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == "E3010" {
+			warnings = append(warnings, fmt.Sprintf("%d: %s", diagnostic.Line, diagnostic.Message))
+		}
+	}
+
+	return warnings
+}
+
+func TestLateBindingWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{
+			name: "mutual recursion",
+			source: `fun isEven(n) { if (n == 0) return true; return isOdd(n - 1); }
+fun isOdd(n) { if (n == 0) return false; return isEven(n - 1); }
+print isEven(4);`,
+		},
+		{
+			name: "top level use",
+			source: `print limit;
+var limit = 10;`,
+			expected: []string{"1: 'limit' is used before its declaration on line 2"},
+		},
+		{
+			name: "call before the declaration",
+			source: `fun show() { print limit; print limit; }
+show();
+var limit = 10;
+show();`,
+			expected: []string{"2: 'limit' is declared below, on line 3, and 'show' uses it before that"},
+		},
+		{
+			name: "call of a function that calls one using it",
+			source: `fun outer() { inner(); }
+fun inner() { print limit; }
+outer();
+var limit = 10;`,
+			expected: []string{"3: 'limit' is declared below, on line 4, and 'outer' uses it before that"},
+		},
+		{
+			name: "call from a function",
+			source: `fun later() { show(); }
+fun show() { print limit; }
+var limit = 10;
+later();`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			warnings := lateBindingWarnings(t, test.source)
+			if fmt.Sprint(warnings) != fmt.Sprint(test.expected) {
+				t.Errorf("warned %q, expected %q", warnings, test.expected)
+			}
+		})
+	}
+}
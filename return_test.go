@@ -0,0 +1,47 @@
+package glox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestReturnValueErrorStopsFunction checks an error evaluating the value of a return
+// statement fails the call, rather than being dropped and letting the function go on.
+func TestReturnValueErrorStopsFunction(t *testing.T) {
+	source := `
+fun f() {
+  return 1 - nil;
+  print "after the return";
+}
+
+f();
+print "after the call";
+`
+	tokens, diagnostics := ScanSource(source)
+	statements, parseDiagnostics := ParseTokens(tokens)
+	locals, resolveDiagnostics := Resolve(statements)
+	if diagnostics = append(append(diagnostics, parseDiagnostics...), resolveDiagnostics...); len(diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+
+	var out bytes.Buffer
+	interpreter := NewInterpreter(nil)
+	interpreter.SetOutput(&out)
+	interpreter.addLocals(locals)
+
+	var err error
+	for _, stmt := range statements {
+		if err = interpreter.execute(stmt); err != nil {
+			break
+		}
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "must be numbers") {
+		t.Errorf("expected the error of the return value, got %v", err)
+	}
+
+	if out.String() != "" {
+		t.Errorf("the program went on after the error and printed %q", out.String())
+	}
+}
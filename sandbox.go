@@ -0,0 +1,215 @@
+package glox
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// Sandbox evaluates single lox expressions, like formulas in a spreadsheet or conditions in
+// a config file, against variables provided by the embedding Go program. Nothing else is in
+// scope, not even the native functions, and expressions can't assign to variables, fields
+// or elements, or call the methods that change arrays and maps, so evaluating one can only
+// compute a value out of the variables.
+type Sandbox struct {
+	interpreter *Interpreter
+}
+
+// NewSandbox creates a sandbox where the given variables are the only globals. Values can be
+// nil, bools, strings, any Go number, lox callables like a *NativeFunction, Go channels and
+// contexts, or any other value a lox program could hold.
+func NewSandbox(variables map[string]interface{}) (*Sandbox, error) {
+	interpreter := NewInterpreter(nil)
+	interpreter.globals = NewEnvironment(nil)
+	interpreter.environment = interpreter.globals
+
+	for name, value := range variables {
+		value, err := sandboxValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("variable '%s': %s", name, err.Error())
+		}
+
+		interpreter.globals.Define(name, value)
+	}
+
+	return &Sandbox{interpreter: interpreter}, nil
+}
+
+// Eval parses and evaluates the expression. Syntax errors, the use of restricted
+// expressions and runtime errors are all returned as the error.
+func (s *Sandbox) Eval(source string) (interface{}, error) {
+	expr, diagnostics := ParseExpr(source)
+	if HasErrors(diagnostics) {
+		return nil, diagnosticsError(diagnostics)
+	}
+
+	return s.EvalExpr(expr)
+}
+
+// EvalExpr evaluates an expression parsed with ParseExpr, so a formula that is evaluated
+// many times only needs to be parsed once.
+func (s *Sandbox) EvalExpr(expr ast.Expr) (interface{}, error) {
+	if _, err := expr.Accept(sandboxChecker{}); err != nil {
+		return nil, err
+	}
+
+	s.interpreter.usage.start()
+	defer s.interpreter.usage.stop()
+
+	return s.interpreter.evaluate(expr)
+}
+
+// EnableStrictLogical makes 'and' and 'or' require boolean operands in the expressions, for
+// conditions that must come out true or false.
+func (s *Sandbox) EnableStrictLogical() {
+	s.interpreter.EnableStrictLogical()
+}
+
+// Stats returns the resources used by the last evaluated expression.
+func (s *Sandbox) Stats() RunStats {
+	return s.interpreter.RunStats()
+}
+
+// sandboxValue converts a Go value to the lox value it stands for.
+func sandboxValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil, bool, string, float64, *big.Float, LoxCallable, *LoxInstance, LoxRange, *LoxArray, *LoxMap, *LoxTuple, *LoxChannel, *LoxContext:
+		return value, nil
+	case context.Context:
+		return NewLoxContext(value), nil
+	case int:
+		return float64(value), nil
+	case int32:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
+	case uint:
+		return float64(value), nil
+	case uint32:
+		return float64(value), nil
+	case uint64:
+		return float64(value), nil
+	case float32:
+		return float64(value), nil
+	}
+
+	if reflect.ValueOf(value).Kind() == reflect.Chan {
+		return NewLoxChannel(value)
+	}
+
+	return nil, fmt.Errorf("values of type %T can't be used in lox", value)
+}
+
+// sandboxChecker walks an expression and rejects the ones that are not allowed in a
+// sandbox: assignments, setting fields or elements, the methods that change arrays and
+// maps, and 'this' and 'super', which have no meaning outside of a class.
+type sandboxChecker struct{}
+
+// sandboxMutators are the names of the methods that change an array or a map. The type of
+// the object is only known once the expression runs, so getting a property with one of
+// these names is rejected whatever the object is.
+var sandboxMutators = map[string]bool{"push": true, "pop": true, "remove": true}
+
+func (sc sandboxChecker) VisitAssignExpr(expr *ast.Assign) (interface{}, error) {
+	return nil, NewRuntimeError(expr.Name, "Can't assign to '"+expr.Name.Lexeme+"' in a sandbox.")
+}
+
+func (sc sandboxChecker) VisitLogicalExpr(expr *ast.Logical) (interface{}, error) {
+	return sc.check(expr.Left, expr.Right)
+}
+
+func (sc sandboxChecker) VisitBinaryExpr(expr *ast.Binary) (interface{}, error) {
+	return sc.check(expr.Left, expr.Right)
+}
+
+func (sc sandboxChecker) VisitCallExpr(expr *ast.Call) (interface{}, error) {
+	exprs := append([]ast.Expr{expr.Callee}, expr.Arguments...)
+	for _, argument := range expr.KeywordArguments {
+		exprs = append(exprs, argument.Value)
+	}
+
+	return sc.check(exprs...)
+}
+
+func (sc sandboxChecker) VisitGroupingExpr(expr *ast.Grouping) (interface{}, error) {
+	return sc.check(expr.Expression)
+}
+
+func (sc sandboxChecker) VisitLiteralExpr(expr *ast.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (sc sandboxChecker) VisitUnaryExpr(expr *ast.Unary) (interface{}, error) {
+	return sc.check(expr.Right)
+}
+
+func (sc sandboxChecker) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (sc sandboxChecker) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	if sandboxMutators[expr.Name.Lexeme] {
+		return nil, NewRuntimeError(expr.Name, "Can't use '"+expr.Name.Lexeme+"' in a sandbox, it changes the object.")
+	}
+
+	return sc.check(expr.Object)
+}
+
+func (sc sandboxChecker) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	return nil, NewRuntimeError(expr.Name, "Can't set the field '"+expr.Name.Lexeme+"' in a sandbox.")
+}
+
+func (sc sandboxChecker) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	return nil, NewRuntimeError(expr.Keyword, "Can't use 'this' in a sandbox.")
+}
+
+func (sc sandboxChecker) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	return nil, NewRuntimeError(expr.Keyword, "Can't use 'super' in a sandbox.")
+}
+
+func (sc sandboxChecker) VisitRangeExpr(expr *ast.Range) (interface{}, error) {
+	return sc.check(expr.Start, expr.End)
+}
+
+func (sc sandboxChecker) VisitComparisonExpr(expr *ast.Comparison) (interface{}, error) {
+	return sc.check(expr.Operands...)
+}
+
+func (sc sandboxChecker) VisitArrayExpr(expr *ast.ArrayLiteral) (interface{}, error) {
+	return sc.check(expr.Elements...)
+}
+
+func (sc sandboxChecker) VisitMapExpr(expr *ast.MapLiteral) (interface{}, error) {
+	if _, err := sc.check(expr.Keys...); err != nil {
+		return nil, err
+	}
+
+	return sc.check(expr.Values...)
+}
+
+func (sc sandboxChecker) VisitTupleExpr(expr *ast.Tuple) (interface{}, error) {
+	return sc.check(expr.Elements...)
+}
+
+func (sc sandboxChecker) VisitIndexGetExpr(expr *ast.IndexGet) (interface{}, error) {
+	return sc.check(expr.Object, expr.Index)
+}
+
+func (sc sandboxChecker) VisitIndexSetExpr(expr *ast.IndexSet) (interface{}, error) {
+	return nil, NewRuntimeError(expr.Bracket, "Can't set an element of an array or map in a sandbox.")
+}
+
+func (sc sandboxChecker) check(exprs ...ast.Expr) (interface{}, error) {
+	for _, expr := range exprs {
+		if _, err := expr.Accept(sc); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+var _ ast.Visitor = sandboxChecker{}
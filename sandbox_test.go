@@ -0,0 +1,51 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSandboxCantChangeArrays(t *testing.T) {
+	items := NewLoxArray([]interface{}{1.0})
+	sandbox, err := NewSandbox(map[string]interface{}{"items": items})
+	if err != nil {
+		t.Fatalf("creating the sandbox: %s", err.Error())
+	}
+
+	for _, source := range []string{`items.push(2)`, `items.pop()`, `[items.push]`, `items[0] = 2`} {
+		if _, err := sandbox.Eval(source); err == nil || !strings.Contains(err.Error(), "in a sandbox") {
+			t.Errorf("%s: expected it to be rejected, got %v", source, err)
+		}
+	}
+
+	if items.Len() != 1 || items.elements[0] != 1.0 {
+		t.Errorf("the array was changed to %v", items)
+	}
+
+	if value, err := sandbox.Eval(`items[0] + 2`); err != nil || value != 3.0 {
+		t.Errorf("reading the array returned %v, %v", value, err)
+	}
+}
+
+func TestSandboxCantChangeMaps(t *testing.T) {
+	scores := NewLoxMap()
+	scores.Set("a", 1.0)
+	sandbox, err := NewSandbox(map[string]interface{}{"scores": scores})
+	if err != nil {
+		t.Fatalf("creating the sandbox: %s", err.Error())
+	}
+
+	for _, source := range []string{`scores.remove("a")`, `[scores.remove]`, `scores["b"] = 2`} {
+		if _, err := sandbox.Eval(source); err == nil || !strings.Contains(err.Error(), "in a sandbox") {
+			t.Errorf("%s: expected it to be rejected, got %v", source, err)
+		}
+	}
+
+	if value, ok := scores.Get("a"); scores.Len() != 1 || !ok || value != 1.0 {
+		t.Errorf("the map was changed to %v", scores)
+	}
+
+	if value, err := sandbox.Eval(`scores.get("a", 0) + scores["a"]`); err != nil || value != 2.0 {
+		t.Errorf("reading the map returned %v, %v", value, err)
+	}
+}
@@ -2,64 +2,85 @@ package glox
 
 import (
 	"bytes"
-	"fmt"
 	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
+
+	"github.com/iamsayantan/glox/ast"
 )
 
+const directivePrefix = "glox:"
+
+// Directive is a comment that controls how a file is checked, written as "//glox:" followed
+// by the directive name and its arguments:
+//
+//	//glox:disable unused-variable            disables the warning for the whole file
+//	//glox:disable-next-line unused-variable  disables the warning for the next line
+type Directive struct {
+	Line      int
+	Name      string
+	Arguments []string
+}
+
+// keywords are the reserved words, shared by every scanner.
+var keywords = map[string]ast.TokenType{
+	"and":    ast.And,
+	"class":  ast.Class,
+	"else":   ast.Else,
+	"false":  ast.False,
+	"for":    ast.For,
+	"fun":    ast.Fun,
+	"if":     ast.If,
+	"nil":    ast.Nil,
+	"or":     ast.Or,
+	"print":  ast.PRINT,
+	"return": ast.Return,
+	"super":  ast.Super,
+	"this":   ast.This,
+	"true":   ast.True,
+	"var":    ast.Var,
+	"while":  ast.While,
+}
+
 type Scanner struct {
 	source      *bytes.Buffer
 	sourceRunes []rune
-	tokens      []Token
-	keywords    map[string]TokenType
+	tokens      []ast.Token
+	directives  []Directive
 
 	start   int
 	current int
 	line    int
+	// lineStart is the index of the first character of the current line, and column the
+	// column of the token being scanned.
+	lineStart int
+	column    int
 
-	runtime *Runtime
-}
-
-func NewScanner(source *bytes.Buffer, runtime *Runtime) *Scanner {
-	keywords := map[string]TokenType{
-		"and":    And,
-		"class":  Class,
-		"else":   Else,
-		"false":  False,
-		"for":    For,
-		"fun":    Fun,
-		"if":     If,
-		"nil":    Nil,
-		"or":     Or,
-		"print":  PRINT,
-		"return": Return,
-		"super":  Super,
-		"this":   This,
-		"true":   True,
-		"var":    Var,
-		"while":  While,
-	}
+	reporter Reporter
+}
 
+func NewScanner(source *bytes.Buffer, reporter Reporter) *Scanner {
 	return &Scanner{
 		source:      source,
 		sourceRunes: bytes.Runes(source.Bytes()),
-		tokens:      make([]Token, 0),
-		keywords:    keywords,
+		tokens:      make([]ast.Token, 0),
 		start:       0,
 		current:     0,
 		line:        1,
-		runtime:     runtime,
+		reporter:    reporter,
 	}
 }
 
-func (sc *Scanner) ScanTokens() []Token {
+func (sc *Scanner) ScanTokens() []ast.Token {
 	for !sc.isAtEnd() {
 		// We are at the begining of the next lexeme.
 		sc.start = sc.current
+		sc.column = sc.current - sc.lineStart + 1
 		sc.scanToken()
 	}
 
-	sc.tokens = append(sc.tokens, NewToken(Eof, "", nil, sc.line))
+	sc.tokens = append(sc.tokens, ast.NewToken(ast.Eof, "", nil, sc.line))
 	return sc.tokens
 }
 
@@ -67,51 +88,71 @@ func (sc *Scanner) scanToken() {
 	c, _, _ := sc.advance()
 	switch c {
 	case '(':
-		sc.addToken(LeftParen, nil)
+		sc.addToken(ast.LeftParen, nil)
 	case ')':
-		sc.addToken(RightParen, nil)
+		sc.addToken(ast.RightParen, nil)
 	case '{':
-		sc.addToken(LeftBrace, nil)
+		sc.addToken(ast.LeftBrace, nil)
 	case '}':
-		sc.addToken(RightBrace, nil)
+		sc.addToken(ast.RightBrace, nil)
+	case '[':
+		sc.addToken(ast.LeftBracket, nil)
+	case ']':
+		sc.addToken(ast.RightBracket, nil)
 	case ',':
-		sc.addToken(Comma, nil)
+		sc.addToken(ast.Comma, nil)
 	case '.':
-		sc.addToken(Dot, nil)
+		if sc.match('.') {
+			if sc.match('=') {
+				sc.addToken(ast.DotDotEqual, nil)
+			} else {
+				sc.addToken(ast.DotDot, nil)
+			}
+		} else {
+			sc.addToken(ast.Dot, nil)
+		}
 	case '-':
-		sc.addToken(Minus, nil)
+		sc.addToken(ast.Minus, nil)
 	case '+':
-		sc.addToken(Plus, nil)
+		sc.addToken(ast.Plus, nil)
 	case ';':
-		sc.addToken(Semicolon, nil)
+		sc.addToken(ast.Semicolon, nil)
 	case '*':
-		sc.addToken(Star, nil)
+		sc.addToken(ast.Star, nil)
+	case ':':
+		sc.addToken(ast.Colon, nil)
+	case '|':
+		if sc.match('>') {
+			sc.addToken(ast.Pipe, nil)
+		} else {
+			sc.scanInvalid()
+		}
 	case ' ', '\r', '\t':
 	case '\n':
-		sc.line++
+		sc.newLine()
 	case '!':
 		if sc.match('=') {
-			sc.addToken(BangEqual, nil)
+			sc.addToken(ast.BangEqual, nil)
 		} else {
-			sc.addToken(Bang, nil)
+			sc.addToken(ast.Bang, nil)
 		}
 	case '=':
 		if sc.match('=') {
-			sc.addToken(EqualEqual, nil)
+			sc.addToken(ast.EqualEqual, nil)
 		} else {
-			sc.addToken(Equal, nil)
+			sc.addToken(ast.Equal, nil)
 		}
 	case '<':
 		if sc.match('=') {
-			sc.addToken(LessEqual, nil)
+			sc.addToken(ast.LessEqual, nil)
 		} else {
-			sc.addToken(Less, nil)
+			sc.addToken(ast.Less, nil)
 		}
 	case '>':
 		if sc.match('=') {
-			sc.addToken(GreaterEqual, nil)
+			sc.addToken(ast.GreaterEqual, nil)
 		} else {
-			sc.addToken(Greater, nil)
+			sc.addToken(ast.Greater, nil)
 		}
 	case '/':
 		if sc.match('/') {
@@ -119,8 +160,10 @@ func (sc *Scanner) scanToken() {
 			for sc.peek() != '\n' && !sc.isAtEnd() {
 				sc.advance()
 			}
+
+			sc.scanDirective(string(sc.sourceRunes[sc.start+2 : sc.current]))
 		} else {
-			sc.addToken(Slash, nil)
+			sc.addToken(ast.Slash, nil)
 		}
 	case '"':
 		sc.scanString()
@@ -130,22 +173,78 @@ func (sc *Scanner) scanToken() {
 		} else if sc.isAlpha(c) {
 			sc.scanIdentifier()
 		} else {
-			sc.runtime.Error(sc.line, fmt.Sprintf("Unexpected character %c", c))
+			sc.scanInvalid()
 		}
 	}
 }
 
+// scanInvalid consumes the characters up to the next one that can start a token, and
+// reports them with a single diagnostic spanning all of them. The Invalid token stands in
+// for them, so tools can still see where they are.
+func (sc *Scanner) scanInvalid() {
+	for !sc.isAtEnd() && !sc.startsToken(sc.peek()) {
+		sc.advance()
+	}
+
+	text := string(sc.sourceRunes[sc.start:sc.current])
+	message := "Unexpected character"
+	if sc.current-sc.start > 1 {
+		message = "Unexpected characters"
+	}
+
+	sc.reporter.Report(Diagnostic{
+		Severity:  SeverityError,
+		Line:      sc.line,
+		Where:     " at '" + text + "'",
+		Message:   message,
+		Column:    sc.column,
+		EndColumn: sc.column + sc.current - sc.start,
+		Code:      diagnosticCode(phaseScanner, message),
+	})
+	sc.addToken(ast.Invalid, nil)
+}
+
+// startsToken reports if the character can start a token, or separates tokens.
+func (sc *Scanner) startsToken(r rune) bool {
+	return strings.ContainsRune("(){}[],.-+;*:!=<>/|\" \r\t\n", r) || sc.isDigit(r) || sc.isAlpha(r)
+}
+
+// newLine counts the line break that was just consumed.
+func (sc *Scanner) newLine() {
+	sc.line++
+	sc.lineStart = sc.current
+}
+
+// scanDirective records the comment as a directive if it's of the form
+// "//glox:<name> <arguments>", e.g. "//glox:disable unused-variable".
+func (sc *Scanner) scanDirective(comment string) {
+	if !strings.HasPrefix(comment, directivePrefix) {
+		return
+	}
+
+	fields := strings.Fields(strings.ReplaceAll(comment[len(directivePrefix):], ",", " "))
+	if len(fields) == 0 {
+		return
+	}
+
+	sc.directives = append(sc.directives, Directive{Line: sc.line, Name: fields[0], Arguments: fields[1:]})
+}
+
+// Directives returns the directive comments found while scanning.
+func (sc *Scanner) Directives() []Directive {
+	return sc.directives
+}
+
 func (sc *Scanner) scanString() {
 	for sc.peek() != '"' && !sc.isAtEnd() {
-		if sc.peek() == '\n' {
-			sc.line++
-		}
-
 		sc.advance()
+		if sc.sourceRunes[sc.current-1] == '\n' {
+			sc.newLine()
+		}
 	}
 
 	if sc.isAtEnd() {
-		sc.runtime.Error(sc.line, "Unterminated string")
+		sc.error("Unterminated string")
 		return
 	}
 
@@ -155,13 +254,11 @@ func (sc *Scanner) scanString() {
 	// Trim the surrounding quotes and just take the string literal.
 	val := sc.sourceRunes[sc.start+1 : sc.current-1]
 
-	sc.addToken(String, string(val))
+	sc.addToken(ast.String, string(val))
 }
 
 func (sc *Scanner) scanNumber() {
-	for sc.isDigit(sc.peek()) {
-		sc.advance()
-	}
+	sc.scanDigits()
 
 	// Look for a fractional part
 	if sc.peek() == '.' && sc.isDigit(sc.peekNext()) {
@@ -169,29 +266,64 @@ func (sc *Scanner) scanNumber() {
 		sc.advance()
 
 		// consume the digits of the fractional part
-		for sc.isDigit(sc.peek()) {
+		sc.scanDigits()
+	}
+
+	// Look for an exponent, like the e3 of 1.5e3 or the E-4 of 2E-4.
+	if sc.peek() == 'e' || sc.peek() == 'E' {
+		sign := sc.peekNext() == '+' || sc.peekNext() == '-'
+		if sc.isDigit(sc.peekNext()) || sign && sc.isDigit(sc.peekAt(2)) {
 			sc.advance()
+			if sign {
+				sc.advance()
+			}
+
+			sc.scanDigits()
 		}
 	}
 
-	num, _ := strconv.ParseFloat(string(sc.sourceRunes[sc.start:sc.current]), 64)
-	sc.addToken(Number, num)
+	text := strings.ReplaceAll(string(sc.sourceRunes[sc.start:sc.current]), "_", "")
+	num, _ := strconv.ParseFloat(text, 64)
+
+	// An n suffix, like 123n, makes a big number literal.
+	if sc.peek() == 'n' && !sc.isAlphaNumeric(sc.peekNext()) {
+		sc.advance()
+	}
+
+	sc.addToken(ast.Number, num)
+}
+
+// scanDigits consumes a run of digits, which may be separated by underscores like in
+// 1_000_000. An underscore is only a separator when a digit follows it.
+func (sc *Scanner) scanDigits() {
+	for sc.isDigit(sc.peek()) || sc.peek() == '_' && sc.isDigit(sc.peekNext()) {
+		sc.advance()
+	}
 }
 
 func (sc *Scanner) scanIdentifier() {
-	for sc.isAlphaNumeric(sc.peek()) {
+	// Most identifiers are ASCII, which is told apart without the Unicode tables.
+	for r := sc.peek(); ; r = sc.peek() {
+		if r < utf8.RuneSelf {
+			if !isASCIIAlphaNumeric(r) {
+				break
+			}
+		} else if !sc.isAlphaNumeric(r) {
+			break
+		}
+
 		sc.advance()
 	}
 
 	// After scanning the identifier, we need to check if this is a reserved keyword.
-	text := sc.sourceRunes[sc.start:sc.current]
-	tokenType, ok := sc.keywords[string(text)]
+	text := string(sc.sourceRunes[sc.start:sc.current])
+	tokenType, ok := keywords[text]
 
 	if !ok {
-		tokenType = Identifiers
+		tokenType = ast.Identifiers
 	}
 
-	sc.addToken(tokenType, nil)
+	sc.addLexeme(tokenType, text, nil)
 }
 
 func (sc *Scanner) isAtEnd() bool {
@@ -226,11 +358,16 @@ func (sc *Scanner) peek() rune {
 }
 
 func (sc *Scanner) peekNext() rune {
-	if sc.current >= len(sc.sourceRunes) {
+	return sc.peekAt(1)
+}
+
+// peekAt returns the character the offset ahead of the current one, 0 past the end.
+func (sc *Scanner) peekAt(offset int) rune {
+	if sc.current+offset >= len(sc.sourceRunes) {
 		return 0
 	}
 
-	return sc.sourceRunes[sc.current+1]
+	return sc.sourceRunes[sc.current+offset]
 }
 
 func (sc *Scanner) isDigit(r rune) bool {
@@ -245,7 +382,22 @@ func (sc *Scanner) isAlphaNumeric(r rune) bool {
 	return sc.isAlpha(r) || sc.isDigit(r)
 }
 
-func (sc *Scanner) addToken(tokenType TokenType, literal interface{}) {
-	text := string(sc.sourceRunes[sc.start:sc.current])
-	sc.tokens = append(sc.tokens, NewToken(tokenType, text, literal, sc.line))
+// isASCIIAlphaNumeric is isAlphaNumeric for the characters below utf8.RuneSelf.
+func isASCIIAlphaNumeric(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_'
+}
+
+func (sc *Scanner) error(message string) {
+	sc.reporter.Report(Diagnostic{Severity: SeverityError, Line: sc.line, Message: message, Code: diagnosticCode(phaseScanner, message)})
+}
+
+func (sc *Scanner) addToken(tokenType ast.TokenType, literal interface{}) {
+	sc.addLexeme(tokenType, string(sc.sourceRunes[sc.start:sc.current]), literal)
+}
+
+// addLexeme adds a token whose lexeme, the text from start to current, is already known.
+func (sc *Scanner) addLexeme(tokenType ast.TokenType, text string, literal interface{}) {
+	token := ast.NewToken(tokenType, text, literal, sc.line)
+	token.Column = sc.column
+	sc.tokens = append(sc.tokens, token)
 }
@@ -0,0 +1,51 @@
+package glox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// TestScanIdentifiersAndKeywords scans keywords, ASCII identifiers, identifiers with other
+// letters and identifiers that only start like a keyword.
+func TestScanIdentifiersAndKeywords(t *testing.T) {
+	tokens, diagnostics := ScanSource(`and class else false for fun if nil or print return super this true var while
+forest _x9 café π fun_ try in`)
+	if len(diagnostics) > 0 {
+		t.Fatalf("scan errors: %v", diagnostics)
+	}
+
+	expected := []struct {
+		tokenType ast.TokenType
+		lexeme    string
+	}{
+		{ast.And, "and"}, {ast.Class, "class"}, {ast.Else, "else"}, {ast.False, "false"},
+		{ast.For, "for"}, {ast.Fun, "fun"}, {ast.If, "if"}, {ast.Nil, "nil"}, {ast.Or, "or"},
+		{ast.PRINT, "print"}, {ast.Return, "return"}, {ast.Super, "super"}, {ast.This, "this"},
+		{ast.True, "true"}, {ast.Var, "var"}, {ast.While, "while"},
+		{ast.Identifiers, "forest"}, {ast.Identifiers, "_x9"}, {ast.Identifiers, "café"},
+		{ast.Identifiers, "π"}, {ast.Identifiers, "fun_"}, {ast.Identifiers, "try"},
+		{ast.Identifiers, "in"}, {ast.Eof, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("scanned %d tokens, expected %d: %v", len(tokens), len(expected), tokens)
+	}
+
+	for n, token := range tokens {
+		if token.Type != expected[n].tokenType || token.Lexeme != expected[n].lexeme {
+			t.Errorf("token %d is %d %q, expected %d %q", n, token.Type, token.Lexeme, expected[n].tokenType, expected[n].lexeme)
+		}
+	}
+}
+
+// BenchmarkScanPromptLine measures scanning a line typed at the prompt, which creates a
+// scanner every time.
+func BenchmarkScanPromptLine(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		scanner := NewScanner(bytes.NewBufferString(`var total = count * price + shipping;`), &diagnosticList{})
+		scanner.ScanTokens()
+	}
+}
@@ -0,0 +1,288 @@
+package glox
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// SemanticKind classifies a token for editors to highlight, telling apart what the name
+// refers to and not only what kind of token it is.
+type SemanticKind int
+
+const (
+	SemanticKeyword SemanticKind = iota
+	SemanticString
+	SemanticNumber
+	SemanticOperator
+	SemanticFunction
+	SemanticClass
+	SemanticParameter
+	SemanticVariable
+	SemanticProperty
+	SemanticMethod
+)
+
+// SemanticKinds returns the names of the kinds, in order. They are the token types of the
+// language server protocol.
+func SemanticKinds() []string {
+	return []string{"keyword", "string", "number", "operator", "function", "class", "parameter", "variable", "property", "method"}
+}
+
+func (k SemanticKind) String() string {
+	return SemanticKinds()[k]
+}
+
+// SemanticToken is a classified token. Line and Column are where it starts, counted from 1,
+// and Length is its length in characters.
+type SemanticToken struct {
+	Line   int
+	Column int
+	Length int
+	Kind   SemanticKind
+	// Declaration is set for the name a variable, function, class, parameter or method is
+	// declared with.
+	Declaration bool
+	// Global is set for names that refer to a global variable.
+	Global bool
+	// Native is set for names that refer to a global defined by glox, like clock.
+	Native bool
+}
+
+// Highlight is an occurrence of the name under the cursor. Write is set for declarations and
+// assignments.
+type Highlight struct {
+	Line   int
+	Column int
+	Length int
+	Write  bool
+}
+
+// tokenPosition identifies a token by where it starts in the source.
+type tokenPosition struct {
+	line   int
+	column int
+}
+
+func positionOf(token ast.Token) tokenPosition {
+	return tokenPosition{line: token.Line, column: token.Column}
+}
+
+// binding is the declaration a name refers to, as found by the resolver.
+type binding struct {
+	declaration ast.Token
+	global      bool
+}
+
+// extensionKeywords are the keywords the scanner reads as identifiers.
+var extensionKeywords = map[string]bool{"throw": true, "try": true, "catch": true, "finally": true, "in": true}
+
+// semanticAnalysis is what editor features know about a source: its tokens, the
+// declaration each name refers to and the kind of every declaration.
+type semanticAnalysis struct {
+	tokens       []ast.Token
+	bindings     map[tokenPosition]binding
+	declarations map[tokenPosition]SemanticKind
+}
+
+// analyze scans, parses and resolves the source, recording the bindings of the names. It
+// works on sources with errors too, the statements that failed to parse just don't bind
+// their names.
+func analyze(source string, options Options) *semanticAnalysis {
+	diagnostics := &diagnosticList{}
+	tokens := NewScanner(bytes.NewBufferString(source), diagnostics).ScanTokens()
+	statements := NewParser(tokens, diagnostics, options).Parse()
+
+	resolver := NewResolver(diagnostics)
+	resolver.bindings = make(map[tokenPosition]binding)
+	resolver.CheckGlobals(predefinedGlobals(options))
+	resolver.ResolveProgram(statements)
+
+	analysis := &semanticAnalysis{tokens: tokens, bindings: resolver.bindings, declarations: make(map[tokenPosition]SemanticKind)}
+	analysis.declare(statements, false)
+	return analysis
+}
+
+// declare records the kinds of the functions, classes, methods and parameters declared by
+// the statements and the statements nested in them.
+func (sa *semanticAnalysis) declare(statements []ast.Stmt, methods bool) {
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.Block:
+			sa.declare(stmt.Statements, false)
+		case *ast.IfStmt:
+			sa.declare([]ast.Stmt{stmt.ThenBranch, stmt.ElseBranch}, false)
+		case *ast.WhileStmt:
+			sa.declare([]ast.Stmt{stmt.Body}, false)
+		case *ast.ForInStmt:
+			sa.declare([]ast.Stmt{stmt.Body}, false)
+		case *ast.TryStmt:
+			sa.declare(stmt.Body, false)
+			if stmt.Catch != nil {
+				sa.declare(stmt.Catch.Body, false)
+			}
+
+			sa.declare(stmt.Finally, false)
+		case *ast.FunctionStmt:
+			kind := SemanticFunction
+			if methods {
+				kind = SemanticMethod
+			}
+
+			sa.declarations[positionOf(stmt.Name)] = kind
+			for _, param := range stmt.Params {
+				sa.declarations[positionOf(param)] = SemanticParameter
+			}
+
+			sa.declare(stmt.Body, false)
+		case *ast.ClassStmt:
+			sa.declarations[positionOf(stmt.Name)] = SemanticClass
+			for _, method := range stmt.Methods {
+				sa.declare([]ast.Stmt{method}, true)
+			}
+		}
+	}
+}
+
+// classify returns the semantic token of the nth token, false for the tokens editors have
+// nothing to highlight on, like punctuation.
+func (sa *semanticAnalysis) classify(n int) (SemanticToken, bool) {
+	token := sa.tokens[n]
+	if token.Column == 0 || strings.Contains(token.Lexeme, "\n") {
+		return SemanticToken{}, false
+	}
+
+	semantic := SemanticToken{Line: token.Line, Column: token.Column, Length: utf8.RuneCountInString(token.Lexeme)}
+	switch token.Type {
+	case ast.String:
+		semantic.Kind = SemanticString
+	case ast.Number:
+		semantic.Kind = SemanticNumber
+	case ast.Minus, ast.Plus, ast.Slash, ast.Star, ast.Bang, ast.BangEqual, ast.Equal, ast.EqualEqual, ast.Greater,
+		ast.GreaterEqual, ast.Less, ast.LessEqual, ast.DotDot, ast.DotDotEqual, ast.Pipe:
+		semantic.Kind = SemanticOperator
+	case ast.Identifiers:
+		sa.classifyName(n, &semantic)
+	default:
+		if token.Type < ast.And || token.Type > ast.In {
+			return SemanticToken{}, false
+		}
+
+		semantic.Kind = SemanticKeyword
+	}
+
+	return semantic, true
+}
+
+// classifyName classifies the identifier by what it refers to.
+func (sa *semanticAnalysis) classifyName(n int, semantic *SemanticToken) {
+	token := sa.tokens[n]
+	if sa.isProperty(n) {
+		semantic.Kind = SemanticProperty
+		if n+1 < len(sa.tokens) && sa.tokens[n+1].Type == ast.LeftParen {
+			semantic.Kind = SemanticMethod
+		}
+
+		return
+	}
+
+	if kind, ok := sa.declarations[positionOf(token)]; ok {
+		semantic.Kind = kind
+		semantic.Declaration = true
+		semantic.Global = sa.bindings[positionOf(token)].global
+		return
+	}
+
+	b, ok := sa.bindings[positionOf(token)]
+	if !ok {
+		semantic.Kind = SemanticVariable
+		if extensionKeywords[token.Lexeme] {
+			semantic.Kind = SemanticKeyword
+		}
+
+		return
+	}
+
+	semantic.Kind = SemanticVariable
+	if kind, ok := sa.declarations[positionOf(b.declaration)]; ok {
+		semantic.Kind = kind
+	}
+
+	semantic.Declaration = positionOf(b.declaration) == positionOf(token)
+	semantic.Global = b.global
+	semantic.Native = b.declaration.Column == 0
+}
+
+// isProperty reports if the nth token is the name of a property, following a '.'.
+func (sa *semanticAnalysis) isProperty(n int) bool {
+	return n > 0 && sa.tokens[n-1].Type == ast.Dot
+}
+
+// SemanticTokens classifies the tokens of the source for editors to highlight, in source
+// order. Names are classified by the declaration they refer to, so parameters, locals,
+// globals and properties can be told apart. Comments and punctuation are left out, and so
+// are strings spanning lines.
+func SemanticTokens(source string, options Options) []SemanticToken {
+	analysis := analyze(source, options)
+	tokens := make([]SemanticToken, 0, len(analysis.tokens))
+	for n := range analysis.tokens {
+		if semantic, ok := analysis.classify(n); ok {
+			tokens = append(tokens, semantic)
+		}
+	}
+
+	return tokens
+}
+
+// Highlights returns the occurrences of the name at the line and column, counted from 1:
+// the declaration and the references bound to it, or every property of the same name for
+// a property, since properties are only known at runtime. It's empty if there is no name
+// there.
+func Highlights(source string, options Options, line, column int) []Highlight {
+	analysis := analyze(source, options)
+
+	at := -1
+	for n, token := range analysis.tokens {
+		length := utf8.RuneCountInString(token.Lexeme)
+		if token.Type == ast.Identifiers && token.Line == line && token.Column <= column && column <= token.Column+length {
+			at = n
+			break
+		}
+	}
+
+	if at < 0 {
+		return []Highlight{}
+	}
+
+	target := analysis.tokens[at]
+	property := analysis.isProperty(at)
+	declaration := positionOf(target)
+	if b, ok := analysis.bindings[positionOf(target)]; ok && !property {
+		declaration = positionOf(b.declaration)
+	}
+
+	highlights := make([]Highlight, 0)
+	for n, token := range analysis.tokens {
+		if token.Type != ast.Identifiers || token.Lexeme != target.Lexeme || analysis.isProperty(n) != property {
+			continue
+		}
+
+		write := n+1 < len(analysis.tokens) && analysis.tokens[n+1].Type == ast.Equal
+		if !property {
+			b, ok := analysis.bindings[positionOf(token)]
+			bound := ok && positionOf(b.declaration) == declaration
+			if !bound && positionOf(token) != declaration {
+				continue
+			}
+
+			_, declared := analysis.declarations[positionOf(token)]
+			write = write || declared || positionOf(token) == declaration
+		}
+
+		highlights = append(highlights, Highlight{Line: token.Line, Column: token.Column, Length: utf8.RuneCountInString(token.Lexeme), Write: write})
+	}
+
+	return highlights
+}
@@ -0,0 +1,283 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// LoxSequence is a lazily evaluated sequence of values, returned by seq(collection) and
+// countFrom(n). Its methods map, filter, take and skip return new sequences without
+// computing any element, the elements are only computed one by one as a for-in loop,
+// toArray or reduce asks for them. That makes sequences over infinite sources usable, as
+// long as something like take ends them.
+type LoxSequence struct {
+	// start returns a function producing the elements from the first one, every time a
+	// sequence is iterated.
+	start func() sequenceNext
+}
+
+// maxSequenceArray is the most elements toArray collects, so draining an infinite sequence
+// fails instead of running out of memory.
+var maxSequenceArray = 1 << 24
+
+// sequenceNext produces the next element of a sequence, returning false at the end. An
+// error ends the sequence, like a function passed to map failing.
+type sequenceNext func() (interface{}, bool, error)
+
+func (s *LoxSequence) Iterate() Iterator {
+	return &sequenceIterator{next: s.start()}
+}
+
+func (s *LoxSequence) String() string {
+	return "<sequence>"
+}
+
+// sequenceOf returns a sequence of the elements of the iterator that iterate returns.
+func sequenceOf(iterate func() Iterator) *LoxSequence {
+	return &LoxSequence{start: func() sequenceNext {
+		iterator := iterate()
+		return func() (interface{}, bool, error) {
+			element, ok := iterator.Next()
+			if !ok {
+				return nil, false, iteratorErr(iterator)
+			}
+
+			return element, true, nil
+		}
+	}}
+}
+
+// property returns the method of the sequence with the given name.
+func (s *LoxSequence) property(name ast.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "map":
+		doc := "map(f) returns a sequence of the results of calling f with every element."
+		return NewNativeFunction("map", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			f, ok := arguments[0].(LoxCallable)
+			if !ok {
+				return nil, NewNativeError("map expects a function")
+			}
+
+			return s.then(func(next sequenceNext) sequenceNext {
+				return func() (interface{}, bool, error) {
+					element, ok, err := next()
+					if !ok || err != nil {
+						return nil, false, err
+					}
+
+					result, err := callValue(interpreter, f, []interface{}{element})
+					return result, err == nil, err
+				}
+			}), nil
+		}), nil
+	case "filter":
+		doc := "filter(f) returns a sequence of the elements for which f returns a truthy value."
+		return NewNativeFunction("filter", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			f, ok := arguments[0].(LoxCallable)
+			if !ok {
+				return nil, NewNativeError("filter expects a function")
+			}
+
+			return s.then(func(next sequenceNext) sequenceNext {
+				return func() (interface{}, bool, error) {
+					for {
+						element, ok, err := next()
+						if !ok || err != nil {
+							return nil, false, err
+						}
+
+						if err := interpreter.interrupted(ast.Token{}); err != nil {
+							return nil, false, err
+						}
+
+						keep, err := callValue(interpreter, f, []interface{}{element})
+						if err != nil {
+							return nil, false, err
+						}
+
+						if interpreter.isTruthy(keep) {
+							return element, true, nil
+						}
+					}
+				}
+			}), nil
+		}), nil
+	case "take":
+		doc := "take(n) returns a sequence of the first n elements."
+		return NewNativeFunction("take", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			n, err := sequenceCount("take", arguments[0])
+			if err != nil {
+				return nil, err
+			}
+
+			return s.then(func(next sequenceNext) sequenceNext {
+				taken := 0
+				return func() (interface{}, bool, error) {
+					if taken >= n {
+						return nil, false, nil
+					}
+
+					taken++
+					return next()
+				}
+			}), nil
+		}), nil
+	case "skip":
+		doc := "skip(n) returns a sequence of the elements after the first n."
+		return NewNativeFunction("skip", doc, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			n, err := sequenceCount("skip", arguments[0])
+			if err != nil {
+				return nil, err
+			}
+
+			return s.then(func(next sequenceNext) sequenceNext {
+				remaining := n
+				return func() (interface{}, bool, error) {
+					for ; remaining > 0; remaining-- {
+						if err := interpreter.interrupted(ast.Token{}); err != nil {
+							return nil, false, err
+						}
+
+						if _, ok, err := next(); !ok || err != nil {
+							return nil, false, err
+						}
+					}
+
+					return next()
+				}
+			}), nil
+		}), nil
+	case "toArray":
+		doc := fmt.Sprintf("toArray() computes the elements and returns them in an array. It fails for a sequence of more than %d elements, like an infinite one.", maxSequenceArray)
+		return NewNativeFunction("toArray", doc, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			elements := make([]interface{}, 0)
+			next := s.start()
+			for {
+				if err := interpreter.interrupted(ast.Token{}); err != nil {
+					return nil, err
+				}
+
+				element, ok, err := next()
+				if err != nil {
+					return nil, err
+				}
+
+				if !ok {
+					return NewLoxArray(elements), nil
+				}
+
+				if len(elements) == maxSequenceArray {
+					return nil, NewNativeError(fmt.Sprintf("toArray expects a sequence of at most %d elements, take some of an infinite sequence first", maxSequenceArray))
+				}
+
+				elements = append(elements, element)
+			}
+		}), nil
+	case "reduce":
+		doc := "reduce(f, initial) calls f with the result so far, starting with initial, and every element, and returns the last result."
+		return NewNativeFunction("reduce", doc, 2, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			f, ok := arguments[0].(LoxCallable)
+			if !ok {
+				return nil, NewNativeError("reduce expects a function")
+			}
+
+			result := arguments[1]
+			next := s.start()
+			for {
+				if err := interpreter.interrupted(ast.Token{}); err != nil {
+					return nil, err
+				}
+
+				element, ok, err := next()
+				if err != nil {
+					return nil, err
+				}
+
+				if !ok {
+					return result, nil
+				}
+
+				if result, err = callValue(interpreter, f, []interface{}{result, element}); err != nil {
+					return nil, err
+				}
+			}
+		}), nil
+	}
+
+	return nil, NewRuntimeError(name, "Undefined property '"+name.Lexeme+"'")
+}
+
+// then returns a sequence whose elements are produced by step from the elements of s.
+func (s *LoxSequence) then(step func(next sequenceNext) sequenceNext) *LoxSequence {
+	return &LoxSequence{start: func() sequenceNext {
+		return step(s.start())
+	}}
+}
+
+// sequenceCount checks the count passed to take and skip is a whole number that isn't
+// negative.
+func sequenceCount(method string, value interface{}) (int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return 0, NewNativeError(method + " expects a whole number that isn't negative")
+	}
+
+	return int(n), nil
+}
+
+type sequenceIterator struct {
+	next sequenceNext
+	err  error
+}
+
+func (si *sequenceIterator) Next() (interface{}, bool) {
+	if si.err != nil {
+		return nil, false
+	}
+
+	element, ok, err := si.next()
+	if err != nil {
+		si.err = err
+		return nil, false
+	}
+
+	return element, ok
+}
+
+func (si *sequenceIterator) Err() error {
+	return si.err
+}
+
+// seq returns a lazy sequence of the elements of a string or a collection.
+func seq(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if sequence, ok := arguments[0].(*LoxSequence); ok {
+		return sequence, nil
+	}
+
+	if _, ok := iterate(arguments[0]); !ok {
+		return nil, NewNativeError("seq expects a string or a collection")
+	}
+
+	return sequenceOf(func() Iterator {
+		iterator, _ := iterate(arguments[0])
+		return iterator
+	}), nil
+}
+
+// countFrom returns the infinite sequence of the numbers from n in steps of one.
+func countFrom(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	start, ok := arguments[0].(float64)
+	if !ok {
+		return nil, NewNativeError("countFrom expects a number")
+	}
+
+	return &LoxSequence{start: func() sequenceNext {
+		n := start
+		return func() (interface{}, bool, error) {
+			n++
+			return n - 1, true, nil
+		}
+	}}, nil
+}
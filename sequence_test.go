@@ -0,0 +1,69 @@
+package glox
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// drainingScripts never end on their own: they drain an infinite sequence.
+var drainingScripts = map[string]string{
+	"toArray": `print countFrom(1).toArray();`,
+	"reduce":  `fun add(a, b) { return a + b; } print countFrom(1).reduce(add, 0);`,
+	"filter":  `fun never(n) { return false; } print countFrom(1).filter(never).take(1).toArray();`,
+	"skip":    `print countFrom(1).skip(1e15).take(1).toArray();`,
+	"in":      `print 0 in countFrom(1);`,
+}
+
+func TestSequencesStopAtTheTimeLimit(t *testing.T) {
+	for name, source := range drainingScripts {
+		t.Run(name, func(t *testing.T) {
+			verdict := Judge(source, strings.NewReader(""), 50*time.Millisecond, DefaultOptions())
+			if verdict.Status != VerdictTimeLimitExceeded {
+				t.Errorf("expected the time limit to be exceeded, got %q: %+v", verdict.Status, verdict.Error)
+			}
+		})
+	}
+}
+
+func TestSequencesStopWhenTheCallIsCancelled(t *testing.T) {
+	for name, source := range drainingScripts {
+		t.Run(name, func(t *testing.T) {
+			program, diagnostics := Compile("fun drain() { "+source+" }", DefaultOptions())
+			if program == nil {
+				t.Fatalf("compile errors: %v", diagnostics)
+			}
+
+			interpreter := newProgramInterpreter(program.options)
+			interpreter.addLocals(program.locals)
+			if err := program.run(interpreter); err != nil {
+				t.Fatalf("running the script: %s", err.Error())
+			}
+
+			drain, _ := interpreter.Global("drain")
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := interpreter.Call(ctx, drain.(LoxCallable))
+			if err == nil || !strings.Contains(err.Error(), "Interrupted") {
+				t.Errorf("expected the call to be interrupted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestToArrayLimitsTheLength(t *testing.T) {
+	defer func(limit int) { maxSequenceArray = limit }(maxSequenceArray)
+	maxSequenceArray = 1000
+
+	_, err := runSource(t, `print countFrom(1).toArray();`, DefaultOptions())
+	if err == nil || !strings.Contains(err.Error(), "at most 1000 elements") {
+		t.Errorf("expected toArray to fail, got %v", err)
+	}
+
+	output, err := runSource(t, `print len(countFrom(1).take(1000).toArray());`, DefaultOptions())
+	if err != nil || output != "1000\n" {
+		t.Errorf("printed %q, %v", output, err)
+	}
+}
@@ -0,0 +1,212 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// allocsMetric is the runtime metric counting the bytes allocated on the heap.
+const allocsMetric = "/gc/heap/allocs:bytes"
+
+// FunctionStats are the statistics collected for a single lox function with --stats.
+type FunctionStats struct {
+	Name  string
+	Line  int
+	Calls int
+	// Time is the cumulative wall time spent in the function, including the functions it
+	// calls. Time spent in recursive calls is only counted once.
+	Time time.Duration
+	// Allocated is the cumulative number of bytes allocated while running the function,
+	// counted the same way as Time.
+	Allocated uint64
+
+	active         int
+	startTime      time.Time
+	startAllocated uint64
+}
+
+// ClassStats are the instance counts of a single lox class collected with --stats.
+type ClassStats struct {
+	Name    string
+	Created int64
+	// Live is the number of instances that were not garbage collected yet. Instances are
+	// only known to be dead once the collector ran, so it can lag behind.
+	Live int64
+}
+
+// RunStats are the resources used by a single run of a program or evaluation of an
+// expression, for embedders that meter scripts or tune their limits.
+type RunStats struct {
+	// Nodes is the number of statements executed and expressions evaluated.
+	Nodes int
+	// Calls is the number of calls made, to lox functions, native functions and classes.
+	Calls int
+	// MaxDepth is the deepest the lox call stack got.
+	MaxDepth int
+	// Time is the wall time of the run.
+	Time time.Duration
+	// Allocated is the number of bytes allocated on the heap during the run. The Go runtime
+	// only counts them for the whole process, so it includes whatever other goroutines
+	// allocated at the same time.
+	Allocated uint64
+
+	startTime      time.Time
+	startAllocated uint64
+	sample         []metrics.Sample
+}
+
+// start resets the counters and starts the clock of a new run.
+func (s *RunStats) start() {
+	*s = RunStats{startTime: time.Now(), sample: []metrics.Sample{{Name: allocsMetric}}}
+	s.startAllocated = readAllocated(s.sample)
+}
+
+// stop stops the clock of the run.
+func (s *RunStats) stop() {
+	s.Time = time.Since(s.startTime)
+	s.Allocated = readAllocated(s.sample) - s.startAllocated
+}
+
+// profiler collects FunctionStats for every lox function called and ClassStats for every
+// class instantiated while it's enabled.
+type profiler struct {
+	functions map[*ast.FunctionStmt]*FunctionStats
+	classes   map[*LoxClass]*ClassStats
+	sample    []metrics.Sample
+}
+
+func newProfiler() *profiler {
+	return &profiler{
+		functions: make(map[*ast.FunctionStmt]*FunctionStats),
+		classes:   make(map[*LoxClass]*ClassStats),
+		sample:    []metrics.Sample{{Name: allocsMetric}},
+	}
+}
+
+func (p *profiler) allocated() uint64 {
+	return readAllocated(p.sample)
+}
+
+// readAllocated reads the number of bytes allocated on the heap so far into the sample.
+func readAllocated(sample []metrics.Sample) uint64 {
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
+
+// enter records a call to the function. Only the outermost of a series of recursive calls
+// starts the clock.
+func (p *profiler) enter(function LoxFunction) {
+	stats, ok := p.functions[function.declaration]
+	if !ok {
+		stats = &FunctionStats{Name: function.Name(), Line: function.declaration.Name.Line}
+		p.functions[function.declaration] = stats
+	}
+
+	stats.Calls++
+	stats.active++
+	if stats.active == 1 {
+		stats.startTime = time.Now()
+		stats.startAllocated = p.allocated()
+	}
+}
+
+// exit records the return from the function.
+func (p *profiler) exit(function LoxFunction) {
+	stats := p.functions[function.declaration]
+	stats.active--
+	if stats.active == 0 {
+		stats.Time += time.Since(stats.startTime)
+		stats.Allocated += p.allocated() - stats.startAllocated
+	}
+}
+
+// instantiated counts a new instance of its class. A finalizer counts the instance as dead
+// when it's garbage collected, finalizers run on their own goroutine so the live count is
+// updated atomically.
+func (p *profiler) instantiated(instance *LoxInstance) {
+	stats, ok := p.classes[instance.klass]
+	if !ok {
+		stats = &ClassStats{Name: instance.klass.name}
+		p.classes[instance.klass] = stats
+	}
+
+	stats.Created++
+	atomic.AddInt64(&stats.Live, 1)
+	runtime.SetFinalizer(instance, func(*LoxInstance) {
+		atomic.AddInt64(&stats.Live, -1)
+	})
+}
+
+// ClassStats returns the instance counts of every class instantiated, the class with the
+// most instances created first. It runs the garbage collector first, so the live counts
+// are as fresh as they can be.
+func (p *profiler) ClassStats() []ClassStats {
+	runtime.GC()
+
+	stats := make([]ClassStats, 0, len(p.classes))
+	for _, s := range p.classes {
+		stats = append(stats, ClassStats{Name: s.Name, Created: s.Created, Live: atomic.LoadInt64(&s.Live)})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Created != stats[j].Created {
+			return stats[i].Created > stats[j].Created
+		}
+
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats
+}
+
+// Stats returns the statistics of every function called, the most time consuming first.
+func (p *profiler) Stats() []FunctionStats {
+	stats := make([]FunctionStats, 0, len(p.functions))
+	for _, s := range p.functions {
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Time != stats[j].Time {
+			return stats[i].Time > stats[j].Time
+		}
+
+		return stats[i].Line < stats[j].Line
+	})
+
+	return stats
+}
+
+// printStats prints the statistics as a table.
+func printStats(w io.Writer, stats []FunctionStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "function\tline\tcalls\ttime\tallocated\t")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%dB\t\n", s.Name, s.Line, s.Calls, s.Time.Round(time.Microsecond), s.Allocated)
+	}
+
+	tw.Flush()
+}
+
+// printClassStats prints the instance counts as a table.
+func printClassStats(w io.Writer, stats []ClassStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "class\tcreated\tlive\t")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t\n", s.Name, s.Created, s.Live)
+	}
+
+	tw.Flush()
+}
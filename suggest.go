@@ -0,0 +1,78 @@
+package glox
+
+import "sort"
+
+// undefinedMessage returns the error for an undefined variable or property, what is
+// "variable" or "property". It suggests the closest of the candidate names, if one is close
+// enough to be a typo of the name.
+func undefinedMessage(what, name string, candidates []string) string {
+	message := "Undefined " + what + " '" + name + "'"
+	if suggestion := closestName(name, candidates); suggestion != "" {
+		message += ", did you mean '" + suggestion + "'?"
+	}
+
+	return message
+}
+
+// closestName returns the candidate with the smallest edit distance to the name, or an
+// empty string if none is within a third of the length of the name, at least one edit. Ties
+// go to the candidate that sorts first, so the suggestion doesn't depend on map order.
+func closestName(name string, candidates []string) string {
+	maxDistance := len([]rune(name)) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+
+	closest, closestDistance := "", maxDistance+1
+	for _, candidate := range sorted {
+		if candidate == name {
+			continue
+		}
+
+		if distance := editDistance(name, candidate); distance < closestDistance {
+			closest, closestDistance = candidate, distance
+		}
+	}
+
+	return closest
+}
+
+// editDistance is the Levenshtein distance of the two strings, the number of characters
+// to insert, delete or replace to turn one into the other.
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+
+	// previous and current are two rows of the distance matrix, previous[j] is the
+	// distance between the first i-1 characters of x and the first j characters of y.
+	previous := make([]int, len(y)+1)
+	current := make([]int, len(y)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(x); i++ {
+		current[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(y)]
+}
@@ -0,0 +1,157 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// The kinds of tags, the letters ctags uses for them.
+const (
+	TagFunction = "f"
+	TagClass    = "c"
+	TagMethod   = "m"
+	TagVariable = "v"
+)
+
+// Tag is a definition an editor can jump to: a function, a class, a method or a global
+// variable. Class is the class of methods.
+type Tag struct {
+	Name   string
+	Kind   string
+	Line   int
+	Column int
+	Class  string
+}
+
+// TagFile is the tags of a file along with its source, which tags files quote the lines of
+// the definitions from.
+type TagFile struct {
+	Path   string
+	Source string
+	Tags   []Tag
+}
+
+// Tags returns the definitions of the program that are visible from other files: the
+// functions, classes and variables declared at the top level and the methods of the
+// classes, in the order they are declared.
+func Tags(statements []ast.Stmt) []Tag {
+	tags := make([]Tag, 0)
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.FunctionStmt:
+			tags = append(tags, newTag(stmt.Name, TagFunction, ""))
+		case *ast.ClassStmt:
+			tags = append(tags, newTag(stmt.Name, TagClass, ""))
+			for _, method := range stmt.Methods {
+				tags = append(tags, newTag(method.Name, TagMethod, stmt.Name.Lexeme))
+			}
+		case *ast.VarStmt:
+			tags = append(tags, newTag(stmt.Name, TagVariable, ""))
+		case *ast.DestructureStmt:
+			for _, name := range stmt.Names {
+				tags = append(tags, newTag(name, TagVariable, ""))
+			}
+		}
+	}
+
+	return tags
+}
+
+func newTag(name ast.Token, kind, class string) Tag {
+	return Tag{Name: name.Lexeme, Kind: kind, Line: name.Line, Column: name.Column, Class: class}
+}
+
+// ctagsEntry is a line of a ctags file, with what it's sorted by.
+type ctagsEntry struct {
+	name string
+	path string
+	line int
+	text string
+}
+
+// WriteCtags writes the tags of the files in the extended ctags format vi and most editors
+// read, sorted by name so editors can binary search them. Definitions are found by a search
+// pattern of their line, so the tags still work after lines are added above them.
+func WriteCtags(w io.Writer, files []TagFile) error {
+	entries := make([]ctagsEntry, 0)
+	for _, file := range files {
+		lines := strings.Split(file.Source, "\n")
+		for _, tag := range file.Tags {
+			line := ""
+			if tag.Line >= 1 && tag.Line <= len(lines) {
+				line = strings.TrimSuffix(lines[tag.Line-1], "\r")
+			}
+
+			pattern := strings.NewReplacer(`\`, `\\`, "/", `\/`).Replace(line)
+			text := fmt.Sprintf("%s\t%s\t/^%s$/;\"\t%s\tline:%d", tag.Name, file.Path, pattern, tag.Kind, tag.Line)
+			if tag.Class != "" {
+				text += "\tclass:" + tag.Class
+			}
+
+			entries = append(entries, ctagsEntry{name: tag.Name, path: file.Path, line: tag.Line, text: text})
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].name != entries[b].name {
+			return entries[a].name < entries[b].name
+		}
+
+		if entries[a].path != entries[b].path {
+			return entries[a].path < entries[b].path
+		}
+
+		return entries[a].line < entries[b].line
+	})
+
+	if _, err := io.WriteString(w, "!_TAG_FILE_FORMAT\t2\t/extended format/\n!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/\n!_TAG_PROGRAM_NAME\tglox\t//\n"); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := io.WriteString(w, entry.text+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteEtags writes the tags of the files in the etags format Emacs reads: a section per
+// file, with the line of every definition up to its name, the name, the line number and
+// the byte offset of the line.
+func WriteEtags(w io.Writer, files []TagFile) error {
+	for _, file := range files {
+		lines := strings.SplitAfter(file.Source, "\n")
+		offsets := make([]int, len(lines))
+		for n := 1; n < len(lines); n++ {
+			offsets[n] = offsets[n-1] + len(lines[n-1])
+		}
+
+		var section strings.Builder
+		for _, tag := range file.Tags {
+			if tag.Line < 1 || tag.Line > len(lines) {
+				continue
+			}
+
+			// The text goes up to the end of the name, the column counts characters.
+			runes := []rune(strings.TrimRight(lines[tag.Line-1], "\r\n"))
+			end := tag.Column - 1 + len([]rune(tag.Name))
+			if tag.Column < 1 || end > len(runes) {
+				end = len(runes)
+			}
+
+			fmt.Fprintf(&section, "%s\x7f%s\x01%d,%d\n", string(runes[:end]), tag.Name, tag.Line, offsets[tag.Line-1])
+		}
+
+		if _, err := fmt.Fprintf(w, "\x0c\n%s,%d\n%s", file.Path, section.Len(), section.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package glox
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// template is a text template compiled into a lox program. The text between the tags is
+// written by calls to __text, which refer to the text by its index so it never has to be
+// escaped as a lox string. {{ expr }} tags become calls to __write, and {% stmt %} tags are
+// copied into the program as they are, so statements can wrap the text around them:
+//
+//	{% for (var i = 1; i <= 3; i = i + 1) { %}item {{ i }}
+//	{% } %}
+//
+// The program keeps every tag on the line it had in the template, so errors point at the
+// template's lines.
+type template struct {
+	program string
+	texts   []string
+}
+
+// compileTemplate compiles the template source. The expressions in {{ }} tags are checked
+// like sandboxed expressions, writing output should not change any variables. A tag writes
+// a single value, so a comma expression, which would become a call to __write with more
+// than one argument, is an error.
+func compileTemplate(source string) (*template, []Diagnostic) {
+	t := &template{}
+	program := strings.Builder{}
+	diagnostics := make([]Diagnostic, 0)
+	line := 1
+
+	for len(source) > 0 {
+		start := nextTag(source)
+		if start > 0 {
+			text := source[:start]
+			program.WriteString(fmt.Sprintf("__text(%d);%s", len(t.texts), strings.Repeat("\n", strings.Count(text, "\n"))))
+			t.texts = append(t.texts, text)
+			line += strings.Count(text, "\n")
+			source = source[start:]
+		}
+
+		if source == "" {
+			break
+		}
+
+		closing := "}}"
+		if source[1] == '%' {
+			closing = "%}"
+		}
+
+		end := strings.Index(source, closing)
+		if end < 0 {
+			message := "Unterminated '" + source[:2] + "' tag"
+			diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, Line: line, Message: message, Code: diagnosticCode(phaseScanner, message)})
+			break
+		}
+
+		code := source[2:end]
+		if closing == "}}" {
+			if expr, exprDiagnostics := ParseExpr(code); HasErrors(exprDiagnostics) {
+				for _, diagnostic := range exprDiagnostics {
+					diagnostic.Line += line - 1
+					diagnostics = append(diagnostics, diagnostic)
+				}
+			} else if comma, ok := expr.(*ast.Binary); ok && comma.Operator.Type == ast.Comma {
+				message := "Expect a single expression in a '{{ }}' tag, write a tag for each value"
+				diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, Line: line + comma.Operator.Line - 1, Where: " at ','", Message: message, Code: diagnosticCode(phaseParser, message)})
+			} else if _, err := expr.Accept(sandboxChecker{}); err != nil {
+				runErr := err.(*RuntimeError)
+				diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, Line: line + runErr.token.Line - 1, Message: runErr.message, Code: runErr.Code()})
+			}
+
+			program.WriteString("__write(" + code + ");")
+		} else {
+			program.WriteString(code)
+		}
+
+		line += strings.Count(code, "\n")
+		source = source[end+2:]
+	}
+
+	t.program = program.String()
+	return t, diagnostics
+}
+
+// nextTag returns the index of the first {{ or {% tag in the source, or the length of the
+// source if there's none.
+func nextTag(source string) int {
+	start := len(source)
+	for _, open := range []string{"{{", "{%"} {
+		if i := strings.Index(source, open); i >= 0 && i < start {
+			start = i
+		}
+	}
+
+	return start
+}
+
+// RunTemplate renders the template file to the interpreter's output. It exits with status
+// 74 if the file can't be read, and like RunFile if the template has errors.
+func (r *Runtime) RunTemplate(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("error reading file: %s\n", err.Error())
+		os.Exit(74)
+	}
+
+	t, diagnostics := compileTemplate(string(data))
+	for _, diagnostic := range diagnostics {
+		r.Report(diagnostic)
+	}
+
+	if !r.hadError {
+		r.interpreter.globals.Define("__text", NewNativeFunction("__text", "__text(index) writes the text of the template at the index.", 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			index, ok := toFloat64(arguments[0])
+			if !ok || int(index) < 0 || int(index) >= len(t.texts) {
+				return nil, NewNativeError("__text expects the index of a text of the template")
+			}
+
+			_, err := fmt.Fprint(interpreter.out, t.texts[int(index)])
+			return nil, err
+		}))
+		r.interpreter.globals.Define("__write", NewNativeFunction("__write", "__write(value) writes the value without a trailing newline.", 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			_, err := fmt.Fprint(interpreter.out, interpreter.stringify(arguments[0]))
+			return nil, err
+		}))
+
+		r.scriptMode = true
+		r.run(t.program)
+	}
+
+	r.finish()
+
+	if r.hadError {
+		os.Exit(65)
+	}
+
+	if r.hadRuntimeError {
+		os.Exit(70)
+	}
+}
@@ -0,0 +1,33 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileTemplateRejectsCommaExpressions(t *testing.T) {
+	source := "<ul>\n{% for (var i = 0; i < 2; i = i + 1) { %}\n<li>{{ i, i + 1 }}</li>\n{% } %}\n</ul>\n"
+	_, diagnostics := compileTemplate(source)
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got %v", diagnostics)
+	}
+
+	diagnostic := diagnostics[0]
+	if diagnostic.Severity != SeverityError || diagnostic.Line != 3 || !strings.Contains(diagnostic.Message, "single expression") {
+		t.Errorf("unexpected diagnostic %v", diagnostic)
+	}
+}
+
+func TestCompileTemplateAcceptsSingleValues(t *testing.T) {
+	for _, tag := range []string{"{{ i }}", "{{ max(i, 2) }}", "{{ [i, 2] }}", "{{ (i, 2) }}", `{{ {"a": i, "b": 2} }}`} {
+		template, diagnostics := compileTemplate("{% var i = 1; %}" + tag)
+		if HasErrors(diagnostics) {
+			t.Errorf("%s: unexpected diagnostics %v", tag, diagnostics)
+			continue
+		}
+
+		if !strings.Contains(template.program, "__write(") {
+			t.Errorf("%s: no call to __write in %q", tag, template.program)
+		}
+	}
+}
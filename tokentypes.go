@@ -1,53 +0,0 @@
-package glox
-
-type  TokenType int
-
-const (
-	// Single-character tokens.
-	LeftParen TokenType = iota
-	RightParen
-	LeftBrace
-	RightBrace
-	Comma
-	Dot
-	Minus
-	Plus
-	Semicolon
-	Slash
-	Star
-
-	// One or two character tokens.
-	Bang
-	BangEqual
-	Equal
-	EqualEqual
-	Greater
-	GreaterEqual
-	Less
-	LessEqual
-
-	// Literals
-	Identifiers
-	String
-	Number
-
-	// Keywords
-	And
-	Class
-	Else
-	False
-	Fun
-	For
-	If
-	Nil
-	Or
-	PRINT // conflicting with the Print{} stmt and I am too lazy to rename everything else for it.
-	Return
-	Super
-	This
-	True
-	Var
-	While
-
-	Eof
-)
\ No newline at end of file
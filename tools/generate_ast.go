@@ -48,7 +48,7 @@ func defineAst(outputDir, baseName string, astTypes []string) error {
 
 	w := bufio.NewWriter(f)
 
-	w.WriteString("package glox\n\n")
+	w.WriteString("package ast\n\n")
 	w.WriteString("type " + baseName + " interface {\n")
 	w.WriteString("    Accept(visitor Visitor" + baseName +") (interface{}, error)\n")
 	w.WriteString("}\n\n")
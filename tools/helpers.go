@@ -1,5 +1,8 @@
 package tools
 
+// IsString reports whether v is a string.
+//
+// Deprecated: the interpreter checks operand types with type assertions, use v.(string).
 func IsString(v interface{}) bool {
 	switch v.(type) {
 	case string:
@@ -9,6 +12,9 @@ func IsString(v interface{}) bool {
 	return false
 }
 
+// IsFloat64 reports whether v is a float64.
+//
+// Deprecated: the interpreter checks operand types with type assertions, use v.(float64).
 func IsFloat64(v interface{}) bool {
 	switch v.(type) {
 	case float64:
@@ -16,4 +22,4 @@ func IsFloat64(v interface{}) bool {
 	}
 
 	return false
-}
\ No newline at end of file
+}
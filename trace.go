@@ -0,0 +1,73 @@
+package glox
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// traceEvent is an event in the Chrome trace event format, which can be loaded in
+// about://tracing or Perfetto.
+type traceEvent struct {
+	Name      string                 `json:"name"`
+	Phase     string                 `json:"ph"`
+	Timestamp int64                  `json:"ts"`
+	Pid       int                    `json:"pid"`
+	Tid       int                    `json:"tid"`
+	Scope     string                 `json:"s,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+// ChromeTracer records the interpreter's events as Chrome trace events. Every function call
+// becomes a span and runtime errors become instant events.
+type ChromeTracer struct {
+	start  time.Time
+	events []traceEvent
+}
+
+func NewChromeTracer() *ChromeTracer {
+	return &ChromeTracer{start: time.Now(), events: make([]traceEvent, 0)}
+}
+
+// HandleEvent records the event. It can be passed to AddEventHandler.
+func (ct *ChromeTracer) HandleEvent(event Event) {
+	switch event := event.(type) {
+	case FunctionEntered:
+		ct.events = append(ct.events, traceEvent{
+			Name:      event.Function,
+			Phase:     "B",
+			Timestamp: ct.timestamp(event.Time),
+			Pid:       1,
+			Tid:       1,
+			Args:      map[string]interface{}{"line": event.Line},
+		})
+	case FunctionExited:
+		ct.events = append(ct.events, traceEvent{Name: event.Function, Phase: "E", Timestamp: ct.timestamp(event.Time), Pid: 1, Tid: 1})
+	case ErrorRaised:
+		ct.events = append(ct.events, traceEvent{
+			Name:      "runtime error",
+			Phase:     "i",
+			Timestamp: ct.timestamp(time.Now()),
+			Pid:       1,
+			Tid:       1,
+			Scope:     "g",
+			Args:      map[string]interface{}{"message": event.Message, "line": event.Line},
+		})
+	}
+}
+
+// timestamp returns the time in microseconds since the tracer started.
+func (ct *ChromeTracer) timestamp(t time.Time) int64 {
+	return t.Sub(ct.start).Microseconds()
+}
+
+// WriteTo writes the recorded events as a Chrome trace JSON file.
+func (ct *ChromeTracer) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(map[string]interface{}{"traceEvents": ct.events, "displayTimeUnit": "ms"})
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
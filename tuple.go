@@ -0,0 +1,87 @@
+package glox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// LoxTuple is a fixed list of values created with (a, b) literals, like the multiple values
+// a function returns. Tuples can't be changed, and two tuples are equal if their elements
+// are.
+type LoxTuple struct {
+	elements []interface{}
+}
+
+func NewLoxTuple(elements []interface{}) *LoxTuple {
+	return &LoxTuple{elements: elements}
+}
+
+// Len returns the number of elements of the tuple.
+func (t *LoxTuple) Len() int {
+	return len(t.elements)
+}
+
+// Elements returns the elements of the tuple. The slice must not be modified.
+func (t *LoxTuple) Elements() []interface{} {
+	return t.elements
+}
+
+func (t *LoxTuple) Iterate() Iterator {
+	return &arrayIterator{array: NewLoxArray(t.elements)}
+}
+
+// stringifyTuple prints the tuple like its literal, with the elements printed like the
+// elements of an array. A tuple can't hold itself, so there are no cycles to look for.
+func (i *Interpreter) stringifyTuple(t *LoxTuple, seen map[interface{}]bool) string {
+	elements := make([]string, 0, len(t.elements))
+	for _, element := range t.elements {
+		elements = append(elements, i.stringifyElement(element, seen))
+	}
+
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+func (i *Interpreter) VisitTupleExpr(expr *ast.Tuple) (interface{}, error) {
+	elements := make([]interface{}, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, value)
+	}
+
+	return NewLoxTuple(elements), nil
+}
+
+// VisitDestructureStmt declares a variable for every element of the value, which must be
+// a tuple or an array with exactly one element per name.
+func (i *Interpreter) VisitDestructureStmt(stmt *ast.DestructureStmt) error {
+	value, err := i.evaluate(stmt.Initializer)
+	if err != nil {
+		return err
+	}
+
+	var elements []interface{}
+	switch value := value.(type) {
+	case *LoxTuple:
+		elements = value.elements
+	case *LoxArray:
+		elements = value.elements
+	default:
+		return NewRuntimeError(stmt.Paren, "Only tuples and arrays can be destructured")
+	}
+
+	if len(elements) != len(stmt.Names) {
+		return NewRuntimeError(stmt.Paren, fmt.Sprintf("Expect %d values to destructure but got %d", len(stmt.Names), len(elements)))
+	}
+
+	for n, name := range stmt.Names {
+		i.define(name.Lexeme, elements[n])
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTuples(t *testing.T) {
+	output, err := runSource(t, `
+var t = (1, "a", nil);
+print t;
+print t[1];
+print len(t);
+var (x, y) = (2, 3);
+print x + y;
+var (p, q) = [4, 5];
+print p * q;
+print (1, 2) == (1, 2);
+print (1, 2) == (1, 3);
+print (1 + 2);
+`, DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := "(1, \"a\", nil)\na\n3\n5\n20\ntrue\nfalse\n3\n"
+	if output != expected {
+		t.Errorf("printed %q, expected %q", output, expected)
+	}
+}
+
+func TestTupleErrors(t *testing.T) {
+	tests := map[string]string{
+		`var (a, b) = (1, 2, 3);`:   "Expect 2 values to destructure but got 3",
+		`var (a, b) = [1];`:         "Expect 2 values to destructure but got 1",
+		`var (a, b) = 5;`:           "Only tuples and arrays can be destructured",
+		`var t = (1, 2); t[0] = 5;`: "Tuples can't be changed",
+	}
+
+	for source, message := range tests {
+		if _, err := runSource(t, source, DefaultOptions()); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected an error containing %q, got %v", source, message, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package util
+
+// PersistentMap is an immutable map from uint32 keys to values. Setting a key returns a new
+// map and leaves the old one untouched, while sharing everything but the path to the key
+// with it, so keeping old versions of a map around is cheap. The zero value is an empty map.
+//
+// The map is a trie that consumes the key four bits at a time, starting from the lowest
+// bits. A key is stored in the node where its remaining bits run out.
+type PersistentMap[V any] struct {
+	root *persistentNode[V]
+	size int
+}
+
+type persistentNode[V any] struct {
+	children [16]*persistentNode[V]
+	value    V
+	hasValue bool
+}
+
+// Get returns the value of the key and whether the key is in the map.
+func (m PersistentMap[V]) Get(key uint32) (V, bool) {
+	node := m.root
+	for node != nil && key != 0 {
+		node = node.children[key&0xf]
+		key >>= 4
+	}
+
+	if node == nil || !node.hasValue {
+		var zero V
+		return zero, false
+	}
+
+	return node.value, true
+}
+
+// Set returns a map with the key set to the value.
+func (m PersistentMap[V]) Set(key uint32, value V) PersistentMap[V] {
+	root, added := m.root.set(key, value)
+	if added {
+		return PersistentMap[V]{root: root, size: m.size + 1}
+	}
+
+	return PersistentMap[V]{root: root, size: m.size}
+}
+
+// Len returns the number of keys in the map.
+func (m PersistentMap[V]) Len() int {
+	return m.size
+}
+
+// Range calls f for every key and value in the map, in no particular order.
+func (m PersistentMap[V]) Range(f func(key uint32, value V)) {
+	m.root.walk(0, 0, f)
+}
+
+// set copies the node with the key set, it reports if the key is new.
+func (n *persistentNode[V]) set(key uint32, value V) (*persistentNode[V], bool) {
+	copied := &persistentNode[V]{}
+	if n != nil {
+		*copied = *n
+	}
+
+	if key == 0 {
+		copied.value = value
+		copied.hasValue = true
+		return copied, n == nil || !n.hasValue
+	}
+
+	child, added := copied.children[key&0xf].set(key>>4, value)
+	copied.children[key&0xf] = child
+	return copied, added
+}
+
+func (n *persistentNode[V]) walk(prefix uint32, shift uint, f func(key uint32, value V)) {
+	if n == nil {
+		return
+	}
+
+	if n.hasValue {
+		f(prefix, n.value)
+	}
+
+	for i, child := range n.children {
+		child.walk(prefix|uint32(i)<<shift, shift+4, f)
+	}
+}
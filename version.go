@@ -0,0 +1,79 @@
+package glox
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version and Commit describe the build. Releases set them with the linker, e.g.
+// -ldflags "-X github.com/iamsayantan/glox.Version=v1.2.0".
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// Backend is the execution engine running the scripts.
+const Backend = "tree"
+
+// VersionInfo returns a one line description of the build, for `glox version`.
+func VersionInfo() string {
+	commit := Commit
+	if commit == "" {
+		commit = vcsRevision()
+	}
+
+	if commit == "" {
+		return fmt.Sprintf("glox %s (%s interpreter, %s)", Version, Backend, runtime.Version())
+	}
+
+	return fmt.Sprintf("glox %s %s (%s interpreter, %s)", Version, commit, Backend, runtime.Version())
+}
+
+// vcsRevision returns the commit that go build embedded in the binary, if any.
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+
+	return ""
+}
+
+// defineBuildInfo defines the VERSION and __glox__ globals, which let scripts feature
+// detect. __glox__ is an instance whose fields describe the backend and the options the
+// runtime was started with.
+func (i *Interpreter) defineBuildInfo(options Options) {
+	info := NewLoxInstance(NewLoxClass("glox", nil, map[string]LoxFunction{}))
+	info.fields["version"] = Version
+	info.fields["backend"] = Backend
+	info.fields["prelude"] = !options.NoPrelude
+	info.fields["edition"] = options.Edition
+	info.fields["optionalSemicolons"] = options.OptionalSemicolons
+	info.fields["keywordArguments"] = options.KeywordArguments
+	info.fields["forIn"] = options.ForIn
+	info.fields["chainedComparisons"] = options.ChainedComparisons
+	info.fields["commaOperator"] = options.CommaOperator
+	info.fields["exceptions"] = options.Exceptions
+	info.fields["ranges"] = options.Ranges
+	info.fields["inOperator"] = options.InOperator
+	info.fields["pipeOperator"] = options.PipeOperator
+	info.fields["arrays"] = options.Arrays
+	info.fields["maps"] = options.Maps
+	info.fields["tuples"] = options.Tuples
+	info.fields["bigLiterals"] = options.BigLiterals
+	info.fields["extendedNumbers"] = options.ExtendedNumbers
+	info.fields["stringOperators"] = options.StringOperators
+	info.fields["bigNumbers"] = options.BigNumbers
+	info.fields["strictLogical"] = options.StrictLogical
+	info.fields["maxArguments"] = float64(options.MaxArguments)
+
+	i.globals.Define("VERSION", Version)
+	i.globals.Define("__glox__", info)
+}
@@ -0,0 +1,85 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/iamsayantan/glox/ast"
+)
+
+// WatchHit is a watched variable being defined or assigned. Line is the line of the
+// assignment, 0 for definitions.
+type WatchHit struct {
+	Name    string
+	Value   interface{}
+	Line    int
+	Defined bool
+}
+
+// WatchHandler is called when a watched variable is defined or assigned, right after the
+// new value is stored. Execution is paused while the handler runs, so it can look at the
+// call stack and evaluate expressions in its frames, as a debugger does.
+type WatchHandler func(hit WatchHit)
+
+// watchpoints are the watched variables of an interpreter, shared by all of its
+// environments.
+type watchpoints struct {
+	names   map[ast.Symbol]bool
+	handler WatchHandler
+}
+
+// hit calls the handler if the variable is watched.
+func (w *watchpoints) hit(symbol ast.Symbol, value interface{}, line int, defined bool) {
+	if w.names[symbol] && w.handler != nil {
+		w.handler(WatchHit{Name: symbol.String(), Value: value, Line: line, Defined: defined})
+	}
+}
+
+// Watch pauses execution, calling the watch handler, whenever a variable with the name is
+// defined or assigned, in any scope. Variables are watched in the environments created
+// after the call, so watchpoints should be set before running code.
+func (i *Interpreter) Watch(name string) {
+	i.watchpoints().names[ast.Intern(name)] = true
+}
+
+// Unwatch stops watching the variables with the name.
+func (i *Interpreter) Unwatch(name string) {
+	delete(i.watchpoints().names, ast.Intern(name))
+}
+
+// SetWatchHandler sets the handler called when a watched variable is defined or assigned.
+func (i *Interpreter) SetWatchHandler(handler WatchHandler) {
+	i.watchpoints().handler = handler
+}
+
+// watchpoints returns the watchpoints of the interpreter, attaching them to the global
+// environment the first time, from where new environments inherit them.
+func (i *Interpreter) watchpoints() *watchpoints {
+	if i.globals.watchpoints == nil {
+		i.globals.watchpoints = &watchpoints{names: make(map[ast.Symbol]bool)}
+		i.environment.watchpoints = i.globals.watchpoints
+	}
+
+	return i.globals.watchpoints
+}
+
+// printWatchHit returns a watch handler that prints every hit, along with the call stack,
+// for --watch.
+func (i *Interpreter) printWatchHit(w io.Writer) WatchHandler {
+	return func(hit WatchHit) {
+		where := ""
+		if hit.Line > 0 {
+			where = fmt.Sprintf(" [line %d]", hit.Line)
+		}
+
+		verb := "="
+		if hit.Defined {
+			verb = "defined as"
+		}
+
+		fmt.Fprintf(w, "watch: %s %s %s%s\n", hit.Name, verb, i.stringify(hit.Value), where)
+		for _, frame := range i.CallStack() {
+			fmt.Fprintf(w, "    in %s (line %d)\n", frame.Function, frame.Line)
+		}
+	}
+}